@@ -1,7 +1,9 @@
 package lookup
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
 )
 
 type addFunc struct {
@@ -23,6 +25,12 @@ func (ef *addFunc) Run(scope *Scope) Pathor {
 	v1 := leftRes.Raw()
 	v2 := rightRes.Raw()
 
+	if i1, i2, ok := jsonInts(v1, v2); ok {
+		if i, ok := addInt(i1, i2); ok {
+			return NewConstantor(scope.Path(), i)
+		}
+	}
+
 	f1, err1 := interfaceToFloat(v1)
 	f2, err2 := interfaceToFloat(v2)
 
@@ -63,6 +71,12 @@ func (ef *subtractFunc) Run(scope *Scope) Pathor {
 	v1 := leftRes.Raw()
 	v2 := rightRes.Raw()
 
+	if i1, i2, ok := jsonInts(v1, v2); ok {
+		if i, ok := subtractInt(i1, i2); ok {
+			return NewConstantor(scope.Path(), i)
+		}
+	}
+
 	f1, err1 := interfaceToFloat(v1)
 	f2, err2 := interfaceToFloat(v2)
 
@@ -99,6 +113,12 @@ func (ef *multiplyFunc) Run(scope *Scope) Pathor {
 	v1 := leftRes.Raw()
 	v2 := rightRes.Raw()
 
+	if i1, i2, ok := jsonInts(v1, v2); ok {
+		if i, ok := multiplyInt(i1, i2); ok {
+			return NewConstantor(scope.Path(), i)
+		}
+	}
+
 	f1, err1 := interfaceToFloat(v1)
 	f2, err2 := interfaceToFloat(v2)
 
@@ -201,3 +221,40 @@ func Modulo(left, right Runner) *moduloFunc {
 		right: right,
 	}
 }
+
+// jsonInts are the operands as int64s when both are integers decoded from JSON with UseNumber, which add, subtract and
+// multiply use as integers so the result keeps the precision a float64 would lose.
+func jsonInts(v1, v2 interface{}) (int64, int64, bool) {
+	n1, ok1 := v1.(json.Number)
+	n2, ok2 := v2.(json.Number)
+	if !ok1 || !ok2 {
+		return 0, 0, false
+	}
+	i1, ok1 := jsonInt(n1)
+	i2, ok2 := jsonInt(n2)
+	return i1, i2, ok1 && ok2
+}
+
+// addInt adds two integers, ok is false when the sum overflows an int64.
+func addInt(a, b int64) (int64, bool) {
+	s := a + b
+	return s, (b >= 0) == (s >= a)
+}
+
+// subtractInt subtracts two integers, ok is false when the difference overflows an int64.
+func subtractInt(a, b int64) (int64, bool) {
+	d := a - b
+	return d, (b >= 0) == (d <= a)
+}
+
+// multiplyInt multiplies two integers, ok is false when the product overflows an int64.
+func multiplyInt(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	p := a * b
+	if p/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return p, false
+	}
+	return p, true
+}
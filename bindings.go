@@ -0,0 +1,66 @@
+package lookup
+
+// Bindings is a frame of named values such as the variables of a query language. Frames are chained to the frame
+// they were created in so a name not bound locally is looked up in the enclosing frames, which gives lexical scoping
+// when a frame is captured along with a Scope.
+type Bindings struct {
+	parent *Bindings
+	values map[string]Pathor
+}
+
+// NewBindings creates an empty frame enclosed by parent, parent may be nil.
+func NewBindings(parent *Bindings) *Bindings {
+	return &Bindings{
+		parent: parent,
+	}
+}
+
+// Parent returns the enclosing frame or nil.
+func (b *Bindings) Parent() *Bindings {
+	if b == nil {
+		return nil
+	}
+	return b.parent
+}
+
+// Bind sets name in this frame, shadowing any binding of the same name in the enclosing frames.
+func (b *Bindings) Bind(name string, value Pathor) {
+	if b.values == nil {
+		b.values = map[string]Pathor{}
+	}
+	b.values[name] = value
+}
+
+// Lookup finds the value bound to name in this frame or the nearest enclosing frame that binds it.
+func (b *Bindings) Lookup(name string) (Pathor, bool) {
+	for f := b; f != nil; f = f.parent {
+		if v, ok := f.values[name]; ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// Enclose returns a copy of the scope with a new empty frame of bindings enclosed by the scope's current frame.
+// Bindings made through the returned scope are not visible through s.
+func (s *Scope) Enclose() *Scope {
+	c := *s
+	c.Bindings = NewBindings(s.Bindings)
+	return &c
+}
+
+// Bind sets name in the scope's current frame creating the frame if the scope doesn't have one yet.
+func (s *Scope) Bind(name string, value Pathor) {
+	if s.Bindings == nil {
+		s.Bindings = NewBindings(nil)
+	}
+	s.Bindings.Bind(name, value)
+}
+
+// Lookup finds the value bound to name in the scope's frames.
+func (s *Scope) Lookup(name string) (Pathor, bool) {
+	if s == nil {
+		return nil, false
+	}
+	return s.Bindings.Lookup(name)
+}
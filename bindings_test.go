@@ -0,0 +1,69 @@
+package lookup
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBindings(t *testing.T) {
+	outer := NewBindings(nil)
+	outer.Bind("a", Constant(1))
+	outer.Bind("b", Constant(2))
+	inner := NewBindings(outer)
+	inner.Bind("b", Constant(3))
+
+	tests := []struct {
+		name     string
+		bindings *Bindings
+		lookup   string
+		want     interface{}
+		found    bool
+	}{
+		{name: "local", bindings: inner, lookup: "b", want: 3, found: true},
+		{name: "enclosing", bindings: inner, lookup: "a", want: 1, found: true},
+		{name: "shadowing doesn't change enclosing", bindings: outer, lookup: "b", want: 2, found: true},
+		{name: "missing", bindings: inner, lookup: "c", found: false},
+		{name: "nil frame", bindings: nil, lookup: "a", found: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, ok := tt.bindings.Lookup(tt.lookup)
+			if ok != tt.found {
+				t.Fatalf("Lookup(%q) found = %v, want %v", tt.lookup, ok, tt.found)
+			}
+			if !ok {
+				return
+			}
+			if diff := cmp.Diff(tt.want, v.Raw()); diff != "" {
+				t.Errorf("Lookup(%q) mismatch: %s", tt.lookup, diff)
+			}
+		})
+	}
+}
+
+func TestScopeBindingsPropagate(t *testing.T) {
+	root := Reflect(map[string]interface{}{"a": []int{1, 2}})
+	scope := NewScope(nil, root).Enclose()
+	scope.Bind("x", Constant("bound"))
+
+	for name, s := range map[string]*Scope{
+		"Nest": scope.Nest(root.Find("a")),
+		"Next": scope.Next(root.Find("a")),
+		"Copy": scope.Copy(),
+	} {
+		if v, ok := s.Lookup("x"); !ok || v.Raw() != "bound" {
+			t.Errorf("%s: expected binding to be visible, got %v %v", name, v, ok)
+		}
+	}
+
+	enclosed := scope.Enclose()
+	enclosed.Bind("y", Constant("inner"))
+	if _, ok := scope.Lookup("y"); ok {
+		t.Errorf("binding made in an enclosed scope leaked to its enclosing scope")
+	}
+	if v, ok := enclosed.Lookup("x"); !ok || v.Raw() != "bound" {
+		t.Errorf("expected enclosed scope to see enclosing binding, got %v %v", v, ok)
+	}
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"encoding/json"
-	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -28,6 +27,7 @@ Options:
   -n         prefix results with their index
   -0         use NUL as output delimiter
   -count     only print the number of matched results
+  -each      query each element of a top-level array as it is read
 `, fs.Name())
 }
 
@@ -46,6 +46,7 @@ func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
 	number := fs.Bool("n", false, "number results")
 	nullDelim := fs.Bool("0", false, "use NUL as delimiter")
 	countOnly := fs.Bool("count", false, "only print match count")
+	each := fs.Bool("each", false, "query each element of a top-level array")
 	fs.Usage = func() { usage(fs) }
 	if err := fs.Parse(args); err != nil {
 		return err
@@ -77,8 +78,6 @@ func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
 		r = f
 	}
 
-	dec := json.NewDecoder(r)
-
 	var re *regexp.Regexp
 	var err error
 	if *grepExpr != "" {
@@ -91,15 +90,7 @@ func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
 	index := 0
 	count := 0
 	first := true
-	for {
-		var doc interface{}
-		err := dec.Decode(&doc)
-		if errors.Is(err, io.EOF) {
-			break
-		}
-		if err != nil {
-			return fmt.Errorf("decode: %w", err)
-		}
+	query := func(doc interface{}) error {
 		for _, q := range queries {
 			res := lookup.QuerySimplePath(doc, q)
 			if res == nil {
@@ -144,6 +135,39 @@ func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
 			}
 			index++
 		}
+		return nil
+	}
+
+	if *each {
+		// The elements of an array are decoded one at a time rather than the whole document at once
+		switch doc := lookup.JsonReader(r).(type) {
+		case *lookup.JsonStream:
+			for _, item := range doc.All() {
+				if err := query(item); err != nil {
+					return err
+				}
+			}
+			if err := doc.Err(); err != nil {
+				return fmt.Errorf("decode: %w", err)
+			}
+		case *lookup.Invalidor:
+			return fmt.Errorf("decode: %w", doc)
+		default:
+			if err := query(doc); err != nil {
+				return err
+			}
+		}
+	} else {
+		// Any number of documents, as in JSON Lines
+		docs := lookup.JsonLines(r)
+		for _, doc := range docs.All() {
+			if err := query(doc); err != nil {
+				return err
+			}
+		}
+		if err := docs.Err(); err != nil {
+			return fmt.Errorf("decode: %w", err)
+		}
 	}
 	if *countOnly {
 		_, _ = fmt.Fprint(stdout, count)
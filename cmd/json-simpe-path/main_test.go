@@ -9,7 +9,7 @@ import (
 	"testing"
 )
 
-const exampleJSON = `{"name":"foo","spec":{"replicas":3},"metadata":{"name":"prod-service"}}`
+const exampleJSON = `{"name":"foo","spec":{"replicas":3},"metadata":{"name":"prod-service","labels":{"app.kubernetes.io/name":"web"}}}`
 
 func TestExamples(t *testing.T) {
 	tmp := t.TempDir()
@@ -28,6 +28,11 @@ func TestExamples(t *testing.T) {
 		{"raw", []string{"-raw", ".spec.replicas"}, exampleJSON, "3"},
 		{"grep", []string{"-f", fname, "-grep", "^prod", "-raw", ".metadata.name"}, "", "prod-service"},
 		{"count", []string{"-f", fname, "-count", ".metadata.name"}, "", "1"},
+		{"quoted", []string{"-f", fname, "-raw", `.metadata.labels."app.kubernetes.io/name"`}, "", "web"},
+		{"each", []string{"-each", "-raw", ".name"}, `[{"name":"a"},{"name":"b"}]`, "a\nb"},
+		{"lines", []string{"-raw", ".name"}, "{\"name\":\"a\"}\n{\"name\":\"b\"}\n", "a\nb"},
+		{"each object", []string{"-each", "-raw", ".name"}, exampleJSON, "foo"},
+		{"bracket", []string{"-f", fname, "-raw", `.metadata.labels['app.kubernetes.io/name']`}, "", "web"},
 	}
 
 	for _, c := range cases {
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"text/tabwriter"
+
+	"github.com/arran4/lookup/jsonata"
+)
+
+func usage(fs *flag.FlagSet) {
+	_, _ = fmt.Fprintf(fs.Output(), `Usage: %s [options]
+Options:
+  -suite dir  test suite directory (default "jsonata/testdata/test-suite")
+  -run regex  only run groups matching the regex
+  -v          list the cases which failed and why
+  -diff       show expected and actual results of failed cases (implies -v)
+  -json       output the report as JSON
+  -markdown   output the report as a markdown table
+`, fs.Name())
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("jsonata-conformance", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	suite := fs.String("suite", "jsonata/testdata/test-suite", "test suite directory")
+	runExpr := fs.String("run", "", "filter groups by regex")
+	verbose := fs.Bool("v", false, "list failed cases")
+	diff := fs.Bool("diff", false, "show expected and actual results")
+	jsonOut := fs.Bool("json", false, "output JSON")
+	markdownOut := fs.Bool("markdown", false, "output markdown")
+	fs.Usage = func() { usage(fs) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	var match func(string) bool
+	if *runExpr != "" {
+		re, err := regexp.Compile(*runExpr)
+		if err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+		match = re.MatchString
+	}
+
+	report, err := jsonata.RunConformance(os.DirFS(*suite), match)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case *jsonOut:
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return fmt.Errorf("json encode: %w", err)
+		}
+	case *markdownOut:
+		writeMarkdown(stdout, report)
+	default:
+		writeTable(stdout, report)
+		if *verbose || *diff {
+			writeFailures(stdout, report, *diff)
+		}
+	}
+	return nil
+}
+
+// writeTable prints the pass rate of each group followed by the total.
+func writeTable(w io.Writer, report *jsonata.ConformanceReport) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "GROUP\tPASSED\tTOTAL\tRATE")
+	for _, g := range report.Groups {
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f%%\n", g.Name, g.Passed, g.Total, 100*g.PassRate())
+	}
+	_, _ = fmt.Fprintf(tw, "TOTAL\t%d\t%d\t%.1f%%\n", report.Passed, report.Total, 100*report.PassRate())
+	_ = tw.Flush()
+}
+
+// writeMarkdown prints the report as the compatibility matrix of the README.
+func writeMarkdown(w io.Writer, report *jsonata.ConformanceReport) {
+	_, _ = fmt.Fprintln(w, "| Feature Group | Passed | Failed | Pass Rate |")
+	_, _ = fmt.Fprintln(w, "|---|---|---|---|")
+	for _, g := range report.Groups {
+		_, _ = fmt.Fprintf(w, "| %s | %d | %d | %.0f%% |\n", g.Name, g.Passed, g.Total-g.Passed, 100*g.PassRate())
+	}
+	_, _ = fmt.Fprintf(w, "| **Total** | %d | %d | %.0f%% |\n", report.Passed, report.Total-report.Passed, 100*report.PassRate())
+}
+
+// writeFailures lists the cases which failed, with their expression and reason.
+func writeFailures(w io.Writer, report *jsonata.ConformanceReport, diff bool) {
+	for _, g := range report.Groups {
+		for _, c := range g.Cases {
+			if c.Passed {
+				continue
+			}
+			_, _ = fmt.Fprintf(w, "\n--- FAIL: %s/%s: %s\n", g.Name, c.Name, c.Message)
+			_, _ = fmt.Fprintf(w, "    expr:     %s\n", c.Expr)
+			if diff && c.Expected != "" {
+				_, _ = fmt.Fprintf(w, "    expected: %s\n", c.Expected)
+				_, _ = fmt.Fprintf(w, "    actual:   %s\n", c.Actual)
+			}
+		}
+	}
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
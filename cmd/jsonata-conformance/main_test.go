@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+const suite = "../../jsonata/testdata/test-suite"
+
+func TestExamples(t *testing.T) {
+	cases := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"table", []string{"-suite", suite, "-run", "^function-assert$"}, []string{"function-assert", "TOTAL"}},
+		{"markdown", []string{"-suite", suite, "-markdown", "-run", "^function-assert$"}, []string{"| Feature Group | Passed | Failed | Pass Rate |", "| function-assert |"}},
+		{"diff", []string{"-suite", suite, "-diff", "-run", "^function-each$"}, []string{"--- FAIL: function-each/", "expr:"}},
+	}
+
+	for _, c := range cases {
+		var out bytes.Buffer
+		if err := run(c.args, strings.NewReader(""), &out, io.Discard); err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		for _, want := range c.want {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("%s: want %q in %q", c.name, want, out.String())
+			}
+		}
+	}
+}
+
+func TestJSONReport(t *testing.T) {
+	var out bytes.Buffer
+	if err := run([]string{"-suite", suite, "-json", "-run", "^function-assert$"}, strings.NewReader(""), &out, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	var report struct {
+		Groups []struct {
+			Name  string `json:"name"`
+			Total int    `json:"total"`
+		} `json:"groups"`
+		Passed int `json:"passed"`
+		Total  int `json:"total"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Groups) != 1 || report.Groups[0].Name != "function-assert" || report.Total != report.Groups[0].Total {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if report.Passed != report.Total {
+		t.Errorf("function-assert passed %d of %d", report.Passed, report.Total)
+	}
+}
+
+func TestInvalidArguments(t *testing.T) {
+	for _, args := range [][]string{{"-run", "("}, {"-suite", "missing"}, {"extra"}} {
+		if err := run(args, strings.NewReader(""), io.Discard, io.Discard); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
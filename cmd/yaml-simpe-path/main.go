@@ -2,7 +2,6 @@ package main
 
 import (
 	"encoding/json"
-	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -77,7 +76,7 @@ func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
 		r = f
 	}
 
-	dec := yaml.NewDecoder(r)
+	docs := lookup.YamlDocuments(r)
 
 	var re *regexp.Regexp
 	var err error
@@ -91,15 +90,7 @@ func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
 	index := 0
 	count := 0
 	first := true
-	for {
-		var doc interface{}
-		err := dec.Decode(&doc)
-		if errors.Is(err, io.EOF) {
-			break
-		}
-		if err != nil {
-			return fmt.Errorf("decode: %w", err)
-		}
+	for _, doc := range docs.All() {
 		for _, q := range queries {
 			res := lookup.QuerySimplePath(doc, q)
 			if res == nil {
@@ -145,6 +136,9 @@ func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
 			index++
 		}
 	}
+	if err := docs.Err(); err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
 	if *countOnly {
 		_, _ = fmt.Fprint(stdout, count)
 	}
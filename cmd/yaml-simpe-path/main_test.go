@@ -14,6 +14,8 @@ spec:
   replicas: 3
 metadata:
   name: prod-service
+  labels:
+    app.kubernetes.io/name: web
 `
 
 func TestExamples(t *testing.T) {
@@ -33,6 +35,9 @@ func TestExamples(t *testing.T) {
 		{"raw", []string{"-raw", ".spec.replicas"}, exampleYAML, "3"},
 		{"grep", []string{"-f", fname, "-grep", "^prod", ".metadata.name"}, "", "prod-service"},
 		{"count", []string{"-f", fname, "-count", ".metadata.name"}, "", "1"},
+		{"quoted", []string{"-f", fname, "-raw", `.metadata.labels."app.kubernetes.io/name"`}, "", "web"},
+		{"bracket", []string{"-f", fname, "-raw", `.metadata.labels['app.kubernetes.io/name']`}, "", "web"},
+		{"documents", []string{"-raw", ".spec.replicas"}, exampleYAML + "---\n---\nspec:\n  replicas: 5\n", "3\n5"},
 	}
 
 	for _, c := range cases {
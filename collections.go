@@ -36,11 +36,10 @@ func Filter(expression Runner) *filterFunc {
 }
 
 func (ef *filterFunc) Run(scope *Scope) Pathor {
-	result := arrayOrSliceForEachPath(ExtractPath(scope.Position), nil, scope.Position.Value(), []Runner{
+	return eachElementPath(scope.Position, []Runner{
 		ef.expression,
 		&subFilterFunc{expression: Result()},
 	}, scope)
-	return result
 }
 
 type mapFunc struct {
@@ -54,8 +53,16 @@ func Map(expression Runner) *mapFunc {
 }
 
 func (ef *mapFunc) Run(scope *Scope) Pathor {
-	result := arrayOrSliceForEachPath(ExtractPath(scope.Position), nil, scope.Position.Value(), []Runner{ef.expression}, scope)
-	return result
+	return eachElementPath(scope.Position, []Runner{ef.expression}, scope)
+}
+
+// eachElementPath runs the runners over each element of the array p, a JsonStream which hasn't been read yet having its
+// elements run as they're read.
+func eachElementPath(p Pathor, runners []Runner, scope *Scope) Pathor {
+	if s, ok := p.(*JsonStream); ok && s.p == nil && !s.iterated {
+		return s.forEachPath(runners, scope)
+	}
+	return arrayOrSliceForEachPath(ExtractPath(p), nil, p.Value(), runners, scope)
 }
 
 func forEach(scope *Scope, v reflect.Value, ef func(pathor Pathor) error) Pathor {
@@ -179,6 +186,23 @@ func iIsZero(i interface{}) bool {
 	return err == nil && val == 0
 }
 
+// indexer is implemented by Pathors which find the elements of their arrays themselves, keeping what they know of an
+// element beyond its value, as a Yamlor navigating yaml.Nodes keeps the position of an element. ok is false when the
+// element is to be found by reflection instead.
+type indexer interface {
+	index(i int64) (element Pathor, ok bool)
+}
+
+// elementAt finds element i of the array of pathor, negative indexes counting from the end.
+func elementAt(pathor Pathor, prefix string, i int64) Pathor {
+	if ix, ok := pathor.(indexer); ok {
+		if element, ok := ix.index(i); ok {
+			return element
+		}
+	}
+	return arrayOrSlicePath(prefix, i, pathor.Value())
+}
+
 func evaluateType(scope *Scope, pathor Pathor, i interface{}) Pathor {
 	if i == nil {
 		return pathor
@@ -189,14 +213,16 @@ func evaluateType(scope *Scope, pathor Pathor, i interface{}) Pathor {
 		if err != nil {
 			return NewInvalidor(ExtractPath(pathor), err)
 		}
-		return arrayOrSlicePath(ExtractPath(pathor)+"["+strconv.Itoa(ip)+"]", ip, pathor.Value())
+		return elementAt(pathor, ExtractPath(pathor)+"["+strconv.Itoa(ip)+"]", int64(ip))
 	case reflect.String:
-		if simpleIntRegex.MatchString(i.(string)) {
-			ii, err := strconv.ParseInt(i.(string), 10, 64)
+		// Any string type, including a json.Number
+		s := reflect.ValueOf(i).String()
+		if simpleIntRegex.MatchString(s) {
+			ii, err := strconv.ParseInt(s, 10, 64)
 			if err != nil {
-				return NewInvalidor(ExtractPath(pathor)+"["+i.(string)+"]", err)
+				return NewInvalidor(ExtractPath(pathor)+"["+s+"]", err)
 			}
-			return arrayOrSlicePath(ExtractPath(pathor)+"["+strconv.FormatInt(ii, 10)+"]", ii, pathor.Value())
+			return elementAt(pathor, ExtractPath(pathor)+"["+strconv.FormatInt(ii, 10)+"]", ii)
 		}
 	case reflect.Struct, reflect.Pointer:
 		switch ii := i.(type) {
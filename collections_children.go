@@ -0,0 +1,148 @@
+package lookup
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+type childrenFunc struct{}
+
+// Children used with .Find() as a PathOpt replaces the position with all of its child values, the values of a map
+// in key order and the exported fields of a struct. Arrays and slices have the children of each of their elements
+// collected, and children which are arrays or slices are flattened into the result.
+//
+//	Reflect(config).Find("Account", Children()).Find("Price")
+func Children() *childrenFunc {
+	return &childrenFunc{}
+}
+
+func (c *childrenFunc) Run(scope *Scope) Pathor {
+	result := []interface{}{}
+	eachChild(scope.Position.Value(), func(v reflect.Value) {
+		result = appendFlattened(result, v)
+	})
+	if len(result) == 0 {
+		return NewInvalidor(scope.Path(), ErrNoMatchesForQuery)
+	}
+	return &Reflector{path: scope.Path() + ".*", v: reflect.ValueOf(result)}
+}
+
+type descendantsFunc struct{}
+
+// Descendants used with .Find() as a PathOpt replaces the position with it and every value nested within it, depth
+// first. Arrays and slices aren't included themselves, only their elements and what they contain.
+//
+//	Reflect(config).Find("", Descendants()).Find("Price")
+func Descendants() *descendantsFunc {
+	return &descendantsFunc{}
+}
+
+func (d *descendantsFunc) Run(scope *Scope) Pathor {
+	result := []interface{}{}
+	// Guard against cycles, through pointers, maps and slices, by visiting each once as DeepCopy copies each once
+	seen := map[copyKey]bool{}
+	visit := func(v reflect.Value) bool {
+		key := copyKey{ptr: v.Pointer(), typ: v.Type()}
+		if seen[key] {
+			return false
+		}
+		seen[key] = true
+		return true
+	}
+	var descend func(v reflect.Value)
+	descend = func(v reflect.Value) {
+		for v.IsValid() && (v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr) {
+			if v.IsNil() {
+				return
+			}
+			if v.Kind() == reflect.Ptr && !visit(v) {
+				return
+			}
+			v = v.Elem()
+		}
+		if !v.IsValid() {
+			return
+		}
+		switch v.Kind() {
+		case reflect.Slice:
+			if v.Len() == 0 || !visit(v) {
+				return
+			}
+			fallthrough
+		case reflect.Array:
+			for i := 0; i < v.Len(); i++ {
+				descend(v.Index(i))
+			}
+			return
+		case reflect.Map:
+			if !v.IsNil() && !visit(v) {
+				return
+			}
+		}
+		result = append(result, v.Interface())
+		eachChild(v, descend)
+	}
+	descend(scope.Position.Value())
+	if len(result) == 0 {
+		return NewInvalidor(scope.Path(), ErrNoMatchesForQuery)
+	}
+	return &Reflector{path: scope.Path() + ".**", v: reflect.ValueOf(result)}
+}
+
+// eachChild calls f with the values of a map, in key order, or the exported fields of a struct. Arrays and slices
+// have the children of each element visited.
+func eachChild(v reflect.Value, f func(reflect.Value)) {
+	v = indirectValue(v)
+	if !v.IsValid() {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Array, reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			eachChild(v.Index(i), f)
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, k := range keys {
+			f(v.MapIndex(k))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				f(v.Field(i))
+			}
+		}
+	}
+}
+
+// appendFlattened appends v to result, appending the elements of arrays and slices, recursively, rather than the
+// array itself.
+func appendFlattened(result []interface{}, v reflect.Value) []interface{} {
+	v = indirectValue(v)
+	if !v.IsValid() {
+		return result
+	}
+	switch v.Kind() {
+	case reflect.Array, reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			result = appendFlattened(result, v.Index(i))
+		}
+		return result
+	}
+	return append(result, v.Interface())
+}
+
+// indirectValue follows interfaces and pointers to the value they hold, returning the zero Value for nil.
+func indirectValue(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
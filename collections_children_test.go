@@ -0,0 +1,163 @@
+package lookup
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestChildren(t *testing.T) {
+	type item struct {
+		Name   string
+		Price  int
+		hidden int
+	}
+	data := map[string]interface{}{
+		"Account": map[string]interface{}{
+			"Name":  "Firefly",
+			"Items": []item{{Name: "a", Price: 1}, {Name: "b", Price: 2}},
+			"Owner": &item{Name: "mal", Price: 3},
+		},
+		"Empty": map[string]interface{}{},
+		"Value": 5,
+	}
+
+	tests := []struct {
+		name   string
+		result func() Pathor
+		want   interface{}
+		fail   bool
+	}{
+		{
+			name:   "map values in key order with arrays flattened",
+			result: func() Pathor { return Reflect(data).Find("Account", Children()) },
+			want: []interface{}{
+				item{Name: "a", Price: 1}, item{Name: "b", Price: 2}, "Firefly", item{Name: "mal", Price: 3},
+			},
+		},
+		{
+			name:   "exported struct fields",
+			result: func() Pathor { return Reflect(data).Find("Account").Find("Owner", Children()) },
+			want:   []interface{}{"mal", 3},
+		},
+		{
+			name:   "children of each element",
+			result: func() Pathor { return Reflect(data).Find("Account").Find("Items", Children()) },
+			want:   []interface{}{"a", 1, "b", 2},
+		},
+		{
+			name:   "navigate through children",
+			result: func() Pathor { return Reflect(data).Find("Account", Children()).Find("Price") },
+			want:   []int{1, 2, 3},
+		},
+		{
+			name:   "empty map has no children",
+			result: func() Pathor { return Reflect(data).Find("Empty", Children()) },
+			fail:   true,
+		},
+		{
+			name:   "scalar has no children",
+			result: func() Pathor { return Reflect(data).Find("Value", Children()) },
+			fail:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.result()
+			if tt.fail {
+				if _, ok := got.(*Invalidor); !ok {
+					t.Errorf("expected failure, got %#v", got.Raw())
+				}
+				return
+			}
+			if diff := cmp.Diff(tt.want, got.Raw(), cmp.AllowUnexported(item{})); diff != "" {
+				t.Errorf("unexpected result: %s", diff)
+			}
+		})
+	}
+}
+
+func TestDescendants(t *testing.T) {
+	type node struct {
+		Name     string
+		Children []*node
+	}
+	tree := &node{Name: "root", Children: []*node{{Name: "a"}, {Name: "b", Children: []*node{{Name: "c"}}}}}
+	cyclic := &node{Name: "loop"}
+	cyclic.Children = []*node{cyclic}
+	cyclicMap := map[string]interface{}{"Name": "map"}
+	cyclicMap["self"] = cyclicMap
+	cyclicSlice := []interface{}{map[string]interface{}{"Name": "slice"}, nil}
+	cyclicSlice[1] = cyclicSlice
+
+	data := map[string]interface{}{
+		"a": map[string]interface{}{"Price": 1, "b": []interface{}{map[string]interface{}{"Price": 2}, 3}},
+		"c": "x",
+	}
+
+	tests := []struct {
+		name   string
+		result func() Pathor
+		want   interface{}
+		fail   bool
+	}{
+		{
+			name:   "self and nested values depth first",
+			result: func() Pathor { return Reflect(data).Find("a", Descendants()) },
+			want: []interface{}{
+				data["a"], 1, map[string]interface{}{"Price": 2}, 2, 3,
+			},
+		},
+		{
+			name:   "navigate through descendants",
+			result: func() Pathor { return Reflect(data).Find("", Descendants()).Find("Price") },
+			want:   []int{1, 2},
+		},
+		{
+			name:   "struct tree",
+			result: func() Pathor { return Reflect(tree).Find("", Descendants()).Find("Name") },
+			want:   []string{"root", "a", "b", "c"},
+		},
+		{
+			name:   "pointer cycles are visited once",
+			result: func() Pathor { return Reflect(cyclic).Find("", Descendants()).Find("Name") },
+			want:   []string{"loop"},
+		},
+		{
+			name:   "map cycles are visited once",
+			result: func() Pathor { return Reflect(cyclicMap).Find("", Descendants()).Find("Name") },
+			want:   []string{"map"},
+		},
+		{
+			name:   "slice cycles are visited once",
+			result: func() Pathor { return Reflect(cyclicSlice).Find("", Descendants()).Find("Name") },
+			want:   []string{"slice"},
+		},
+		{
+			name:   "scalar is its own descendant",
+			result: func() Pathor { return Reflect(data).Find("c", Descendants()) },
+			want:   []interface{}{"x"},
+		},
+		{
+			name:   "nil has no descendants",
+			result: func() Pathor { return Reflect(nil).Find("", Descendants()) },
+			fail:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.result()
+			if tt.fail {
+				if _, ok := got.(*Invalidor); !ok {
+					t.Errorf("expected failure, got %#v", got.Raw())
+				}
+				return
+			}
+			if diff := cmp.Diff(tt.want, got.Raw()); diff != "" {
+				t.Errorf("unexpected result: %s", diff)
+			}
+		})
+	}
+}
@@ -362,3 +362,35 @@ func TestInStruct(t *testing.T) {
 		t.Errorf("value mismatch: %s", diff)
 	}
 }
+
+func TestContainsAndInUseNumber(t *testing.T) {
+	data := []byte(`{"list":[1,2,9007199254740993],"n":2,"id":9007199254740993}`)
+	r := Json(data)
+	u := Json(data, UseNumber())
+
+	tests := []struct {
+		name   string
+		result func() Pathor
+		want   interface{}
+	}{
+		{name: "contains float", result: func() Pathor { return r.Find("list", Contains(Constant(2.0))) }, want: true},
+		{name: "contains float UseNumber", result: func() Pathor { return u.Find("list", Contains(Constant(2.0))) }, want: true},
+		{name: "contains missing UseNumber", result: func() Pathor { return u.Find("list", Contains(Constant(3.0))) }, want: false},
+		{name: "contains int UseNumber", result: func() Pathor { return u.Find("list", Contains(Constant(2))) }, want: true},
+		{name: "contains large int UseNumber", result: func() Pathor { return u.Find("list", Contains(Constant(int64(9007199254740993)))) }, want: true},
+		{name: "contains near large int UseNumber", result: func() Pathor { return u.Find("list", Contains(Constant(int64(9007199254740992)))) }, want: false},
+		{name: "in floats", result: func() Pathor { return r.Find("n", In(Array(1.0, 2.0))) }, want: true},
+		{name: "in floats UseNumber", result: func() Pathor { return u.Find("n", In(Array(1.0, 2.0))) }, want: true},
+		{name: "in ints UseNumber", result: func() Pathor { return u.Find("n", In(Array(1, 2))) }, want: true},
+		{name: "in missing UseNumber", result: func() Pathor { return u.Find("n", In(Array(3))) }, want: false},
+		{name: "in large int UseNumber", result: func() Pathor { return u.Find("id", In(Array(int64(9007199254740993)))) }, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, tt.result().Raw()); diff != "" {
+				t.Errorf("unexpected result: %s", diff)
+			}
+		})
+	}
+}
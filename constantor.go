@@ -1,6 +1,7 @@
 package lookup
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 )
@@ -113,9 +114,12 @@ func (c *Constantor) IsString() bool {
 }
 
 func (c *Constantor) IsInt() bool {
-	switch c.c.(type) {
+	switch v := c.c.(type) {
 	case int, int8, int16, int32, int64:
 		return true
+	case json.Number:
+		_, ok := jsonInt(v)
+		return ok
 	}
 	return false
 }
@@ -127,7 +131,7 @@ func (c *Constantor) IsBool() bool {
 
 func (c *Constantor) IsFloat() bool {
 	switch c.c.(type) {
-	case float32, float64:
+	case float32, float64, json.Number:
 		return true
 	}
 	return false
@@ -197,6 +201,10 @@ func (c *Constantor) AsInt() (int64, error) {
 		return int64(v), nil
 	case int64:
 		return v, nil
+	case json.Number:
+		if n, ok := jsonInt(v); ok {
+			return n, nil
+		}
 	}
 	return 0, fmt.Errorf("path %s: %w", c.path, ErrNotInt)
 }
@@ -214,6 +222,10 @@ func (c *Constantor) AsFloat() (float64, error) {
 		return float64(v), nil
 	case float64:
 		return v, nil
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return f, nil
+		}
 	}
 	return 0.0, fmt.Errorf("path %s: %w", c.path, ErrNotFloat)
 }
@@ -0,0 +1,248 @@
+package lookup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"iter"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Documents is a Pathor over a stream of documents, such as those of a multi-document YAML file or the values of JSON
+// Lines, navigated as an array of the documents so Index, Filter and Map work across them. All reads the documents one
+// at a time as they're iterated over, without keeping them. Used as any other Pathor all of the documents are read
+// first, which can't be done once iteration has started.
+type Documents struct {
+	path     string
+	read     func(path string) (Pathor, error) // reads the next document, io.EOF after the last
+	closer   io.Closer
+	count    int  // documents read so far
+	iterated bool // All has read documents, which weren't kept
+	ended    bool
+	err      error
+	docs     []Pathor // all of the documents, once read
+	p        Pathor   // the documents as an array, once read
+}
+
+// YamlDocuments creates a Documents of the YAML documents read from r, separated by "---" as in a bundle of Kubernetes
+// manifests. Empty documents are skipped. The options are those of Yaml, with UseNodes each document is a Yamlor
+// navigating its nodes.
+func YamlDocuments(r io.Reader, opts ...YamlOption) *Documents {
+	return yamlDocuments(r, nil, newYamlOptions(opts))
+}
+
+// YamlDocumentsFile creates a Documents of the YAML documents in the named file of fsys, as YamlDocuments does. The file
+// is left open until the last document is read or the Documents is closed.
+func YamlDocumentsFile(fsys fs.FS, name string, opts ...YamlOption) *Documents {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return &Documents{err: err, ended: true}
+	}
+	return yamlDocuments(f, f, newYamlOptions(opts))
+}
+
+func yamlDocuments(r io.Reader, closer io.Closer, opts yamlOptions) *Documents {
+	dec := yaml.NewDecoder(r)
+	read := func(path string) (Pathor, error) {
+		for {
+			var doc yaml.Node
+			if err := dec.Decode(&doc); err != nil {
+				return nil, err
+			}
+			// An empty document, such as one after a trailing "---", holds an empty null
+			n := documentValue(&doc)
+			if n == nil || n.Kind == yaml.ScalarNode && n.ShortTag() == "!!null" && n.Value == "" {
+				continue
+			}
+			if opts.useNodes {
+				return &Yamlor{path: path, opts: opts, node: n, parsed: true}, nil
+			}
+			var v interface{}
+			if err := n.Decode(&v); err != nil {
+				return nil, err
+			}
+			return &Reflector{path: path, v: reflect.ValueOf(v)}, nil
+		}
+	}
+	return &Documents{read: read, closer: closer}
+}
+
+// JsonLines creates a Documents of the JSON values read from r, one a line as in JSON Lines or separated by any other
+// whitespace. Each value is a Jsonor, and the options are those of Json.
+func JsonLines(r io.Reader, opts ...JsonOption) *Documents {
+	return jsonLines(r, nil, newJsonOptions(opts))
+}
+
+// JsonLinesFile creates a Documents of the JSON values in the named file of fsys, as JsonLines does. The file is left
+// open until the last value is read or the Documents is closed.
+func JsonLinesFile(fsys fs.FS, name string, opts ...JsonOption) *Documents {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return &Documents{err: err, ended: true}
+	}
+	return jsonLines(f, f, newJsonOptions(opts))
+}
+
+func jsonLines(r io.Reader, closer io.Closer, opts jsonOptions) *Documents {
+	dec := json.NewDecoder(r)
+	read := func(path string) (Pathor, error) {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		return &Jsonor{path: path, raw: raw, opts: opts}, nil
+	}
+	return &Documents{read: read, closer: closer}
+}
+
+// Path returns the current lookup path.
+func (d *Documents) Path() string { return d.path }
+
+// All iterates over the documents, reading them from the stream as they're needed. Once all of the documents have been
+// read the documents iterated over are those read. Err reports whether reading failed.
+func (d *Documents) All() iter.Seq2[int, Pathor] {
+	return func(yield func(int, Pathor) bool) {
+		if d.p != nil {
+			for i, doc := range d.docs {
+				if !yield(i, doc) {
+					return
+				}
+			}
+			return
+		}
+		for {
+			doc, ok := d.next()
+			if !ok {
+				return
+			}
+			d.iterated = true
+			if !yield(d.count-1, doc) {
+				return
+			}
+		}
+	}
+}
+
+// Err returns the error reading the stream stopped with, if any.
+func (d *Documents) Err() error { return d.err }
+
+// Close closes the file a Documents created from a file reads from, which happens anyway when the last document is
+// read.
+func (d *Documents) Close() error {
+	if d.ended {
+		return nil
+	}
+	d.ended = true
+	if d.closer != nil {
+		return d.closer.Close()
+	}
+	return nil
+}
+
+// next reads the next document, ok is false after the last or when reading fails.
+func (d *Documents) next() (Pathor, bool) {
+	if d.ended {
+		return nil, false
+	}
+	doc, err := d.read(fmt.Sprintf("%s[%d]", d.path, d.count))
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			d.err = err
+		}
+		_ = d.Close()
+		return nil, false
+	}
+	d.count++
+	return doc, true
+}
+
+func (d *Documents) ensure() Pathor {
+	if d.p != nil {
+		return d.p
+	}
+	if d.iterated {
+		return NewInvalidor(d.path, ErrStreamConsumed)
+	}
+	d.docs = []Pathor{}
+	values := []interface{}{}
+	for {
+		doc, ok := d.next()
+		if !ok {
+			break
+		}
+		d.docs = append(d.docs, doc)
+		values = append(values, doc.Raw())
+	}
+	if d.err != nil {
+		d.p = NewInvalidor(d.path, d.err)
+	} else {
+		d.p = &Reflector{path: d.path, v: reflect.ValueOf(values)}
+	}
+	return d.p
+}
+
+// Find navigates the array of documents after reading all of them. The runners of a Find of "" are run on the
+// Documents, so Index finds the documents themselves.
+func (d *Documents) Find(path string, opts ...Runner) Pathor {
+	docs := d.ensure()
+	if _, failed := docs.(*Invalidor); failed || path != "" {
+		return docs.Find(path, opts...)
+	}
+	var rr Pathor = d
+	for _, runner := range opts {
+		rr = runner.Run(NewScope(d, rr))
+		if rr == nil {
+			rr = NewInvalidor(d.path, ErrEvalFail)
+		}
+	}
+	return rr
+}
+
+// index finds a document for Index, so a document navigating YAML nodes keeps them.
+func (d *Documents) index(i int64) (Pathor, bool) {
+	if _, ok := d.ensure().(*Reflector); !ok {
+		return nil, false
+	}
+	if i < 0 {
+		i += int64(len(d.docs))
+	}
+	if i < 0 || i >= int64(len(d.docs)) {
+		return nil, false
+	}
+	return d.docs[i], true
+}
+
+// Raw returns the documents as a slice of their decoded values.
+func (d *Documents) Raw() interface{} { return d.ensure().Raw() }
+
+// RawAsInterfaceSlice returns the decoded documents as a slice of interface{}.
+func (d *Documents) RawAsInterfaceSlice() []interface{} { return d.ensure().RawAsInterfaceSlice() }
+
+// Value returns the reflect.Value of the decoded documents.
+func (d *Documents) Value() reflect.Value { return d.ensure().Value() }
+
+// Type returns the reflect.Type of the decoded documents.
+func (d *Documents) Type() reflect.Type { return d.ensure().Type() }
+
+func (d *Documents) IsString() bool    { return d.ensure().IsString() }
+func (d *Documents) IsInt() bool       { return d.ensure().IsInt() }
+func (d *Documents) IsBool() bool      { return d.ensure().IsBool() }
+func (d *Documents) IsFloat() bool     { return d.ensure().IsFloat() }
+func (d *Documents) IsSlice() bool     { return d.ensure().IsSlice() }
+func (d *Documents) IsMap() bool       { return d.ensure().IsMap() }
+func (d *Documents) IsStruct() bool    { return d.ensure().IsStruct() }
+func (d *Documents) IsNil() bool       { return d.ensure().IsNil() }
+func (d *Documents) IsPtr() bool       { return d.ensure().IsPtr() }
+func (d *Documents) IsInterface() bool { return d.ensure().IsInterface() }
+
+func (d *Documents) AsString() (string, error)              { return d.ensure().AsString() }
+func (d *Documents) AsInt() (int64, error)                  { return d.ensure().AsInt() }
+func (d *Documents) AsBool() (bool, error)                  { return d.ensure().AsBool() }
+func (d *Documents) AsFloat() (float64, error)              { return d.ensure().AsFloat() }
+func (d *Documents) AsSlice() ([]interface{}, error)        { return d.ensure().AsSlice() }
+func (d *Documents) AsMap() (map[string]interface{}, error) { return d.ensure().AsMap() }
+func (d *Documents) AsPtr() (interface{}, error)            { return d.ensure().AsPtr() }
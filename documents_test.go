@@ -0,0 +1,112 @@
+package lookup
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const manifests = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+# an empty document
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: worker
+spec:
+  replicas: 1
+`
+
+func TestYamlDocumentsAsCollection(t *testing.T) {
+	docs := YamlDocuments(strings.NewReader(manifests))
+	assert.Equal(t, []string{"Deployment", "Service", "Deployment"}, docs.Find("kind").Raw())
+	assert.Equal(t, "worker", docs.Find("", Index(-1)).Find("metadata").Find("name").Raw())
+	assert.Equal(t, []string{"web", "worker"}, docs.Find("", Filter(This("kind").Find("", Equals(Constant("Deployment"))))).Find("metadata").Find("name").Raw())
+	assert.Equal(t, []string{"web", "web", "worker"}, docs.Find("", Map(This("metadata", "name"))).Raw())
+	assert.True(t, docs.IsSlice())
+	assert.NoError(t, docs.Err())
+}
+
+func TestYamlDocumentsAll(t *testing.T) {
+	docs := YamlDocuments(strings.NewReader(manifests))
+	var names []string
+	for i, doc := range docs.All() {
+		names = append(names, fmt.Sprintf("%d:%s:%s", i, ExtractPath(doc), doc.Find("metadata").Find("name").Raw()))
+	}
+	assert.Equal(t, []string{"0:[0]:web", "1:[1]:web", "2:[2]:worker"}, names)
+	assert.NoError(t, docs.Err())
+
+	// The documents weren't kept
+	assert.ErrorIs(t, docs.Find("", Index(0)).(*Invalidor), ErrStreamConsumed)
+}
+
+func TestYamlDocumentsUseNodes(t *testing.T) {
+	docs := YamlDocuments(strings.NewReader(manifests), UseNodes())
+	replicas := docs.Find("", Index(2)).Find("spec").Find("replicas")
+	assert.Equal(t, 1, replicas.Raw())
+	line, column, ok := YamlPosition(replicas)
+	assert.True(t, ok)
+	assert.Equal(t, 20, line)
+	assert.Equal(t, 13, column)
+	assert.Equal(t, `[2]."spec"."replicas"`, ExtractPath(replicas))
+}
+
+func TestJsonLines(t *testing.T) {
+	in := `{"id": 1, "tags": ["a"]}
+{"id": 2, "tags": []}
+
+{"id": 3, "tags": ["b", "c"]}
+`
+	docs := JsonLines(strings.NewReader(in))
+	assert.Equal(t, []float64{1, 2, 3}, docs.Find("id").Raw())
+	assert.Equal(t, 3.0, docs.Find("", Index(-1)).Find("id").Raw())
+
+	var ids []interface{}
+	for _, doc := range JsonLines(strings.NewReader(in), UseNumber()).All() {
+		ids = append(ids, doc.Find("id").Raw())
+	}
+	assert.Len(t, ids, 3)
+	i, err := JsonLines(strings.NewReader(in), UseNumber()).Find("", Index(1)).Find("id").AsInt()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), i)
+}
+
+func TestDocumentsErrors(t *testing.T) {
+	docs := JsonLines(strings.NewReader("{\"id\": 1}\n{\"id\": \n"))
+	var read int
+	for range docs.All() {
+		read++
+	}
+	assert.Equal(t, 1, read)
+	assert.Error(t, docs.Err())
+
+	assert.IsType(t, &Invalidor{}, YamlDocuments(strings.NewReader("a: [1\n")).Find("a"))
+}
+
+func TestDocumentsFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"bundle.yaml":  {Data: []byte(manifests)},
+		"events.jsonl": {Data: []byte("{\"id\": 1}\n{\"id\": 2}\n")},
+	}
+	assert.Equal(t, []string{"web", "web", "worker"}, YamlDocumentsFile(fsys, "bundle.yaml").Find("", Map(This("metadata", "name"))).Raw())
+	assert.Equal(t, []float64{1, 2}, JsonLinesFile(fsys, "events.jsonl").Find("id").Raw())
+
+	missing := YamlDocumentsFile(fsys, "missing.yaml")
+	assert.IsType(t, &Invalidor{}, missing.Find("kind"))
+	assert.Error(t, missing.Err())
+	assert.Error(t, JsonLinesFile(fsys, "missing.jsonl").Err())
+}
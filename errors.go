@@ -14,6 +14,7 @@ var (
 	ErrValueNotIn                = errors.New("value not in set")
 	ErrNoMatchesForQuery         = errors.New("nothing matched query")
 	ErrFalse                     = errors.New("evaluated to false")
+	ErrNotModifiable             = errors.New("value can't be modified in place")
 
 	// Type errors
 	ErrNotString    = errors.New("value is not a string")
@@ -2,6 +2,7 @@ package lookup
 
 import (
 	"reflect"
+	"time"
 
 	"github.com/arran4/go-evaluator"
 )
@@ -17,6 +18,10 @@ type Scope struct {
 	path     *string
 	Position Pathor
 	Context  *evaluator.Context
+	Bindings *Bindings
+	// Clock supplies the current time to anything evaluated in the scope, such as date and time functions. When nil
+	// time.Now is used. Set it to make evaluations deterministic.
+	Clock func() time.Time
 }
 
 func NewScope(parent Pathor, position Pathor) *Scope {
@@ -38,28 +43,40 @@ func NewScopeWithContext(parent Pathor, position Pathor, ctx *evaluator.Context)
 
 func (s *Scope) Copy() *Scope {
 	var ctx *evaluator.Context
+	var b *Bindings
+	var clock func() time.Time
 	if s != nil {
 		ctx = s.Context
+		b = s.Bindings
+		clock = s.Clock
 	}
 	return &Scope{
-		Current: s.Current,
-		Parent:  s.Parent,
-		v:       s.v,
-		path:    s.path,
-		Context: ctx,
+		Current:  s.Current,
+		Parent:   s.Parent,
+		v:        s.v,
+		path:     s.path,
+		Context:  ctx,
+		Bindings: b,
+		Clock:    clock,
 	}
 }
 
 func (s *Scope) Nest(new Pathor) *Scope {
 	var ctx *evaluator.Context
+	var b *Bindings
+	var clock func() time.Time
 	if s != nil {
 		ctx = s.Context
+		b = s.Bindings
+		clock = s.Clock
 	}
 	return &Scope{
 		Current:  new,
 		Parent:   s,
 		Position: new,
 		Context:  ctx,
+		Bindings: b,
+		Clock:    clock,
 	}
 }
 
@@ -91,5 +108,16 @@ func (s *Scope) Next(position Pathor) *Scope {
 		Current:  s.Current,
 		Parent:   s,
 		Position: position,
+		Context:  s.Context,
+		Bindings: s.Bindings,
+		Clock:    s.Clock,
+	}
+}
+
+// Now returns the current time from the scope's Clock, or time.Now when it has none.
+func (s *Scope) Now() time.Time {
+	if s != nil && s.Clock != nil {
+		return s.Clock()
 	}
+	return time.Now()
 }
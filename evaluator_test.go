@@ -0,0 +1,36 @@
+package lookup
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScopeClockPropagates(t *testing.T) {
+	fixed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	root := Reflect(map[string]interface{}{"a": []int{1, 2}})
+	scope := NewScope(nil, root)
+	scope.Clock = func() time.Time { return fixed }
+
+	for name, s := range map[string]*Scope{
+		"Nest":    scope.Nest(root.Find("a")),
+		"Next":    scope.Next(root.Find("a")),
+		"Copy":    scope.Copy(),
+		"Enclose": scope.Enclose(),
+	} {
+		if got := s.Now(); !got.Equal(fixed) {
+			t.Errorf("%s: expected %v, got %v", name, fixed, got)
+		}
+	}
+}
+
+func TestScopeNowDefaultsToTimeNow(t *testing.T) {
+	before := time.Now()
+	got := NewScope(nil, Reflect(nil)).Now()
+	if got.Before(before) || got.After(time.Now()) {
+		t.Errorf("expected the current time, got %v", got)
+	}
+	var nilScope *Scope
+	if nilScope.Now().IsZero() {
+		t.Errorf("expected a nil scope to use the current time")
+	}
+}
@@ -1,6 +1,7 @@
 package lookup
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 )
@@ -91,9 +92,12 @@ func (i *Interfaceor) IsString() bool {
 }
 
 func (i *Interfaceor) IsInt() bool {
-	switch i.i.Raw().(type) {
+	switch v := i.i.Raw().(type) {
 	case int, int8, int16, int32, int64:
 		return true
+	case json.Number:
+		_, ok := jsonInt(v)
+		return ok
 	}
 	return false
 }
@@ -105,7 +109,7 @@ func (i *Interfaceor) IsBool() bool {
 
 func (i *Interfaceor) IsFloat() bool {
 	switch i.i.Raw().(type) {
-	case float32, float64:
+	case float32, float64, json.Number:
 		return true
 	}
 	return false
@@ -180,6 +184,10 @@ func (i *Interfaceor) AsInt() (int64, error) {
 		return int64(v), nil
 	case int64:
 		return v, nil
+	case json.Number:
+		if n, ok := jsonInt(v); ok {
+			return n, nil
+		}
 	}
 	return 0, fmt.Errorf("path %s: %w", i.path, ErrNotInt)
 }
@@ -197,6 +205,10 @@ func (i *Interfaceor) AsFloat() (float64, error) {
 		return float64(v), nil
 	case float64:
 		return v, nil
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return f, nil
+		}
 	}
 	return 0.0, fmt.Errorf("path %s: %w", i.path, ErrNotFloat)
 }
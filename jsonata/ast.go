@@ -1,5 +1,7 @@
 package jsonata
 
+import "regexp"
+
 // AST represents a parsed JSONata expression.
 type AST struct {
 	Node Node
@@ -23,31 +25,212 @@ type BinaryNode struct {
 
 func (n *BinaryNode) isNode() {}
 
+// NegationNode is the unary minus, `-Price`. A minus directly followed by a number is part of a number literal
+// instead.
+type NegationNode struct {
+	Operand Node
+}
+
+func (n *NegationNode) isNode() {}
+
+// ConditionNode is `Condition ? Then : Else`, Else may be nil.
+type ConditionNode struct {
+	Condition Node
+	Then      Node
+	Else      Node
+}
+
+func (n *ConditionNode) isNode() {}
+
 type LiteralNode struct {
 	Value interface{}
 }
 
 func (n *LiteralNode) isNode() {}
 
+// RegexNode is a regular expression literal, `/ab+/i`, compiled when the expression is parsed.
+type RegexNode struct {
+	Regexp *regexp.Regexp
+}
+
+func (n *RegexNode) isNode() {}
+
 type FunctionCallNode struct {
-	Name string
-	Args []Node
+	Name   string
+	Callee Node // expression yielding the function when it isn't called by Name, eg `function($x){$x}(1)`
+	Args   []Node
 }
 
 func (n *FunctionCallNode) isNode() {}
 
+// isPartial reports whether the call is a partial application, with placeholders for some of its arguments.
+func (n *FunctionCallNode) isPartial() bool {
+	for _, arg := range n.Args {
+		if _, ok := arg.(*PlaceholderNode); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// PlaceholderNode is an argument left to be supplied later in a partial application, `$substring(?, 0, 5)`.
+type PlaceholderNode struct{}
+
+func (n *PlaceholderNode) isNode() {}
+
+// VariableNode references a variable such as `$price`. Name excludes the leading `$`, so the context item `$` has
+// the empty name and the root `$$` is named "$".
+type VariableNode struct {
+	Name string
+}
+
+func (n *VariableNode) isNode() {}
+
+// LambdaNode defines a function, `function($a, $b){ body }` or `λ($a, $b){ body }`, optionally with a signature
+// validating its arguments, `function($a, $b)<nn:n>{ body }`.
+type LambdaNode struct {
+	Params    []string // parameter names without the leading `$`
+	Signature *Signature
+	Body      Node
+}
+
+func (n *LambdaNode) isNode() {}
+
+// BindNode assigns the result of Value to a variable, `$name := value`.
+type BindNode struct {
+	Name  string
+	Value Node
+}
+
+func (n *BindNode) isNode() {}
+
+// BlockNode is a parenthesised list of expressions separated by `;`. The block has its own frame of variables and
+// results in the value of the last expression.
+type BlockNode struct {
+	Expressions []Node
+}
+
+func (n *BlockNode) isNode() {}
+
+// ArrayNode constructs an array, `[a, b, c]`. Items resulting in sequences or arrays are flattened into the new
+// array, except items which are themselves array constructors which are kept as nested arrays.
+type ArrayNode struct {
+	Items []Node
+}
+
+func (n *ArrayNode) isNode() {}
+
+// ObjectNode constructs an object from key/value pairs, `{ "name": Name }`. With an Input the object is built by
+// grouping the items of the input by key, `Account.Order{ OrderID: $sum(Product.Price) }`, otherwise the context item
+// is grouped.
+type ObjectNode struct {
+	Input Node
+	Pairs []ObjectPair
+}
+
+func (n *ObjectNode) isNode() {}
+
+// ObjectPair is a key/value pair of an object constructor. The key must evaluate to a string.
+type ObjectPair struct {
+	Key   Node
+	Value Node
+}
+
+// TransformNode is the transform operator, `|Account.Order|{"status": "done"}, ["temp"]|`. It results in a function
+// which copies its argument and, for each item of the copy matched by Pattern, merges in the fields of the object
+// Update results in and removes the fields named by Delete, which may be nil.
+type TransformNode struct {
+	Pattern Node
+	Update  Node
+	Delete  Node
+}
+
+func (n *TransformNode) isNode() {}
+
+// ParentNode is the parent operator, `%`, the item the current context item was navigated from.
+type ParentNode struct{}
+
+func (n *ParentNode) isNode() {}
+
+// SortTerm is a term of an order-by step, `<Price` or `>Price`, ascending unless Descending.
+type SortTerm struct {
+	Expr       Node
+	Descending bool
+}
+
 // Step describes a navigation step in the query.
 type Step struct {
 	Name         string            // field name
-	Index        *int              // optional index
-	Filter       *Predicate        // optional filter
+	Wildcard     string            // "*" for every child value or "**" for every descendant, instead of a Name
+	Predicates   []Node            // `[expr]` filters and indexes, applied in order
 	SubExpr      Node              // Parenthesized sub-expression in path
 	FunctionCall *FunctionCallNode // Function call as a step
+	Sort         []SortTerm        // terms of an order-by step, `^(>Price, <Quantity)`, sorting the path so far
+	Index        string            // variable bound to the position of each item within the step, `#$i`
+	Focus        string            // variable bound to each item of the step leaving the context unchanged, `@$o`
+	Stages       []Node            // predicates following a binding or sort, filtering all the results of the step
+}
+
+// usesTuples reports whether the step needs the items of its path to keep track of where they came from.
+func (s Step) usesTuples() bool {
+	return s.Sort != nil || s.Index != "" || s.Focus != "" || len(s.Stages) > 0
 }
 
-// Predicate represents a condition.
-type Predicate struct {
-	Field    string
-	Operator string // "=", ">", "<", etc.
-	Value    string
+// inspect calls f for node and each node within it, depth first, descending into a node's children while f returns
+// true.
+func inspect(node Node, f func(Node) bool) {
+	if node == nil || !f(node) {
+		return
+	}
+	switch n := node.(type) {
+	case *PathNode:
+		for _, step := range n.Steps {
+			inspect(step.SubExpr, f)
+			if step.FunctionCall != nil {
+				inspect(step.FunctionCall, f)
+			}
+			for _, term := range step.Sort {
+				inspect(term.Expr, f)
+			}
+			for _, predicate := range append(append([]Node{}, step.Predicates...), step.Stages...) {
+				inspect(predicate, f)
+			}
+		}
+	case *BinaryNode:
+		inspect(n.Left, f)
+		inspect(n.Right, f)
+	case *NegationNode:
+		inspect(n.Operand, f)
+	case *ConditionNode:
+		inspect(n.Condition, f)
+		inspect(n.Then, f)
+		inspect(n.Else, f)
+	case *FunctionCallNode:
+		inspect(n.Callee, f)
+		for _, arg := range n.Args {
+			inspect(arg, f)
+		}
+	case *LambdaNode:
+		inspect(n.Body, f)
+	case *TransformNode:
+		inspect(n.Pattern, f)
+		inspect(n.Update, f)
+		inspect(n.Delete, f)
+	case *BindNode:
+		inspect(n.Value, f)
+	case *BlockNode:
+		for _, e := range n.Expressions {
+			inspect(e, f)
+		}
+	case *ArrayNode:
+		for _, item := range n.Items {
+			inspect(item, f)
+		}
+	case *ObjectNode:
+		inspect(n.Input, f)
+		for _, pair := range n.Pairs {
+			inspect(pair.Key, f)
+			inspect(pair.Value, f)
+		}
+	}
 }
@@ -63,3 +63,51 @@ func TestBinaryOperators(t *testing.T) {
 		})
 	}
 }
+
+func TestConditionalOperators(t *testing.T) {
+	data := map[string]interface{}{
+		"Price": float64(50),
+		"Empty": "",
+		"Zero":  float64(0),
+		"Null":  nil,
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected interface{}
+	}{
+		{"Condition true", "Price < 100 ? 'cheap' : 'dear'", "cheap"},
+		{"Condition false", "Price < 10 ? 'cheap' : 'dear'", "dear"},
+		{"Condition undefined", "Missing ? 'yes' : 'no'", "no"},
+		{"Condition truthy", "Empty ? 'yes' : 'no'", "no"},
+		{"Nested condition", "Price < 10 ? 'cheap' : Price < 100 ? 'fair' : 'dear'", "fair"},
+		{"Condition binds loosest", "Price > 10 and Price < 100 ? 1 + 1 : 0", float64(2)},
+		{"Condition in block", "($p := Price > 10 ? 'a' : 'b'; $p)", "a"},
+		{"Default truthy", "Price ?: 1", float64(50)},
+		{"Default falsy", "Zero ?: 1", float64(1)},
+		{"Default empty string", "Empty ?: 'none'", "none"},
+		{"Default undefined", "Missing ?: 'none'", "none"},
+		{"Default chained", "Missing ?: Zero ?: 3", float64(3)},
+		{"Coalesce defined", "Price ?? 1", float64(50)},
+		{"Coalesce keeps falsy", "Zero ?? 1", float64(0)},
+		{"Coalesce keeps null", "Null ?? 1", nil},
+		{"Coalesce undefined", "Missing ?? 'none'", "none"},
+		{"Coalesce chained", "Missing ?? Other ?? 3", float64(3)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, run(t, data, tt.expr))
+		})
+	}
+}
+
+func TestConditionWithoutElse(t *testing.T) {
+	ast, err := Parse("Price > 100 ? 'dear'")
+	if !assert.NoError(t, err) {
+		return
+	}
+	res := Compile(ast).Run(lookup.NewScope(nil, lookup.Reflect(map[string]interface{}{"Price": float64(50)})))
+	assert.IsType(t, &lookup.Invalidor{}, res)
+}
@@ -0,0 +1,83 @@
+package jsonata
+
+import (
+	"github.com/arran4/go-evaluator"
+	"github.com/arran4/lookup"
+)
+
+// Callable is a function value that is invoked with the scope of the call and its arguments as Pathors. Unlike an
+// evaluator.Function, which only sees raw evaluated values, a Callable can tell undefined arguments (see IsUndefined)
+// from null ones and can call function values, such as lambdas, passed to it. Lambda implements Callable.
+type Callable interface {
+	Invoke(scope *lookup.Scope, args []lookup.Pathor) lookup.Pathor
+}
+
+// NativeFunction adapts a Go function to Callable. It also satisfies evaluator.Function so it can be registered in
+// Functions or an evaluator.Context alongside other functions.
+//
+//	jsonata.Functions["$twice"] = jsonata.NativeFunction(func(scope *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+//		return jsonata.Call(scope, args[0].Raw(), args[1])
+//	})
+type NativeFunction func(scope *lookup.Scope, args []lookup.Pathor) lookup.Pathor
+
+func (f NativeFunction) Invoke(scope *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+	return f(scope, args)
+}
+
+// Call invokes the function without a scope, nil arguments are passed as undefined and an undefined result is nil.
+func (f NativeFunction) Call(args ...interface{}) (interface{}, error) {
+	in := make([]lookup.Pathor, len(args))
+	for i, arg := range args {
+		if arg == nil {
+			in[i] = undefinedAt("", "argument not supplied")
+		} else {
+			in[i] = lookup.Reflect(arg)
+		}
+	}
+	res := f(&lookup.Scope{}, in)
+	if IsUndefined(res) {
+		return nil, nil
+	}
+	if err, ok := res.(*lookup.Invalidor); ok {
+		return nil, err
+	}
+	return res.Raw(), nil
+}
+
+// Call applies a function value, as found in the arguments of a Callable, to the arguments. A Lambda is only given as
+// many arguments as it has parameters, a SignedFunction as many as its signature requires and a partial application
+// as many as it has placeholders, so callers can offer extra information, such as the index of an item, which the
+// function may ignore.
+func Call(scope *lookup.Scope, fn interface{}, args ...lookup.Pathor) lookup.Pathor {
+	if n, ok := arity(fn); ok && len(args) > n {
+		args = args[:n]
+	}
+	return callFunction(scope, fn, args)
+}
+
+// arity is the number of arguments Call gives a function, ok is false when it takes any number.
+func arity(fn interface{}) (n int, ok bool) {
+	switch fn := fn.(type) {
+	case *Lambda:
+		return len(fn.Params), true
+	case *SignedFunction:
+		return fn.Signature.arity(), true
+	case *partialFunction:
+		for _, arg := range fn.args {
+			if arg == nil {
+				n++
+			}
+		}
+		return n, true
+	}
+	return 0, false
+}
+
+// isFunction reports whether v can be called as a function.
+func isFunction(v interface{}) bool {
+	switch v.(type) {
+	case Callable, evaluator.Function:
+		return true
+	}
+	return false
+}
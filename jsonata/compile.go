@@ -6,9 +6,15 @@ import (
 	"github.com/arran4/lookup"
 )
 
-// Compile converts the AST into a lookup.Runner.
+// Compile converts the AST into a lookup.Runner, evaluating within DefaultLimits.
 func Compile(ast *AST) lookup.Runner {
-	return &jsonataRunner{inner: compileNode(ast.Node)}
+	return CompileWithLimits(ast, DefaultLimits)
+}
+
+// CompileWithLimits converts the AST into a lookup.Runner whose evaluations fail with U1001 rather than exceed the
+// limits given.
+func CompileWithLimits(ast *AST, limits Limits) lookup.Runner {
+	return &jsonataRunner{inner: compileNode(ast.Node), limits: limits}
 }
 
 func compileNode(node Node) lookup.Runner {
@@ -17,138 +23,213 @@ func compileNode(node Node) lookup.Runner {
 		return compilePath(n)
 	case *BinaryNode:
 		return compileBinary(n)
+	case *NegationNode:
+		return &negationRunner{operand: compileNode(n.Operand)}
 	case *LiteralNode:
 		return lookup.Constant(n.Value)
+	case *ParentNode:
+		return &parentRunner{}
+	case *RegexNode:
+		return lookup.Constant(&Matcher{Regexp: n.Regexp})
+	case *ConditionNode:
+		return compileCondition(n, compileNode)
 	case *FunctionCallNode:
 		return compileFunctionCall(n)
+	case *VariableNode:
+		return &variableRunner{name: n.Name}
+	case *BindNode:
+		return &bindRunner{name: n.Name, value: compileNode(n.Value)}
+	case *BlockNode:
+		return compileBlock(n, compileNode)
+	case *LambdaNode:
+		return &lambdaRunner{params: n.Params, signature: n.Signature, body: compileTail(n.Body)}
+	case *TransformNode:
+		transform := &transformRunner{pattern: compileNode(n.Pattern), update: compileNode(n.Update)}
+		if n.Delete != nil {
+			transform.delete = compileNode(n.Delete)
+		}
+		return transform
+	case *ArrayNode:
+		array := &arrayRunner{}
+		for _, item := range n.Items {
+			_, nested := item.(*ArrayNode)
+			array.items = append(array.items, arrayItem{runner: compileNode(item), nested: nested})
+		}
+		return array
+	case *ObjectNode:
+		object := &objectRunner{}
+		if n.Input != nil {
+			object.input = compileNode(n.Input)
+		}
+		for _, pair := range n.Pairs {
+			object.pairs = append(object.pairs, objectPairRunner{key: compileNode(pair.Key), value: compileNode(pair.Value)})
+		}
+		return object
 	}
 	return lookup.Error(nil) // Should not happen
 }
 
-func compileFunctionCall(n *FunctionCallNode) lookup.Runner {
+// compileCondition compiles a condition, compiling its branches with compileBranch.
+func compileCondition(n *ConditionNode, compileBranch func(Node) lookup.Runner) lookup.Runner {
+	otherwise := lookup.Runner(lookup.Error(fmt.Errorf("condition is false and there is no else: %w", ErrUndefined)))
+	if n.Else != nil {
+		otherwise = compileBranch(n.Else)
+	}
+	return lookup.If(&booleanRunner{inner: compileNode(n.Condition)}, compileBranch(n.Then), otherwise)
+}
+
+// compileBlock compiles a block, compiling its last expression, the one it results in, with compileLast.
+func compileBlock(n *BlockNode, compileLast func(Node) lookup.Runner) lookup.Runner {
+	block := &blockRunner{}
+	for i, e := range n.Expressions {
+		if i == len(n.Expressions)-1 {
+			block.expressions = append(block.expressions, compileLast(e))
+		} else {
+			block.expressions = append(block.expressions, compileNode(e))
+		}
+	}
+	return block
+}
+
+// compileTail compiles an expression in tail position within the body of a lambda, where the result of a call is the
+// result of the lambda. Such calls to lambdas are left to the lambda invoking the body to make, so recursion through
+// them doesn't nest. The branches of a condition and the last expression of a block are in tail position when the
+// condition or block is.
+func compileTail(node Node) lookup.Runner {
+	switch n := node.(type) {
+	case *ConditionNode:
+		return compileCondition(n, compileTail)
+	case *BlockNode:
+		return compileBlock(n, compileTail)
+	case *FunctionCallNode:
+		if !n.isPartial() {
+			call := compileFunctionCall(n)
+			call.tail = true
+			return call
+		}
+	case *PathNode:
+		// A call on its own, `$f(x)`, or a parenthesized expression, `(...)`, is a path of a single step
+		if len(n.Steps) == 1 {
+			step := n.Steps[0]
+			if len(step.Predicates) == 0 && !step.usesTuples() {
+				switch {
+				case step.FunctionCall != nil:
+					return compileTail(step.FunctionCall)
+				case step.SubExpr != nil:
+					return compileTail(step.SubExpr)
+				}
+			}
+		}
+	}
+	return compileNode(node)
+}
+
+func compileFunctionCall(n *FunctionCallNode) *jsonataFunctionRunner {
 	var args []lookup.Runner
 	for _, arg := range n.Args {
+		if _, ok := arg.(*PlaceholderNode); ok {
+			args = append(args, nil)
+			continue
+		}
 		args = append(args, compileNode(arg))
 	}
 
 	// Function resolution is done at runtime via Scope/Context
-	return &jsonataFunctionRunner{Name: n.Name, Args: args}
+	r := &jsonataFunctionRunner{Name: n.Name, Args: args, partial: n.isPartial()}
+	if n.Callee != nil {
+		r.Callee = compileNode(n.Callee)
+	}
+	return r
 }
 
 func compileBinary(n *BinaryNode) lookup.Runner {
+	if n.Operator == "~>" {
+		return compileApply(n)
+	}
 	left := compileNode(n.Left)
 	right := compileNode(n.Right)
 
 	switch n.Operator {
 	case "&":
-		return lookup.StringConcat(left, right)
+		return &concatRunner{left: left, right: right}
 	case "+":
-		return lookup.Add(left, right)
+		return &arithmeticRunner{inner: lookup.Add(numberOperands(n.Operator, left, right)), operator: n.Operator}
 	case "-":
-		return lookup.Subtract(left, right)
+		return &arithmeticRunner{inner: lookup.Subtract(numberOperands(n.Operator, left, right)), operator: n.Operator}
 	case "*":
-		return lookup.Multiply(left, right)
+		return &arithmeticRunner{inner: lookup.Multiply(numberOperands(n.Operator, left, right)), operator: n.Operator}
 	case "/":
-		return lookup.Divide(left, right)
+		return &arithmeticRunner{inner: lookup.Divide(numberOperands(n.Operator, left, right)), operator: n.Operator}
 	case "%":
-		return lookup.Modulo(left, right)
+		return &arithmeticRunner{inner: lookup.Modulo(numberOperands(n.Operator, left, right)), operator: n.Operator}
 	case "=":
-		return lookup.BinaryEquals(left, right)
+		return &equalityRunner{left: left, right: right, compare: lookup.BinaryEquals}
 	case "!=":
-		return lookup.BinaryNotEquals(left, right)
+		return &equalityRunner{left: left, right: right, compare: lookup.BinaryNotEquals}
 	case ">":
-		return lookup.BinaryGreaterThan(left, right)
+		return &comparisonRunner{operator: n.Operator, left: left, right: right, compare: lookup.BinaryGreaterThan}
 	case "<":
-		return lookup.BinaryLessThan(left, right)
+		return &comparisonRunner{operator: n.Operator, left: left, right: right, compare: lookup.BinaryLessThan}
 	case ">=":
-		return lookup.BinaryGreaterThanOrEqual(left, right)
+		return &comparisonRunner{operator: n.Operator, left: left, right: right, compare: lookup.BinaryGreaterThanOrEqual}
 	case "<=":
-		return lookup.BinaryLessThanOrEqual(left, right)
+		return &comparisonRunner{operator: n.Operator, left: left, right: right, compare: lookup.BinaryLessThanOrEqual}
 	case "in":
-		return lookup.BinaryIn(left, right)
+		return &inclusionRunner{value: left, in: right}
 	case "and":
-		return lookup.BinaryAnd(left, right)
+		// Operands are cast as $boolean does, undefined being false
+		return lookup.BinaryAnd(&booleanRunner{inner: left}, &booleanRunner{inner: right})
 	case "or":
-		return lookup.BinaryOr(left, right)
+		return lookup.BinaryOr(&booleanRunner{inner: left}, &booleanRunner{inner: right})
 	case "..":
-		return lookup.Sequence(left, right)
+		start, end := integerOperands(n.Operator, left, right)
+		return &rangeRunner{start: start, end: end}
+	case "?:":
+		return &defaultRunner{value: left, otherwise: right}
+	case "??":
+		return &coalesceRunner{value: left, otherwise: right}
 	}
 	// Fallback
 	return lookup.Error(fmt.Errorf("unsupported binary operator: %s", n.Operator))
 }
 
-func compilePath(n *PathNode) lookup.Runner {
-	var r lookup.Runner = lookup.NewRelator()
-	for _, step := range n.Steps {
-		// Prepare opts (Filters and Indices)
-		opts := []lookup.Runner{}
-		if step.Index != nil {
-			opts = append(opts, &jsonataSingletonRunner{inner: lookup.Index(*step.Index)})
-		}
-		if step.Filter != nil {
-			var op lookup.Runner
-			switch step.Filter.Operator {
-			case "=":
-				op = lookup.Equals(lookup.Constant(step.Filter.Value))
-			case "!=":
-				op = lookup.NotEquals(lookup.Constant(step.Filter.Value))
-			case ">":
-				op = lookup.GreaterThan(lookup.Constant(step.Filter.Value))
-			case "<":
-				op = lookup.LessThan(lookup.Constant(step.Filter.Value))
-			case ">=":
-				op = lookup.GreaterThanOrEqual(lookup.Constant(step.Filter.Value))
-			case "<=":
-				op = lookup.LessThanOrEqual(lookup.Constant(step.Filter.Value))
-			default:
-				op = lookup.Equals(lookup.Constant(step.Filter.Value))
-			}
-
-			field := step.Filter.Field
-			var fieldRunner *lookup.Relator
-			if field == "$" {
-				fieldRunner = lookup.This()
-			} else {
-				fieldRunner = lookup.This(field)
-			}
-			filterRunner := lookup.Filter(fieldRunner.Find("", op))
-			opts = append(opts, &jsonataSingletonRunner{inner: filterRunner})
-		}
-
-		// Helper to apply opts
-		applyOpts := func(base lookup.Runner) lookup.Runner {
-			if len(opts) == 0 {
-				return base
-			}
-			// Use Find("", opts...) which chains runners on the result of base.
-			// But base needs to be linked.
-			// If base is This("name"), Find("", opts) works on that result.
-			// But here we return a Runner.
-
-			// We can chain base + Find("", opts...).
-			return &jsonataChain{
-				first:  base,
-				second: lookup.Find("", opts...),
-			}
+// compileApply compiles the function application operator. A call on the right hand side, `$f(args)` but not
+// `Orders.$f(args)` or `$f(?, arg)`, is made with the left hand side as an extra first argument.
+func compileApply(n *BinaryNode) lookup.Runner {
+	apply := &applyRunner{value: compileNode(n.Left)}
+	call, _ := n.Right.(*FunctionCallNode)
+	if path, ok := n.Right.(*PathNode); ok && len(path.Steps) == 1 {
+		if step := path.Steps[0]; step.FunctionCall != nil && len(step.Predicates) == 0 && !step.usesTuples() {
+			call = step.FunctionCall
 		}
+	}
+	// A partial application on the right is a function to compose or call, `$f ~> $substring(?, 0, 2)`
+	if call != nil && !call.isPartial() {
+		apply.call = compileFunctionCall(call)
+	} else {
+		apply.fn = compileNode(n.Right)
+	}
+	return apply
+}
 
+func compilePath(n *PathNode) lookup.Runner {
+	if usesTuples(n) {
+		return compileTuplePath(n)
+	}
+	var r lookup.Runner = lookup.NewRelator()
+	for i, step := range n.Steps {
 		if step.FunctionCall != nil {
 			// Function Call Step
 			funcRunner := compileFunctionCall(step.FunctionCall)
-			stepRunner := applyOpts(funcRunner)
-
-			// If the function call is part of a path (e.g. foo.bar()),
-			// the function is executed.
-			// NOTE: In JSONata, functions like $substring are usually global or defined in scope,
-			// not methods on objects (unless purely method call syntax which JSONata is loose about).
-			// If the step name is empty, it means just apply logic.
-
-			// We should probably treat it similar to SubExpr or simple name but with execution.
-			// But wait, step.FunctionCall.Args are expressions evaluated in current scope.
+			stepRunner := compilePredicates(funcRunner, step.Predicates)
 
-			// If this is part of a path chain, the previous result is current scope.
-
-			r = &jsonataChain{first: r, second: stepRunner}
+			// Leading the path (`$f(x).y`) the call is made once in the current context, later in the path
+			// (`foo.$f(x)`) it's made for each item of the previous step.
+			if i == 0 {
+				r = stepRunner
+			} else {
+				r = &jsonataChain{first: r, second: &jsonataMapRunner{stepRunner: stepRunner}}
+			}
 
 		} else if step.SubExpr != nil {
 			// SubExpression step: (expr).
@@ -158,10 +239,14 @@ func compilePath(n *PathNode) lookup.Runner {
 			// So we wrap in MapRunner.
 
 			subRunner := compileNode(step.SubExpr)
-			// Apply opts to the result of subExpr? `(expr)[0]`. Yes.
+			// Predicates apply to the result of the expression, `(expr)[0]`
+			stepRunner := compilePredicates(subRunner, step.Predicates)
 
-			// Combine subRunner + opts
-			stepRunner := applyOpts(subRunner)
+			// Leading the path the expression (a block, variable or lambda) is evaluated once in the current context.
+			if i == 0 {
+				r = stepRunner
+				continue
+			}
 
 			// Wrap in MapRunner to ensure iteration over current context
 			// We don't have a "Name" for this step, it's just a mapping.
@@ -173,30 +258,22 @@ func compilePath(n *PathNode) lookup.Runner {
 
 			r = &jsonataChain{first: r, second: mapRunner}
 
-		} else if step.Name == "$" {
-			// $ refers to the query root.
-			chainStep := &jsonataChain{
-				first:  &rootRunner{},
-				second: lookup.Find("", opts...),
+		} else if step.Wildcard != "" {
+			modifier := lookup.Runner(lookup.Children())
+			if step.Wildcard == "**" {
+				modifier = lookup.Descendants()
 			}
-
-			r = &jsonataChain{
-				first: r,
-				second: &jsonataMapRunner{
-					stepRunner: chainStep,
-					name:       "$",
-				},
+			mapRunner := &jsonataMapRunner{
+				stepRunner: compilePredicates(lookup.Find("", modifier), step.Predicates),
+				name:       step.Wildcard,
 			}
-
+			r = &jsonataChain{first: r, second: mapRunner}
 		} else {
 			if step.Name == "" {
-				// Just apply opts to current context.
-				r = &jsonataChain{
-					first:  r,
-					second: lookup.Find("", opts...),
-				}
+				// Just apply the predicates to the current context.
+				r = compilePredicates(r, step.Predicates)
 			} else {
-				stepRunner := lookup.This(step.Name).Find("", opts...)
+				stepRunner := compilePredicates(lookup.This(step.Name), step.Predicates)
 
 				mapRunner := &jsonataMapRunner{
 					stepRunner: stepRunner,
@@ -208,3 +285,85 @@ func compilePath(n *PathNode) lookup.Runner {
 	}
 	return r
 }
+
+// usesTuples reports whether a path sorts, binds variables or contains the parent operator, in which case its items
+// need to keep track of where they came from.
+func usesTuples(n *PathNode) bool {
+	found := false
+	inspect(n, func(node Node) bool {
+		switch node := node.(type) {
+		case *ParentNode:
+			found = true
+		case *PathNode:
+			for _, step := range node.Steps {
+				found = found || step.usesTuples()
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// compileTuplePath compiles a path to be evaluated as a stream of tuples.
+func compileTuplePath(n *PathNode) lookup.Runner {
+	r := &tuplePathRunner{}
+	for i, step := range n.Steps {
+		var ts tupleStep
+		switch {
+		case step.Sort != nil:
+			for _, term := range step.Sort {
+				ts.sort = append(ts.sort, sortTermRunner{expr: compileNode(term.Expr), descending: term.Descending})
+			}
+		case isParentStep(step):
+			ts.parent = true
+		case step.FunctionCall != nil:
+			ts.runner = compileFunctionCall(step.FunctionCall)
+			ts.leading = i == 0
+		case step.SubExpr != nil:
+			ts.runner = compileNode(step.SubExpr)
+			ts.leading = i == 0
+		case step.Wildcard == "**":
+			ts.runner = lookup.Find("", lookup.Descendants())
+		case step.Wildcard != "":
+			ts.runner = lookup.Find("", lookup.Children())
+		default:
+			ts.runner = lookup.This(step.Name)
+		}
+		// Predicates are evaluated against the tuples of the step's items so they can refer to their parent
+		for _, predicate := range step.Predicates {
+			ts.predicates = append(ts.predicates, compilePredicate(predicate))
+		}
+		ts.index = step.Index
+		ts.focus = step.Focus
+		for _, stage := range step.Stages {
+			ts.stages = append(ts.stages, compilePredicate(stage))
+		}
+		r.steps = append(r.steps, ts)
+	}
+	return r
+}
+
+// isParentStep reports whether the step is the parent operator without predicates, which moves back up the path.
+func isParentStep(step Step) bool {
+	_, ok := step.SubExpr.(*ParentNode)
+	return ok && len(step.Predicates) == 0
+}
+
+// compilePredicates chains the predicates of a step onto the runner producing the step's results, each predicate
+// filtering the sequence produced by the one before it.
+func compilePredicates(base lookup.Runner, predicates []Node) lookup.Runner {
+	for _, predicate := range predicates {
+		base = &jsonataChain{first: base, second: compilePredicate(predicate)}
+	}
+	return base
+}
+
+func compilePredicate(predicate Node) *predicateRunner {
+	pr := &predicateRunner{expr: compileNode(predicate)}
+	if lit, ok := predicate.(*LiteralNode); ok {
+		if f, ok := lookup.ToFloat(lit.Value); ok {
+			pr.index = &f
+		}
+	}
+	return pr
+}
@@ -0,0 +1,323 @@
+package jsonata
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/arran4/go-evaluator"
+	"github.com/arran4/lookup"
+)
+
+// ConformanceReport is the outcome of running a JSONata test suite, such as the one converted from upstream JSONata
+// in testdata/test-suite, showing how close the implementation is to JSONata.
+type ConformanceReport struct {
+	Groups []GroupReport `json:"groups"`
+	Passed int           `json:"passed"`
+	Total  int           `json:"total"`
+}
+
+// GroupReport is the outcome of the cases of a group of the suite, a group covering a feature such as
+// "function-substring" or "range-operator".
+type GroupReport struct {
+	Name   string       `json:"name"`
+	Passed int          `json:"passed"`
+	Total  int          `json:"total"`
+	Cases  []CaseReport `json:"cases"`
+}
+
+// CaseReport is the outcome of a case. A case which failed says why in Message, and when it produced the wrong
+// result gives the result expected and the actual result as JSON.
+type CaseReport struct {
+	Name     string `json:"name"`
+	Expr     string `json:"expr"`
+	Passed   bool   `json:"passed"`
+	Message  string `json:"message,omitempty"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+}
+
+// PassRate is the fraction of the cases of the report which passed, 0 when there are none.
+func (r *ConformanceReport) PassRate() float64 {
+	return passRate(r.Passed, r.Total)
+}
+
+// PassRate is the fraction of the cases of the group which passed, 0 when there are none.
+func (g *GroupReport) PassRate() float64 {
+	return passRate(g.Passed, g.Total)
+}
+
+func passRate(passed, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(passed) / float64(total)
+}
+
+// RunConformance runs the groups of a test suite laid out as testdata/test-suite is, a groups directory of txtar
+// files, one per group, and a datasets directory of the JSON inputs cases refer to. Only the groups match is true
+// for are run, all of them when match is nil.
+func RunConformance(suite fs.FS, match func(group string) bool) (*ConformanceReport, error) {
+	entries, err := fs.ReadDir(suite, "groups")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+	report := &ConformanceReport{}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".txtar")
+		if entry.IsDir() || !ok || match != nil && !match(name) {
+			continue
+		}
+		group, err := runConformanceGroup(suite, name)
+		if err != nil {
+			return nil, err
+		}
+		report.Groups = append(report.Groups, group)
+		report.Passed += group.Passed
+		report.Total += group.Total
+	}
+	return report, nil
+}
+
+func runConformanceGroup(suite fs.FS, name string) (GroupReport, error) {
+	group := GroupReport{Name: name}
+	cases, err := readConformanceGroup(suite, name)
+	if err != nil {
+		return group, err
+	}
+	for _, c := range cases {
+		result := checkCase(suite, c)
+		group.Cases = append(group.Cases, result)
+		group.Total++
+		if result.Passed {
+			group.Passed++
+		}
+	}
+	return group, nil
+}
+
+// readConformanceGroup reads the cases of a group in order of their names.
+func readConformanceGroup(suite fs.FS, name string) ([]txtarCase, error) {
+	filename := path.Join("groups", name+".txtar")
+	data, err := fs.ReadFile(suite, filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read txtar file %s: %w", filename, err)
+	}
+	cases, err := parseTxtar(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse txtar file %s: %w", filename, err)
+	}
+	sort.Slice(cases, func(i, j int) bool { return cases[i].Name < cases[j].Name })
+	return cases, nil
+}
+
+// checkCase runs a case, reporting whether it resulted in the value, undefined result or error it expects.
+func checkCase(suite fs.FS, c txtarCase) (report CaseReport) {
+	report = CaseReport{Name: c.Name, Expr: c.Expr}
+	// A panic is a failure of the case rather than of the whole run
+	defer func() {
+		if r := recover(); r != nil {
+			report.Passed = false
+			report.Message = fmt.Sprintf("panic: %v", r)
+		}
+	}()
+	fail := func(format string, args ...interface{}) CaseReport {
+		report.Message = fmt.Sprintf(format, args...)
+		return report
+	}
+
+	var sc suiteCase
+	if err := json.Unmarshal([]byte(c.Input), &sc); err != nil {
+		return fail("invalid suite case config: %v", err)
+	}
+
+	if sc.Code != "" {
+		jerr := caseError(suite, sc, c.Expr)
+		if jerr == nil || jerr.Code != sc.Code {
+			return fail("expected error %s, got %v", sc.Code, jerr)
+		}
+		report.Passed = true
+		return report
+	}
+
+	res, err := evaluateCase(suite, sc, c.Expr)
+	if err != nil {
+		return fail("evaluation failed: %v", err)
+	}
+	// An undefined result is only expected by cases saying so, null is a value
+	if sc.Undefined || IsUndefined(res) {
+		if sc.Undefined != IsUndefined(res) {
+			return fail("expected undefined %v, got %v", sc.Undefined, res)
+		}
+		report.Passed = true
+		return report
+	}
+	if failed, ok := res.(*lookup.Invalidor); ok {
+		return fail("evaluation failed: %v", failed)
+	}
+
+	expected, err := parseJSON(c.Expected)
+	if err != nil {
+		return fail("failed to parse expected json: %v", err)
+	}
+	out := res.Raw()
+	if !resultsEqual(expected, out) {
+		report.Expected = compactJSON(expected)
+		report.Actual = compactJSON(out)
+		return fail("result differs from the one expected")
+	}
+	report.Passed = true
+	return report
+}
+
+// evaluateCase parses and evaluates the expression of a case, resulting in the Pathor the evaluation resulted in.
+func evaluateCase(suite fs.FS, c suiteCase, expr string) (lookup.Pathor, error) {
+	// A case without input data is evaluated against undefined rather than null
+	root := undefined()
+	switch {
+	case c.Data != nil:
+		var data interface{}
+		if err := json.Unmarshal(c.Data, &data); err != nil {
+			return nil, fmt.Errorf("invalid case data: %w", err)
+		}
+		root = lookup.Reflect(data)
+	case c.Dataset != "":
+		data, err := loadDataset(suite, c.Dataset)
+		if err != nil {
+			return nil, err
+		}
+		root = lookup.Reflect(data)
+	}
+
+	ast, err := Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("parse failed: %w", err)
+	}
+	q := Compile(ast)
+	ctx := &evaluator.Context{
+		Functions: GetStandardFunctions(),
+	}
+	scope := lookup.NewScopeWithContext(nil, root, ctx)
+	for name, v := range c.Bindings {
+		scope.Bind(name, lookup.Reflect(v))
+	}
+	return q.Run(scope), nil
+}
+
+// caseError evaluates a case which is expected to fail, resulting in the Error it failed with, if any.
+func caseError(suite fs.FS, c suiteCase, expr string) *Error {
+	res, err := evaluateCase(suite, c, expr)
+	var jerr *Error
+	if errors.As(err, &jerr) {
+		return jerr
+	}
+	if err == nil && res != nil {
+		return errorOf(res)
+	}
+	return nil
+}
+
+func loadDataset(suite fs.FS, name string) (interface{}, error) {
+	filename := path.Join("datasets", name+".json")
+	data, err := fs.ReadFile(suite, filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	v, err := parseJSON(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %w", filename, err)
+	}
+	return v, nil
+}
+
+func parseJSON(data string) (interface{}, error) {
+	var v interface{}
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal json: %w", err)
+	}
+	return v, nil
+}
+
+// compactJSON formats a value for a report, falling back to Go's formatting for values JSON can't represent.
+func compactJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+// resultsEqual compares an expected suite value with an evaluation result
+// by their JSON representation, so numbers decoded as json.Number match
+// float64 or int results at any depth.
+func resultsEqual(expected, out interface{}) bool {
+	if reflect.DeepEqual(expected, out) {
+		return true
+	}
+	a, err := normalizeJSON(expected)
+	if err != nil {
+		return false
+	}
+	b, err := normalizeJSON(out)
+	if err != nil {
+		return false
+	}
+	return jsonValuesEqual(a, b)
+}
+
+func normalizeJSON(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return parseJSON(string(data))
+}
+
+func jsonValuesEqual(a, b interface{}) bool {
+	switch av := a.(type) {
+	case json.Number:
+		bv, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		af, aerr := av.Float64()
+		bf, berr := bv.Float64()
+		if aerr != nil || berr != nil {
+			return av == bv
+		}
+		return math.Abs(af-bf) <= 0.0000001*math.Max(1, math.Abs(af))
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !jsonValuesEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, v := range av {
+			w, ok := bv[k]
+			if !ok || !jsonValuesEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
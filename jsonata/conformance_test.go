@@ -0,0 +1,68 @@
+package jsonata
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunConformance(t *testing.T) {
+	suite := fstest.MapFS{
+		"datasets/people.json": {Data: []byte(`{"Name": "Fred"}`)},
+		"groups/arithmetic.txtar": {Data: []byte(`-- case000.json --
+{"dataset": "people"}
+-- case000.JSONATA --
+$uppercase(Name)
+-- case000_expected.json --
+"FRED"
+-- case001.json --
+{"data": {"Price": 5}}
+-- case001.JSONATA --
+Price + 1
+-- case001_expected.json --
+7
+-- case002.json --
+{"code": "T2001"}
+-- case002.JSONATA --
+"a" - 1
+-- case003.json --
+{"undefinedResult": true}
+-- case003.JSONATA --
+Missing
+`)},
+		"groups/other.txtar": {Data: []byte(`-- case000.json --
+{"code": "D3137"}
+-- case000.JSONATA --
+1
+`)},
+	}
+
+	report, err := RunConformance(suite, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 3, report.Passed)
+	assert.Equal(t, 5, report.Total)
+	if assert.Len(t, report.Groups, 2) {
+		arithmetic := report.Groups[0]
+		assert.Equal(t, "arithmetic", arithmetic.Name)
+		assert.Equal(t, 0.75, arithmetic.PassRate())
+		assert.Equal(t, CaseReport{
+			Name:     "case001",
+			Expr:     "Price + 1",
+			Message:  "result differs from the one expected",
+			Expected: "7",
+			Actual:   "6",
+		}, arithmetic.Cases[1])
+		assert.Equal(t, "expected error D3137, got <nil>", report.Groups[1].Cases[0].Message)
+	}
+
+	report, err = RunConformance(suite, func(group string) bool { return group == "other" })
+	if assert.NoError(t, err) && assert.Len(t, report.Groups, 1) {
+		assert.Equal(t, "other", report.Groups[0].Name)
+	}
+
+	_, err = RunConformance(fstest.MapFS{}, nil)
+	assert.Error(t, err)
+}
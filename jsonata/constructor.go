@@ -0,0 +1,162 @@
+package jsonata
+
+import "github.com/arran4/lookup"
+
+// constructedArray marks an array built by an array constructor. Unlike arrays from the input and sequences produced
+// by paths it isn't flattened into the results of a path step, nor unwrapped when it holds a single item.
+type constructedArray struct {
+	lookup.Pathor
+}
+
+func isConstructedArray(p lookup.Pathor) bool {
+	_, ok := p.(*constructedArray)
+	return ok
+}
+
+// arrayValue marks an array returned by a function. Like arrays from the input it's flattened into the results of a
+// path step, but it isn't a sequence so it's kept as an array when it holds a single item.
+type arrayValue struct {
+	lookup.Pathor
+}
+
+func isArrayValue(p lookup.Pathor) bool {
+	_, ok := p.(*arrayValue)
+	return ok
+}
+
+type arrayItem struct {
+	runner lookup.Runner
+	nested bool // the item is an array constructor so its result is kept as a single member
+}
+
+type arrayRunner struct {
+	items []arrayItem
+}
+
+func (r *arrayRunner) Run(scope *lookup.Scope) lookup.Pathor {
+	result := []interface{}{}
+	for _, item := range r.items {
+		v := item.runner.Run(scope)
+		if errorOf(v) != nil {
+			return v
+		}
+		if IsUndefined(v) {
+			continue
+		}
+		if item.nested {
+			result = append(result, v.Raw())
+			continue
+		}
+		result = appendValue(result, v)
+	}
+	return &constructedArray{lookup.Reflect(result)}
+}
+
+type objectPairRunner struct {
+	key   lookup.Runner
+	value lookup.Runner
+}
+
+type objectRunner struct {
+	input lookup.Runner // nil groups the context item
+	pairs []objectPairRunner
+}
+
+// objectGroup collects the items which produced the same key, they must all have come from the same pair.
+type objectGroup struct {
+	pair int
+	data []interface{}
+}
+
+func (r *objectRunner) Run(scope *lookup.Scope) lookup.Pathor {
+	input := scope.Current
+	if r.input != nil {
+		input = r.input.Run(scope)
+		if errorOf(input) != nil {
+			return input
+		}
+	}
+
+	var items []lookup.Pathor
+	switch {
+	case IsUndefined(input), input.IsNil():
+	case input.IsSlice():
+		s, _ := input.AsSlice()
+		for _, item := range s {
+			items = append(items, lookup.Reflect(item))
+		}
+	default:
+		items = []lookup.Pathor{input}
+	}
+	if len(items) == 0 {
+		// A single undefined item so that literal objects are still constructed
+		items = []lookup.Pathor{undefined()}
+	}
+
+	var keys []string
+	groups := map[string]*objectGroup{}
+	for _, item := range items {
+		itemScope := scope.Nest(item)
+		for i, pair := range r.pairs {
+			k := pair.key.Run(itemScope)
+			if errorOf(k) != nil {
+				return k
+			}
+			if IsUndefined(k) {
+				continue
+			}
+			key, err := k.AsString()
+			if err != nil {
+				return lookup.NewInvalidor(scope.Path(), errorf("T1003", "key in object structure must evaluate to a string; got: %v", k.Raw()))
+			}
+			g, ok := groups[key]
+			if !ok {
+				g = &objectGroup{pair: i}
+				groups[key] = g
+				keys = append(keys, key)
+			} else if g.pair != i {
+				return lookup.NewInvalidor(scope.Path(), errorf("D1009", "multiple key definitions evaluate to same key: %s", key))
+			}
+			if !IsUndefined(item) {
+				g.data = appendValue(g.data, item)
+			}
+		}
+	}
+
+	result := map[string]interface{}{}
+	for _, key := range keys {
+		g := groups[key]
+		var context lookup.Pathor
+		switch len(g.data) {
+		case 0:
+			context = undefined()
+		case 1:
+			context = lookup.Reflect(g.data[0])
+		default:
+			context = lookup.Reflect(g.data)
+		}
+		v := r.pairs[g.pair].value.Run(scope.Nest(context))
+		if errorOf(v) != nil {
+			return v
+		}
+		if IsUndefined(v) {
+			continue
+		}
+		result[key] = v.Raw()
+	}
+	return lookup.Reflect(result)
+}
+
+// appendValue appends a value to a sequence the way JSONata's $append does, arrays are concatenated and anything
+// else is added as a single member.
+func appendValue(seq []interface{}, v lookup.Pathor) []interface{} {
+	if v.IsSlice() {
+		s, _ := v.AsSlice()
+		if len(seq) == 0 {
+			// The array starts the sequence rather than being copied into it, clipped so appending to it copies it
+			return s[:len(s):len(s)]
+		}
+		return append(seq, s...)
+	}
+	return append(seq, v.Raw())
+}
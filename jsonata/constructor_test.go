@@ -0,0 +1,85 @@
+package jsonata
+
+import (
+	"testing"
+
+	"github.com/arran4/lookup"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConstructors(t *testing.T) {
+	data := map[string]interface{}{
+		"foo": map[string]interface{}{"a": float64(1), "b": []interface{}{float64(2), float64(3)}},
+		"Orders": []interface{}{
+			map[string]interface{}{"ID": "o1", "Type": "book", "Price": float64(10)},
+			map[string]interface{}{"ID": "o2", "Type": "pen", "Price": float64(2)},
+			map[string]interface{}{"ID": "o3", "Type": "book", "Price": float64(15)},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected interface{}
+	}{
+		{"Empty array", "[]", []interface{}{}},
+		{"Singleton array is kept", "[1]", []interface{}{float64(1)}},
+		{"Nested arrays", "[1, 2, [3, 4]]", []interface{}{float64(1), float64(2), []interface{}{float64(3), float64(4)}}},
+		{"Array index", "[1, 2, 3][0]", float64(1)},
+		{"Paths are flattened", "[foo.a, foo.b]", []interface{}{float64(1), float64(2), float64(3)}},
+		{"Undefined items are dropped", "[foo.a, foo.missing]", []interface{}{float64(1)}},
+		{"Array per item is not flattened", "Orders.[ID, Type]", []interface{}{
+			[]interface{}{"o1", "book"}, []interface{}{"o2", "pen"}, []interface{}{"o3", "book"},
+		}},
+		{"Empty object", "{}", map[string]interface{}{}},
+		{"Object literal", `{"one": 1, "two": [2]}`, map[string]interface{}{"one": float64(1), "two": []interface{}{float64(2)}}},
+		{"Undefined values are omitted", `{"a": foo.a, "b": foo.missing}`, map[string]interface{}{"a": float64(1)}},
+		{"Object per item", "Orders.{ID: Price}", []interface{}{
+			map[string]interface{}{"o1": float64(10)}, map[string]interface{}{"o2": float64(2)}, map[string]interface{}{"o3": float64(15)},
+		}},
+		{"Grouping", "Orders{Type: Price}", map[string]interface{}{
+			"book": []interface{}{float64(10), float64(15)}, "pen": float64(2),
+		}},
+		{"Grouping with aggregate", "Orders{Type: $sum(Price)}", map[string]interface{}{"book": float64(25), "pen": float64(2)}},
+		{"Grouping with several pairs", `Orders{Type: ID, "total": $sum(Price)}`, map[string]interface{}{
+			"book": []interface{}{"o1", "o3"}, "pen": "o2", "total": float64(27),
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, run(t, data, tt.expr))
+		})
+	}
+}
+
+func TestObjectConstructorErrors(t *testing.T) {
+	data := map[string]interface{}{
+		"Orders": []interface{}{
+			map[string]interface{}{"ID": float64(1), "Type": "book"},
+			map[string]interface{}{"ID": float64(2), "Type": "book"},
+		},
+	}
+	for _, expr := range []string{
+		"Orders{ID: Type}",
+		`Orders{Type: ID, "book": 1}`,
+	} {
+		t.Run(expr, func(t *testing.T) {
+			ast, err := Parse(expr)
+			if !assert.NoError(t, err) {
+				return
+			}
+			res := Compile(ast).Run(lookup.NewScope(nil, lookup.Reflect(data)))
+			assert.IsType(t, &lookup.Invalidor{}, res)
+		})
+	}
+}
+
+func TestParseConstructorErrors(t *testing.T) {
+	for _, expr := range []string{"[1, 2", "{'a' 1}", "{'a': 1"} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := Parse(expr)
+			assert.Error(t, err)
+		})
+	}
+}
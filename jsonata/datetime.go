@@ -0,0 +1,583 @@
+package jsonata
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dates and times are formatted and parsed with XPath picture strings, such as `[Y0001]-[M01]-[D01]`. Text in square
+// brackets is a variable marker made of a component, an optional presentation modifier and an optional width
+// modifier, `[MNn,3-3]` is the month name in title case abbreviated to three letters. Everything else is literal,
+// `[[` and `]]` escape brackets.
+
+// iso8601Picture is the picture used when none is given, ISO 8601 with milliseconds.
+const iso8601Picture = "[Y0001]-[M01]-[D01]T[H01]:[m01]:[s01].[f001][Z01:01t]"
+
+// defaultPresentations is the presentation of each component when the marker doesn't have one.
+var defaultPresentations = map[rune]string{
+	'Y': "1", 'M': "1", 'D': "1", 'd': "1", 'F': "n", 'W': "1", 'X': "1", 'w': "1", 'x': "1", 'H': "1", 'h': "1",
+	'P': "n", 'm': "01", 's': "01", 'f': "1", 'Z': "01:01", 'z': "01:01", 'C': "n", 'E': "n",
+}
+
+// dateTimeMarker is a variable marker of a picture, or literal text when component is 0.
+type dateTimeMarker struct {
+	literal      string
+	component    rune
+	presentation string
+	modifier     rune // second presentation modifier, `o` for ordinals or `t` for traditional
+	minWidth     int  // -1 when not given
+	maxWidth     int  // -1 when not given or `*`
+	integer      *integerPicture
+	yearDigits   int // the number of digits a year is truncated to, 0 to keep them all
+}
+
+func (m *dateTimeMarker) isNamed() bool {
+	switch m.presentation {
+	case "N", "n", "Nn":
+		return true
+	}
+	return false
+}
+
+// parseDateTimePicture splits a picture into literal text and analysed variable markers.
+func parseDateTimePicture(picture string) ([]*dateTimeMarker, error) {
+	var markers []*dateTimeMarker
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() > 0 {
+			markers = append(markers, &dateTimeMarker{literal: literal.String()})
+			literal.Reset()
+		}
+	}
+	runes := []rune(picture)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '[' && i+1 < len(runes) && runes[i+1] == '[':
+			literal.WriteRune('[')
+			i++
+		case r == ']' && i+1 < len(runes) && runes[i+1] == ']':
+			literal.WriteRune(']')
+			i++
+		case r == '[':
+			end := i + 1
+			for end < len(runes) && runes[end] != ']' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, errorf("D3135", "no matching closing bracket ']' in date/time picture string")
+			}
+			flush()
+			marker, err := parseDateTimeMarker(string(runes[i+1 : end]))
+			if err != nil {
+				return nil, err
+			}
+			markers = append(markers, marker)
+			i = end
+		default:
+			literal.WriteRune(r)
+		}
+	}
+	flush()
+	return markers, nil
+}
+
+func parseDateTimeMarker(spec string) (*dateTimeMarker, error) {
+	spec = strings.Join(strings.Fields(spec), "")
+	if spec == "" {
+		return nil, errorf("D3132", "empty variable marker in date/time picture string")
+	}
+	runes := []rune(spec)
+	m := &dateTimeMarker{component: runes[0], minWidth: -1, maxWidth: -1}
+	if _, ok := defaultPresentations[m.component]; !ok {
+		return nil, errorf("D3132", "%q is not a supported date/time component", string(m.component))
+	}
+	rest := string(runes[1:])
+	if i := strings.LastIndex(rest, ","); i >= 0 {
+		widths := strings.SplitN(rest[i+1:], "-", 2)
+		rest = rest[:i]
+		parseWidth := func(s string) (int, error) {
+			if s == "*" {
+				return -1, nil
+			}
+			return strconv.Atoi(s)
+		}
+		var err error
+		if m.minWidth, err = parseWidth(widths[0]); err != nil {
+			return nil, errorf("D3132", "invalid width modifier in date/time picture string: %w", err)
+		}
+		m.maxWidth = m.minWidth
+		if len(widths) > 1 {
+			if m.maxWidth, err = parseWidth(widths[1]); err != nil {
+				return nil, errorf("D3132", "invalid width modifier in date/time picture string: %w", err)
+			}
+		}
+	}
+	if n := len(rest); n > 1 && strings.ContainsRune("atco", rune(rest[n-1])) && rest != "Nn" {
+		m.modifier = rune(rest[n-1])
+		rest = rest[:n-1]
+	}
+	if rest == "" {
+		rest = defaultPresentations[m.component]
+	}
+	m.presentation = rest
+
+	if m.isNamed() || m.component == 'Z' || m.component == 'z' {
+		return m, nil
+	}
+	picture := m.presentation
+	if m.modifier == 'o' {
+		picture += ";o"
+	}
+	integer, err := parseIntegerPicture(picture)
+	if err != nil {
+		return nil, err
+	}
+	if integer.primary == decimalPrimary && m.minWidth > integer.mandatory {
+		integer.mandatory = m.minWidth
+	}
+	m.integer = integer
+	if m.component == 'Y' {
+		if m.maxWidth > 0 {
+			m.yearDigits = m.maxWidth
+		} else if digits := strings.Count(picture, "#") + integer.mandatory; integer.primary == decimalPrimary && digits >= 2 {
+			m.yearDigits = digits
+		}
+	}
+	return m, nil
+}
+
+var (
+	monthNames = []string{
+		"January", "February", "March", "April", "May", "June", "July", "August", "September", "October",
+		"November", "December",
+	}
+	dayNames = []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+)
+
+// formatDateTime formats t, in its own location, with a picture string.
+func formatDateTime(t time.Time, picture string) (string, error) {
+	markers, err := parseDateTimePicture(picture)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, m := range markers {
+		if m.component == 0 {
+			b.WriteString(m.literal)
+			continue
+		}
+		b.WriteString(m.format(t))
+	}
+	return b.String(), nil
+}
+
+func (m *dateTimeMarker) format(t time.Time) string {
+	switch m.component {
+	case 'Z', 'z':
+		return m.formatTimezone(t)
+	case 'f':
+		return m.formatFraction(t)
+	case 'P':
+		s := "am"
+		if t.Hour() >= 12 {
+			s = "pm"
+		}
+		return m.formatName(s)
+	case 'C':
+		return m.formatName("ISO")
+	case 'E':
+		return m.formatName("AD")
+	}
+	value := m.value(t)
+	if m.isNamed() {
+		switch m.component {
+		case 'M':
+			return m.formatName(monthNames[value-1])
+		case 'F':
+			return m.formatName(dayNames[value-1])
+		}
+	}
+	if m.integer == nil {
+		// a name presentation for a component without names
+		return strconv.Itoa(value)
+	}
+	if m.yearDigits > 0 {
+		value %= int(math.Pow10(m.yearDigits))
+	}
+	return m.integer.format(int64(value))
+}
+
+// value returns the numeric value of the marker's component.
+func (m *dateTimeMarker) value(t time.Time) int {
+	switch m.component {
+	case 'Y':
+		return t.Year()
+	case 'M':
+		return int(t.Month())
+	case 'D':
+		return t.Day()
+	case 'd':
+		return t.YearDay()
+	case 'F':
+		return (int(t.Weekday())+6)%7 + 1
+	case 'W':
+		_, week := t.ISOWeek()
+		return week
+	case 'X':
+		year, _ := t.ISOWeek()
+		return year
+	case 'w':
+		thursday := weekThursday(t)
+		return (thursday.Day()-1)/7 + 1
+	case 'x':
+		return int(weekThursday(t).Month())
+	case 'H':
+		return t.Hour()
+	case 'h':
+		if h := t.Hour() % 12; h != 0 {
+			return h
+		}
+		return 12
+	case 'm':
+		return t.Minute()
+	case 's':
+		return t.Second()
+	}
+	return 0
+}
+
+// weekThursday returns the Thursday of the Monday to Sunday week containing t, which decides the month a week
+// belongs to as it does the year of an ISO week.
+func weekThursday(t time.Time) time.Time {
+	return t.AddDate(0, 0, 3-(int(t.Weekday())+6)%7)
+}
+
+func (m *dateTimeMarker) formatName(name string) string {
+	switch m.presentation {
+	case "N":
+		name = strings.ToUpper(name)
+	case "n":
+		name = strings.ToLower(name)
+	}
+	if r := []rune(name); m.maxWidth > 0 && len(r) > m.maxWidth {
+		name = string(r[:m.maxWidth])
+	}
+	return name
+}
+
+// formatFraction writes the milliseconds as the digits following a decimal point, padded or truncated to the width
+// of the presentation.
+func (m *dateTimeMarker) formatFraction(t time.Time) string {
+	digits := fmt.Sprintf("%03d", t.Nanosecond()/int(time.Millisecond))
+	width := 1
+	if m.integer != nil && m.integer.primary == decimalPrimary {
+		width = m.integer.mandatory
+		if m.maxWidth > width {
+			width = m.maxWidth
+		}
+	}
+	if width < len(digits) {
+		digits = digits[:width]
+	} else {
+		digits += strings.Repeat("0", width-len(digits))
+	}
+	if m.integer != nil && m.integer.zero != '0' {
+		r := []rune(digits)
+		for i := range r {
+			r[i] = m.integer.zero + (r[i] - '0')
+		}
+		digits = string(r)
+	}
+	return digits
+}
+
+// formatTimezone writes the offset from UTC as hours and minutes laid out like the presentation, `01:01` gives
+// `+05:30` and `0101` gives `+0530`. The `t` modifier writes `Z` for UTC and `z` prefixes the offset with GMT.
+func (m *dateTimeMarker) formatTimezone(t time.Time) string {
+	_, offset := t.Zone()
+	minutes := offset / 60
+	if m.modifier == 't' && minutes == 0 {
+		return "Z"
+	}
+	sign := "+"
+	if minutes < 0 {
+		sign, minutes = "-", -minutes
+	}
+	hours, minutes := minutes/60, minutes%60
+	presentation := m.presentation
+	var s string
+	if i := strings.IndexFunc(presentation, func(r rune) bool { return r < '0' || r > '9' }); i >= 0 {
+		hoursWidth := i
+		s = fmt.Sprintf("%0*d%s%02d", hoursWidth, hours, presentation[i:i+1], minutes)
+	} else if len(presentation) <= 2 {
+		s = fmt.Sprintf("%0*d", len(presentation), hours)
+		if minutes != 0 {
+			s += fmt.Sprintf(":%02d", minutes)
+		}
+	} else {
+		s = fmt.Sprintf("%0*d%02d", len(presentation)-2, hours, minutes)
+	}
+	if m.component == 'z' {
+		return "GMT" + sign + s
+	}
+	return sign + s
+}
+
+// dateTimeComponents collects the components read from a string by parseDateTime.
+type dateTimeComponents map[rune]int
+
+// parseDateTime reads a time from a string laid out as described by a picture. Components the picture doesn't
+// include take their lowest value when they're less significant than one that is included, and are taken from now
+// when more significant, so `[H]:[m]` is a time today.
+func parseDateTime(s string, picture string, now time.Time) (time.Time, bool, error) {
+	markers, err := parseDateTimePicture(picture)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	var fields []*dateTimeMarker
+	for i, m := range markers {
+		if m.component == 0 {
+			pattern.WriteString(regexp.QuoteMeta(m.literal))
+			continue
+		}
+		adjacent := i+1 < len(markers) && markers[i+1].component != 0
+		pattern.WriteString("(" + m.regexp(adjacent) + ")")
+		fields = append(fields, m)
+	}
+	pattern.WriteString("$")
+	re, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	match := re.FindStringSubmatch(s)
+	if match == nil {
+		return time.Time{}, false, nil
+	}
+
+	components := dateTimeComponents{}
+	location := time.UTC
+	pm := -1
+	for i, m := range fields {
+		text := match[i+1]
+		switch m.component {
+		case 'Z', 'z':
+			loc, err := parseTimezone(strings.TrimPrefix(text, "GMT"))
+			if err != nil {
+				return time.Time{}, false, err
+			}
+			location = loc
+			continue
+		case 'P':
+			if strings.HasPrefix(strings.ToLower(text), "p") {
+				pm = 1
+			} else {
+				pm = 0
+			}
+			continue
+		case 'f':
+			digits := (text + "00")[:3]
+			ms, err := strconv.Atoi(digits)
+			if err != nil {
+				return time.Time{}, false, err
+			}
+			components['f'] = ms
+			continue
+		case 'C', 'E':
+			continue
+		}
+		value, err := m.parseValue(text)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		components[m.component] = value
+	}
+	if h, ok := components['h']; ok {
+		if h == 12 {
+			h = 0
+		}
+		if pm == 1 {
+			h += 12
+		}
+		components['H'] = h
+	}
+	return components.time(now.In(location), location), true, nil
+}
+
+// regexp returns a pattern matching the marker's component. Adjacent decimal markers need a fixed width to be told
+// apart.
+func (m *dateTimeMarker) regexp(adjacent bool) string {
+	switch m.component {
+	case 'Z':
+		return `Z|[+-][0-9]{1,2}(?::?[0-9]{2})?`
+	case 'z':
+		return `GMT[+-][0-9]{1,2}(?::?[0-9]{2})?`
+	case 'P':
+		return `[AaPp]\.?[Mm]\.?`
+	case 'f':
+		return `[0-9]+`
+	}
+	if m.isNamed() || m.component == 'C' || m.component == 'E' {
+		return `[A-Za-z]+`
+	}
+	switch m.integer.primary {
+	case lettersPrimary:
+		return `[A-Za-z]+`
+	case romanPrimary:
+		return `[MDCLXVImdclxvi]+`
+	case wordsPrimary:
+		// lazy so words don't run into the text of the following markers
+		return `[A-Za-z]+(?:(?:, | and |[ -])[A-Za-z]+)*?`
+	}
+	suffix := ""
+	if m.integer.ordinal {
+		suffix = `(?:st|nd|rd|th)?`
+	}
+	switch {
+	case m.maxWidth > 0:
+		return fmt.Sprintf(`[0-9]{1,%d}`, m.maxWidth) + suffix
+	case adjacent:
+		return fmt.Sprintf(`[0-9]{%d}`, m.integer.mandatory+strings.Count(m.presentation, "#")) + suffix
+	}
+	return `[0-9]+` + suffix
+}
+
+func (m *dateTimeMarker) parseValue(text string) (int, error) {
+	if m.isNamed() {
+		var names []string
+		switch m.component {
+		case 'M':
+			names = monthNames
+		case 'F':
+			names = dayNames
+		default:
+			return 0, errorf("D3133", "the %q component has no names", string(m.component))
+		}
+		for i, name := range names {
+			if len(text) >= 3 && strings.HasPrefix(strings.ToLower(name), strings.ToLower(text)) {
+				return i + 1, nil
+			}
+		}
+		return 0, errorf("D3110", "%q is not a recognised name", text)
+	}
+	var value int64
+	var err error
+	switch m.integer.primary {
+	case lettersPrimary:
+		value, err = lettersToInteger(text, m.integer.upper)
+	case romanPrimary:
+		value, err = romanToInteger(text)
+	case wordsPrimary:
+		value, err = wordsToInteger(text)
+	default:
+		value, err = m.integer.parseDecimal(text)
+	}
+	return int(value), err
+}
+
+// time builds the time the components describe, filling in those missing from now.
+func (c dateTimeComponents) time(now time.Time, location *time.Location) time.Time {
+	get := func(component rune, fallback int) int {
+		if v, ok := c[component]; ok {
+			return v
+		}
+		return fallback
+	}
+	// Components from the most to the least significant, the missing ones before the first given are taken from now
+	order := []rune{'Y', 'M', 'D', 'H', 'm', 's', 'f'}
+	current := []int{now.Year(), int(now.Month()), now.Day(), now.Hour(), now.Minute(), now.Second(), now.Nanosecond() / int(time.Millisecond)}
+	lowest := []int{0, 1, 1, 0, 0, 0, 0}
+	if _, ok := c['d']; ok {
+		// the day of the year stands in for the month and day
+		c['M'], c['D'] = 1, c['d']
+	}
+	values := make([]int, len(order))
+	given := false
+	for i, component := range order {
+		if _, ok := c[component]; ok {
+			given = true
+		}
+		if given {
+			values[i] = get(component, lowest[i])
+		} else {
+			values[i] = get(component, current[i])
+		}
+	}
+	return time.Date(values[0], time.Month(values[1]), values[2], values[3], values[4], values[5], values[6]*int(time.Millisecond), location)
+}
+
+// parseTimezone reads a UTC offset such as `+0530`, `-05:00`, `+5` or `Z`.
+func parseTimezone(s string) (*time.Location, error) {
+	if s == "Z" || s == "" {
+		return time.UTC, nil
+	}
+	sign := 1
+	switch s[0] {
+	case '-':
+		sign = -1
+		fallthrough
+	case '+':
+		s = s[1:]
+	default:
+		return nil, errorf("D3110", "invalid timezone %q", s)
+	}
+	s = strings.Replace(s, ":", "", 1)
+	var hours, minutes int
+	var err error
+	switch {
+	case len(s) <= 2:
+		hours, err = strconv.Atoi(s)
+	case len(s) <= 4:
+		if hours, err = strconv.Atoi(s[:len(s)-2]); err == nil {
+			minutes, err = strconv.Atoi(s[len(s)-2:])
+		}
+	default:
+		err = fmt.Errorf("too many digits")
+	}
+	if err != nil {
+		return nil, errorf("D3110", "invalid timezone %q: %w", s, err)
+	}
+	offset := sign * (hours*60 + minutes) * 60
+	if offset == 0 {
+		return time.UTC, nil
+	}
+	return time.FixedZone("", offset), nil
+}
+
+// iso8601Regex matches the forms of ISO 8601 accepted when no picture is given, from a year on its own to a date and
+// time with fractional seconds and an offset.
+var iso8601Regex = regexp.MustCompile(`^(\d{4})(?:-(\d{2})(?:-(\d{2})(?:T(\d{2}):(\d{2})(?::(\d{2})(?:\.(\d+))?)?(Z|[+-]\d{2}:?\d{2})?)?)?)?$`)
+
+// parseISO8601 reads a timestamp in ISO 8601 format, times without an offset are in UTC.
+func parseISO8601(s string) (time.Time, error) {
+	m := iso8601Regex.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}, errorf("D3110", "%q is not an ISO 8601 timestamp", s)
+	}
+	number := func(s string, fallback int) int {
+		if s == "" {
+			return fallback
+		}
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+	nanos := 0
+	if m[7] != "" {
+		nanos = number((m[7] + "000000000")[:9], 0)
+	}
+	location, err := parseTimezone(m[8])
+	if err != nil {
+		return time.Time{}, err
+	}
+	month, day := number(m[2], 1), number(m[3], 1)
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return time.Time{}, errorf("D3110", "%q is not a valid date", s)
+	}
+	return time.Date(number(m[1], 0), time.Month(month), day, number(m[4], 0), number(m[5], 0), number(m[6], 0), nanos, location), nil
+}
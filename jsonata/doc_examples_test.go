@@ -163,7 +163,7 @@ func TestDocExampleHigherOrderMap(t *testing.T) {
 	skipIf(t, testFeatureHigherOrderFunctions, "higher order functions")
 	data := loadPerson(t)
 	out := run(t, data, "$map(Phone,function($v){$v.number})")
-	_ = out
+	assert.Equal(t, []interface{}{"0203 544 1234", "01962 001234", "01962 001235", "077 7700 1234"}, out)
 }
 
 // TestDocExampleDateTimeNow demonstrates obtaining the current timestamp.
@@ -175,8 +175,8 @@ func TestDocExampleDateTimeNow(t *testing.T) {
 // TestDocExampleRegexMatch demonstrates regular expression matching.
 func TestDocExampleRegexMatch(t *testing.T) {
 	skipIf(t, testFeatureRegex, "regex functions")
-	out := run(t, nil, "$match('abc','/^a/')")
-	_ = out
+	out := run(t, nil, "$match('abc', /^a/)")
+	assert.Equal(t, []interface{}{map[string]interface{}{"match": "a", "index": float64(0), "groups": []interface{}{}}}, out)
 }
 
 // TestDocExampleWildcardPath demonstrates the wildcard path operator.
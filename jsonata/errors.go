@@ -0,0 +1,97 @@
+package jsonata
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/arran4/lookup"
+)
+
+// ErrUndefined is wrapped by the lookup.Invalidor an expression results in when it has no result, JSONata's
+// undefined. It's told apart from JSON null, which is a value: `Missing` is undefined whereas `{"a": null}.a` is null.
+var ErrUndefined = errors.New("undefined")
+
+// IsUndefined reports whether an evaluation had no result, as opposed to resulting in a value, null included, or
+// failing with an error.
+func IsUndefined(p lookup.Pathor) bool {
+	if isNilOrNilPointer(p) {
+		return true
+	}
+	inv, ok := p.(*lookup.Invalidor)
+	return ok && errors.Is(inv, ErrUndefined)
+}
+
+// undefinedAt is the undefined result of the path, the reason says why nothing was found.
+func undefinedAt(path string, reason string) *lookup.Invalidor {
+	return lookup.NewInvalidor(path, fmt.Errorf("%s: %w", reason, ErrUndefined))
+}
+
+// Error is an error found parsing or evaluating an expression. Code is the code JSONata gives the error, such as
+// S0201 for a syntax error or T2001 for arithmetic on something other than a number, so errors can be told apart
+// without matching their messages. It's returned by Parse and wrapped by the lookup.Invalidor an evaluation results
+// in, either way errors.As finds it:
+//
+//	var jerr *jsonata.Error
+//	if errors.As(err, &jerr) {
+//		fmt.Println(jerr.Code, jerr.Position, jerr.Token)
+//	}
+type Error struct {
+	Code     string
+	Position int    // offset of the offending token in characters from the start of the expression, -1 if not known
+	Token    string // the offending token, the operator or function name for errors found evaluating
+	Message  string
+	Err      error // the underlying error, if any
+}
+
+// errorf creates an Error with the code, its position isn't known. The format may wrap an error with %w.
+func errorf(code string, format string, args ...interface{}) *Error {
+	err := fmt.Errorf(format, args...)
+	return &Error{Code: code, Position: -1, Message: err.Error(), Err: errors.Unwrap(err)}
+}
+
+// invalid is errorf resulting in the lookup.Invalidor an evaluation fails with.
+func invalid(code string, format string, args ...interface{}) *lookup.Invalidor {
+	return lookup.NewInvalidor("", errorf(code, format, args...))
+}
+
+func (e *Error) Error() string {
+	msg := e.Code + ": " + e.Message
+	if e.Position >= 0 {
+		msg += fmt.Sprintf(" at position %d", e.Position)
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// withToken sets the token of an Error with none.
+func (e *Error) withToken(token string) *Error {
+	if e.Token == "" {
+		e.Token = token
+	}
+	return e
+}
+
+// errorOf finds the Error a failed evaluation resulted in, if it was one.
+func errorOf(p lookup.Pathor) *Error {
+	inv, ok := p.(*lookup.Invalidor)
+	if !ok {
+		return nil
+	}
+	var err *Error
+	if errors.As(inv, &err) {
+		return err
+	}
+	return nil
+}
+
+// characterOffset converts a byte offset into s into the offset in characters an Error's Position is given as.
+func characterOffset(s string, i int) int {
+	if i > len(s) {
+		i = len(s)
+	}
+	return utf8.RuneCountInString(s[:i])
+}
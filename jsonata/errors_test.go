@@ -0,0 +1,210 @@
+package jsonata
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/arran4/lookup"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr     string
+		code     string
+		position int
+		token    string
+	}{
+		{expr: `"no closing quote`, code: "S0101", position: 0, token: `"no closing quote`},
+		{expr: `"\q"`, code: "S0103", position: 1},
+		{expr: `"\u12"`, code: "S0104", position: 1},
+		{expr: "`name", code: "S0105", position: 0, token: "`name"},
+		{expr: `foo /* unclosed`, code: "S0106", position: 4, token: "/"},
+		{expr: `Account.Order[0].Product;`, code: "S0201", position: 24, token: ";"},
+		{expr: `[1,2)`, code: "S0202", position: 4, token: ")"},
+		{expr: `foo(1 2)`, code: "S0202", position: 6, token: "2"},
+		{expr: `[1, 2`, code: "S0203", position: 5},
+		{expr: `1 \ 2`, code: "S0204", position: 2, token: `\`},
+		{expr: `1 =`, code: "S0207", position: 3},
+		{expr: `function(x){ x }`, code: "S0208", position: 9, token: "x"},
+		{expr: `$[0]{"a": 1}[0]`, code: "S0209", position: 12, token: "["},
+		{expr: `$[0]{"a": 1}{"b": 2}`, code: "S0210", position: 12, token: "{"},
+		{expr: `@ bar`, code: "S0211", position: 0, token: "@"},
+		{expr: `a := 1`, code: "S0212", position: 2, token: ":="},
+		{expr: `Order#i`, code: "S0214", position: 6, token: "i"},
+		{expr: `//`, code: "S0301", position: 0, token: "/"},
+		{expr: `/abc`, code: "S0302", position: 0, token: "/"},
+		{expr: `function($x)<q>{ $x }`, code: "S0401", position: 12, token: "<q>"},
+		{expr: `"café" & ]`, code: "S0211", position: 9, token: "]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Parse(tt.expr)
+			var jerr *Error
+			if !assert.True(t, errors.As(err, &jerr), "expected a *jsonata.Error, got %v", err) {
+				return
+			}
+			assert.Equal(t, tt.code, jerr.Code, jerr.Error())
+			assert.Equal(t, tt.position, jerr.Position, jerr.Error())
+			if tt.token != "" {
+				assert.Equal(t, tt.token, jerr.Token, jerr.Error())
+			}
+		})
+	}
+}
+
+func TestEvaluationErrors(t *testing.T) {
+	data := map[string]interface{}{
+		"Name":  "hello",
+		"Items": []interface{}{map[string]interface{}{"Price": "free"}},
+	}
+	tests := []struct {
+		expr  string
+		code  string
+		token string
+	}{
+		{expr: `"s" - 1`, code: "T2001", token: "-"},
+		{expr: `1 + null`, code: "T2002", token: "+"},
+		{expr: `-Name`, code: "D1002", token: "-"},
+		{expr: `"a" < 1`, code: "T2009", token: "<"},
+		{expr: `[1] > 0`, code: "T2010", token: ">"},
+		{expr: `[1.5..3]`, code: "T2003", token: ".."},
+		{expr: `[1.."3"]`, code: "T2004", token: ".."},
+		{expr: `sum(Items)`, code: "T1005", token: "sum"},
+		{expr: `$nothing()`, code: "T1006", token: "$nothing"},
+		{expr: `2()`, code: "T1006"},
+		{expr: `$uppercase(1)`, code: "T0410"},
+		{expr: `{1: "a"}`, code: "T1003"},
+		{expr: `$number("abc")`, code: "D3030"},
+		{expr: `$sqrt(-1)`, code: "D3060"},
+		{expr: `$single([1, 2])`, code: "D3138"},
+		{expr: `Items.(Price * 2)`, code: "T2001", token: "*"},
+		{expr: `Name ~> |$|5|`, code: "T2011"},
+		{expr: `Name and ("a" - 1)`, code: "T2001", token: "-"},
+		{expr: `$exists($error("boom"))`, code: "D3137"},
+		{expr: `$uppercase($sqrt(-1))`, code: "D3060"},
+		{expr: `$count([$error("boom")])`, code: "D3137"},
+		{expr: `$count([1, $sqrt(-1)])`, code: "D3060"},
+		{expr: `1/0`, code: "D1001", token: "/"},
+		{expr: `5 % 0`, code: "D1001", token: "%"},
+		{expr: `10e300*10e100`, code: "D1001", token: "*"},
+		{expr: `{"a": 1/0}`, code: "D1001", token: "/"},
+		{expr: `"a" & (1/0)`, code: "D1001", token: "/"},
+		{expr: `(1/0) ?? 5`, code: "D1001", token: "/"},
+		{expr: `Items[$error("boom")]`, code: "D3137"},
+		{expr: `$map([1, 2], function($v) { $error("boom") })`, code: "D3137"},
+		{expr: `Items ~> |$|{"Price": 1/0}|`, code: "D1001", token: "/"},
+		{expr: `$pad("x", 1000000000)`, code: "D1001"},
+		{expr: `$pad("x", -1e300)`, code: "D1001"},
+		{expr: `$fromMillis(1e20)`, code: "D1001"},
+		{expr: `$fromMillis(-1e20)`, code: "D1001"},
+		{expr: `$round(1e308, 2)`, code: "D1001"},
+		{expr: `$formatBase(1e20, 2)`, code: "D1001"},
+		{expr: `$formatInteger(1e20, "w")`, code: "D1001"},
+		{expr: `$formatInteger(-9223372036854775808, "0")`, code: "D1001"},
+		{expr: `$formatInteger(5000000, "I")`, code: "D3130"},
+		{expr: `$formatInteger(5, "")`, code: "D3130"},
+		{expr: `$formatInteger(5, "#0#")`, code: "D3130"},
+		{expr: `$parseInteger("xyz", "I")`, code: "D3130"},
+		{expr: `$parseInteger("12a", "0")`, code: "D3130"},
+		{expr: `$base64decode("!!!")`, code: "D3140"},
+		{expr: `$substring("abc")`, code: "T0410"},
+		{expr: `$sum(["a"])`, code: "T0412"},
+		{expr: `$map([1], 2)`, code: "T0410"},
+		{expr: `$formatNumber(1, "0", {"decimal-separator": ""})`, code: "T0410"},
+		{expr: `$fromMillis(0, "[]")`, code: "D3132"},
+		{expr: `$fromMillis(0, "[Y,x]")`, code: "D3132"},
+		{expr: `$fromMillis(0, "[Y]", "05:00")`, code: "D3110"},
+		{expr: `$toMillis("2024-13-01")`, code: "D3110"},
+		{expr: `$toMillis("Foo 2024", "[MNn] [Y]")`, code: "D3110"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			ast, err := Parse(tt.expr)
+			if !assert.NoError(t, err) {
+				return
+			}
+			root := lookup.Reflect(data)
+			res := Compile(ast).Run(lookup.NewScope(root, root))
+			evalErr, _ := res.(error)
+			var jerr *Error
+			if !assert.True(t, errors.As(evalErr, &jerr), "expected a *jsonata.Error, got %v", res.Raw()) {
+				return
+			}
+			assert.Equal(t, tt.code, jerr.Code, jerr.Error())
+			assert.Equal(t, -1, jerr.Position)
+			if tt.token != "" {
+				assert.Equal(t, tt.token, jerr.Token)
+			}
+		})
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	_, err := Parse(`[1,2)`)
+	assert.EqualError(t, err, "S0202: expected ], got ) at position 4")
+
+	cause := errors.New("cause")
+	err = errorf("D3030", "unable to cast: %w", cause)
+	assert.EqualError(t, err, "D3030: unable to cast: cause")
+	assert.ErrorIs(t, err, cause)
+}
+
+func TestUndefinedAndNull(t *testing.T) {
+	data := map[string]interface{}{
+		"Null":  nil,
+		"Items": []interface{}{map[string]interface{}{"v": nil}, map[string]interface{}{"v": 1.0}, map[string]interface{}{}},
+	}
+	tests := []struct {
+		expr      string
+		undefined bool
+		expected  interface{}
+	}{
+		{expr: `Missing`, undefined: true},
+		{expr: `Null`, expected: nil},
+		{expr: `Null.Field`, undefined: true},
+		{expr: `Items.v`, expected: []interface{}{nil, 1.0}},
+		{expr: `$count(Items.v)`, expected: 2},
+		{expr: `Items[2].v`, undefined: true},
+		{expr: `Items[5]`, undefined: true},
+		{expr: `Null = null`, expected: true},
+		{expr: `Missing = null`, expected: false},
+		{expr: `Missing = Missing`, expected: false},
+		{expr: `Missing != 1`, expected: false},
+		{expr: `Null != 1`, expected: true},
+		{expr: `$exists(Null)`, expected: true},
+		{expr: `$exists(Missing)`, expected: false},
+		{expr: `$uppercase(Missing)`, undefined: true},
+		{expr: `$substring(Missing, 1)`, undefined: true},
+		{expr: `$lookup({"a": null}, "a")`, expected: nil},
+		{expr: `$nothing`, undefined: true},
+		{expr: `Null ?? "default"`, expected: nil},
+		{expr: `Missing ?? "default"`, expected: "default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			ast, err := Parse(tt.expr)
+			if !assert.NoError(t, err) {
+				return
+			}
+			root := lookup.Reflect(data)
+			res := Compile(ast).Run(lookup.NewScope(root, root))
+			assert.Equal(t, tt.undefined, IsUndefined(res), "%v", res)
+			if !tt.undefined {
+				assert.EqualValues(t, tt.expected, res.Raw())
+			}
+		})
+	}
+
+	ast, err := Parse(`"a" - 1`)
+	if assert.NoError(t, err) {
+		assert.False(t, IsUndefined(Compile(ast).Run(lookup.NewScope(nil, lookup.Reflect(nil)))), "an error isn't undefined")
+	}
+
+	v, err := Functions["$uppercase"].Call(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+}
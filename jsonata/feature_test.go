@@ -7,19 +7,19 @@ const (
 	testFeatureArrayIndexNavigation = true
 	testFeatureEqualityFilter       = true
 	testFeatureFunctionCalls        = false
-	testFeaturePredicate            = false
+	testFeaturePredicate            = true
 	testFeaturePathOperators        = false
 	testFeatureNumericOperators     = false
 	testFeatureBooleanOperators     = false
-	testFeatureBooleanFunctions     = false
-	testFeatureStringFunctions      = false
-	testFeatureNumericFunctions     = false
+	testFeatureBooleanFunctions     = true
+	testFeatureStringFunctions      = true
+	testFeatureNumericFunctions     = true
 	testFeatureAggregationFunctions = false
-	testFeatureArrayFunctions       = false
-	testFeatureObjectFunctions      = false
-	testFeatureHigherOrderFunctions = false
-	testFeatureDateTimeFunctions    = false
-	testFeatureRegex                = false
+	testFeatureArrayFunctions       = true
+	testFeatureObjectFunctions      = true
+	testFeatureHigherOrderFunctions = true
+	testFeatureDateTimeFunctions    = true
+	testFeatureRegex                = true
 )
 
 // groupStatus controls which test groups are enabled.
@@ -27,105 +27,157 @@ const (
 // If a group is missing, it runs in "strict" mode (failures break the build).
 // Eventually, this map should be empty as all groups are fixed.
 var groupStatus = map[string]bool{
-	"array-constructor":    true,
-	"blocks":               true,
+	// "array-constructor": true, // Fixed, running in strict mode
+	// "blocks": true, // Fixed, running in strict mode
 	"boolean-expresssions": true,
-	"closures":             true,
-	"coalescing-operator":  true,
+	// "closures": true, // Fixed, running in strict mode
+	// "coalescing-operator": true, // Fixed, running in strict mode
 	// "comments":                    true,
-	"comparison-operators":        true,
-	"conditionals":                true,
-	"context":                     true,
-	"default-operator":            true,
-	"descendent-operator":         true,
-	"encoding":                    true,
-	"errors":                      true,
-	"fields":                      true,
-	"flattening":                  true,
-	"function-abs":                true,
-	"function-append":             true,
-	"function-applications":       true,
-	"function-assert":             true,
-	"function-average":            true,
-	"function-boolean":            true,
-	"function-ceil":               true,
-	"function-contains":           true,
-	"function-count":              true,
+	"comparison-operators": true,
+	"conditionals":         true,
+	"context":              true,
+	// "default-operator": true, // Fixed, running in strict mode
+	// "descendent-operator": true, // Fixed, running in strict mode
+	// "encoding": true, // Fixed, running in strict mode
+	// "errors": true, // Fixed, running in strict mode
+	// "fields":                      true, // Fixed, running in strict mode
+	"flattening": true,
+	// "function-abs": true, // Fixed, running in strict mode
+	"function-append": true,
+	// "function-applications": true, // Fixed, running in strict mode
+	// "function-assert": true, // Fixed, running in strict mode
+	// "function-average": true, // Fixed, running in strict mode
+	// "function-boolean": true, // Fixed, running in strict mode
+	// "function-ceil": true, // Fixed, running in strict mode
+	// "function-contains": true, // Fixed, running in strict mode
+	// "function-count": true, // Fixed, running in strict mode
 	"function-decodeUrl":          true,
 	"function-decodeUrlComponent": true,
 	"function-each":               true,
 	"function-encodeUrl":          true,
 	"function-encodeUrlComponent": true,
-	"function-error":              true,
-	"function-eval":               true,
-	"function-exists":             true,
-	"function-floor":              true,
-	"function-formatBase":         true,
-	"function-formatNumber":       true,
-	"function-fromMillis":         true,
-	"function-join":               true,
-	"function-keys":               true,
-	"function-length":             true,
-	"function-lookup":             true,
-	"function-lowercase":          true,
-	"function-max":                true,
-	"function-merge":              true,
-	"function-number":             true,
-	"function-pad":                true,
-	"function-power":              true,
-	"function-replace":            true,
-	"function-reverse":            true,
-	"function-round":              true,
-	"function-shuffle":            true,
-	"function-sift":               true,
-	"function-signatures":         true,
-	"function-sort":               true,
-	"function-split":              true,
-	"function-spread":             true,
-	"function-sqrt":               true,
-	"function-string":             true,
-	"function-substring":          true,
-	"function-substringAfter":     true,
-	"function-substringBefore":    true,
-	"function-sum":                true,
-	"function-tomillis":           true,
-	"function-trim":               true,
-	"function-typeOf":             true,
-	"function-uppercase":          true,
-	"function-zip":                true,
-	"higher-order-functions":      true,
-	"hof-filter":                  true,
-	"hof-map":                     true,
-	"hof-reduce":                  true,
-	"hof-single":                  true,
-	"hof-zip-map":                 true,
-	"inclusion-operator":          true,
-	"lambdas":                     true,
-	"literals":                    true,
-	"matchers":                    true,
-	"multiple-array-selectors":    true,
-	"null":                        true,
-	"numeric-operators":           true,
-	"object-constructor":          true,
-	"parentheses":                 true,
-	"partial-application":         true,
-	"performance":                 true,
-	"predicates":                  true,
-	"quoted-selectors":            true,
-	"range-operator":              true,
-	"regex":                       true,
-	"simple-array-selectors":      true,
-	"sorting":                     true,
+	// "function-error": true, // Fixed, running in strict mode
+	// "function-eval": true, // Fixed, running in strict mode
+	// "function-exists": true, // Fixed, running in strict mode
+	// "function-floor": true, // Fixed, running in strict mode
+	"function-formatBase": true,
+	// "function-formatNumber": true, // Fixed, running in strict mode
+	// "function-fromMillis": true, // Fixed, running in strict mode
+	// "function-join": true, // Fixed, running in strict mode
+	"function-keys":   true,
+	"function-length": true,
+	// "function-lookup": true, // Fixed, running in strict mode
+	// "function-lowercase": true, // Fixed, running in strict mode
+	// "function-max": true, // Fixed, running in strict mode
+	// "function-merge": true, // Fixed, running in strict mode
+	// "function-number": true, // Fixed, running in strict mode
+	// "function-pad": true, // Fixed, running in strict mode
+	// "function-power": true, // Fixed, running in strict mode
+	// "function-replace": true, // Fixed, running in strict mode
+	"function-reverse": true,
+	// "function-round": true, // Fixed, running in strict mode
+	// "function-shuffle": true, // Fixed, running in strict mode
+	// "function-sift": true, // Fixed, running in strict mode
+	// "function-signatures": true, // Fixed, running in strict mode
+	"function-sort": true,
+	// "function-split": true, // Fixed, running in strict mode
+	"function-spread": true,
+	// "function-sqrt": true, // Fixed, running in strict mode
+	"function-string": true,
+	// "function-substring": true, // Fixed, running in strict mode
+	// "function-substringAfter": true, // Fixed, running in strict mode
+	// "function-substringBefore": true, // Fixed, running in strict mode
+	// "function-sum": true, // Fixed, running in strict mode
+	// "function-tomillis": true, // Fixed, running in strict mode
+	// "function-trim": true, // Fixed, running in strict mode
+	// "function-typeOf": true, // Fixed, running in strict mode
+	// "function-uppercase": true, // Fixed, running in strict mode
+	// "function-zip": true, // Fixed, running in strict mode
+	// "higher-order-functions": true, // Fixed, running in strict mode
+	"hof-filter": true,
+	"hof-map":    true,
+	"hof-reduce": true,
+	"hof-single": true,
+	// "hof-zip-map": true, // Fixed, running in strict mode
+	// "inclusion-operator": true, // Fixed, running in strict mode
+	// "lambdas": true, // Fixed, running in strict mode
+	"literals": true,
+	// "matchers": true, // Fixed, running in strict mode
+	// "multiple-array-selectors":    true, // Fixed, running in strict mode
+	// "null": true, // Fixed, running in strict mode
+	"numeric-operators": true,
+	// "object-constructor": true, // Fixed, running in strict mode
+	// "parentheses":                 true, // Fixed, running in strict mode
+	// "partial-application": true, // Fixed, running in strict mode
+	"performance": true,
+	// "predicates": true, // Fixed, running in strict mode
+	// "quoted-selectors": true, // Fixed, running in strict mode
+	"range-operator": true,
+	// "regex": true, // Fixed, running in strict mode
+	"simple-array-selectors": true,
+	// "sorting": true, // Fixed, running in strict mode
 	// "string-concat":               true, // Fixed, running in strict mode
-	"tail-recursion":   true,
+	// "tail-recursion": true, // Fixed, running in strict mode
 	"token-conversion": true,
-	"transform":        true,
-	"transforms":       true,
-	"variables":        true,
-	"wildcards":        true,
+	// "transform": true, // Fixed, running in strict mode
+	// "transforms": true, // Fixed, running in strict mode
+	// "variables": true, // Fixed, running in strict mode
+	// "wildcards": true, // Fixed, running in strict mode
 	// "missing-paths": true, // Not present means STRICT PASS
 }
 
+// knownFailures are the cases expected to fail, by group and case name, with the reason. They're skipped even in
+// strict mode, but fail once they pass so they're taken off the list. Most are cases whose input data, expression or
+// expected error was lost converting the suite to txtar, which result in null, or nothing, being expected instead.
+var knownFailures = map[string]string{
+	"function-signatures/case026": "expects the S0401 lost from the fixture",
+	"function-signatures/case034": "expects the S0402 lost from the fixture",
+	"matchers/case000":            "the expression was lost from the fixture",
+	"object-constructor/case013":  "expects the T1003 lost from the fixture",
+	"object-constructor/case014":  "expects the T1003 lost from the fixture",
+	"object-constructor/case017":  "expects the D1009 lost from the fixture",
+	"object-constructor/case023":  "the input data was lost from the fixture",
+	"object-constructor/case024":  "the input data was lost from the fixture",
+	"object-constructor/case025":  "the expression was lost from the fixture",
+	"predicates/case001":          "the input data was lost from the fixture",
+	"regex/case035":               "expects the D3012 lost from the fixture",
+	"regex/case036":               "expects the D3012 lost from the fixture",
+	"regex/case037":               "the input data was lost from the fixture",
+	"regex/case038":               "the input data was lost from the fixture",
+	"sorting/case014":             "the input data was lost from the fixture",
+	"sorting/case015":             "the input data was lost from the fixture",
+	"sorting/case016":             "the input data was lost from the fixture",
+	"sorting/case017":             "the input data was lost from the fixture",
+	"sorting/case018":             "the input data was lost from the fixture",
+	"sorting/case019":             "the input data was lost from the fixture",
+	"tail-recursion/case002":      "the expected result was lost from the fixture",
+	"transform/case003":           "the input data was lost from the fixture",
+	"transform/case004":           "the input data was lost from the fixture",
+	"transform/case005":           "the input data was lost from the fixture",
+	"transform/case006":           "the input data was lost from the fixture",
+	"transform/case007":           "the input data was lost from the fixture",
+	"transform/case011":           "the input data was lost from the fixture",
+	"transform/case012":           "the input data was lost from the fixture",
+	"transform/case013":           "the input data was lost from the fixture",
+	"transform/case014":           "the input data was lost from the fixture",
+	"transform/case015":           "the input data was lost from the fixture",
+	"transform/case016":           "the input data was lost from the fixture",
+	"transform/case017":           "the input data was lost from the fixture",
+	"transform/case018":           "the input data was lost from the fixture",
+	"transform/case019":           "the input data was lost from the fixture",
+	"transform/case020":           "the input data was lost from the fixture",
+	"transform/case023":           "the input data was lost from the fixture",
+	"transform/case024":           "the input data was lost from the fixture",
+	"transform/case025":           "the input data was lost from the fixture",
+	"transform/case026":           "the input data was lost from the fixture",
+	"transform/case057":           "expects the S0203 lost from the fixture",
+	"transforms/case013":          "the input data was lost from the fixture",
+	"transforms/case014":          "the input data was lost from the fixture",
+	"variables/case012":           "expects the S0212 lost from the fixture",
+	"wildcards/case006":           "the fields of a Go map are visited in sorted key order, not the document's",
+	"wildcards/case009":           "the input data was lost from the fixture",
+}
+
 func skipIf(t *testing.T, feature bool, name string) {
 	if !feature {
 		t.Skip("feature: " + name + " not ready for implementation")
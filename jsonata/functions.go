@@ -1,21 +1,81 @@
 package jsonata
 
 import (
-	"fmt"
 	"unicode/utf8"
 
 	"github.com/arran4/go-evaluator"
 	"github.com/arran4/lookup"
 )
 
+// Functions is the default function registry. Functions are looked up in the scope's evaluator.Context first and then
+// here, so registering a function in this map makes it available to every expression. The standard functions carry
+// their JSONata signatures so their arguments are validated, and a missing first argument taken from the context
+// item, before they're called.
+var Functions = GetStandardFunctions()
+
 func GetStandardFunctions() map[string]evaluator.Function {
 	return map[string]evaluator.Function{
-		"$substring": &substringFunc{},
-		"$sum":       &sumFunc{},
-		"$count":     &countFunc{},
-		"$max":       &maxFunc{},
-		"$min":       &minFunc{},
-		"$average":   &averageFunc{},
+		"$substring":       WithSignature("<s-nn?:s>", &substringFunc{}),
+		"$substringBefore": WithSignature("<s-s:s>", &substringBeforeFunc{}),
+		"$substringAfter":  WithSignature("<s-s:s>", &substringAfterFunc{}),
+		"$string":          WithSignature("<x-b?:s>", NativeFunction(stringFunction)),
+		"$length":          WithSignature("<s-:n>", &lengthFunc{}),
+		"$uppercase":       WithSignature("<s-:s>", &uppercaseFunc{}),
+		"$lowercase":       WithSignature("<s-:s>", &lowercaseFunc{}),
+		"$trim":            WithSignature("<s-:s>", &trimFunc{}),
+		"$pad":             WithSignature("<s-ns?:s>", &padFunc{}),
+		"$contains":        WithSignature("<s-(sf):b>", &containsFunc{}),
+		"$split":           WithSignature("<s-(sf)n?:a<s>>", &splitFunc{}),
+		"$join":            WithSignature("<a<s>s?:s>", &joinFunc{}),
+		"$replace":         WithSignature("<s-(sf)(sf)n?:s>", &replaceFunc{}),
+		"$match":           WithSignature("<s-f<s:o>n?:a<o>>", &matchFunc{}),
+		"$base64encode":    WithSignature("<s-:s>", &base64EncodeFunc{}),
+		"$base64decode":    WithSignature("<s-:s>", &base64DecodeFunc{}),
+		"$number":          WithSignature("<(nsb)-:n>", &numberFunc{}),
+		"$abs":             WithSignature("<n-:n>", &absFunc{}),
+		"$floor":           WithSignature("<n-:n>", &floorFunc{}),
+		"$ceil":            WithSignature("<n-:n>", &ceilFunc{}),
+		"$round":           WithSignature("<n-n?:n>", &roundFunc{}),
+		"$power":           WithSignature("<n-n:n>", &powerFunc{}),
+		"$sqrt":            WithSignature("<n-:n>", &sqrtFunc{}),
+		"$random":          WithSignature("<:n>", &randomFunc{}),
+		"$formatNumber":    WithSignature("<n-so?:s>", &formatNumberFunc{}),
+		"$formatBase":      WithSignature("<n-n?:s>", &formatBaseFunc{}),
+		"$formatInteger":   WithSignature("<n-s:s>", &formatIntegerFunc{}),
+		"$parseInteger":    WithSignature("<s-s:n>", &parseIntegerFunc{}),
+		"$now":             WithSignature("<s?s?:s>", clockFunction(nowFunction)),
+		"$millis":          WithSignature("<:n>", clockFunction(millisFunction)),
+		"$fromMillis":      WithSignature("<n-s?s?:s>", &fromMillisFunc{}),
+		"$toMillis":        WithSignature("<s-s?:n>", clockFunction(toMillisFunction)),
+		"$sum":             WithSignature("<a<n>:n>", &sumFunc{}),
+		"$count":           WithSignature("<a:n>", &countFunc{}),
+		"$max":             WithSignature("<a<n>:n>", &maxFunc{}),
+		"$min":             WithSignature("<a<n>:n>", &minFunc{}),
+		"$average":         WithSignature("<a<n>:n>", &averageFunc{}),
+		"$map":             WithSignature("<af>", NativeFunction(mapFunction)),
+		"$filter":          WithSignature("<af>", NativeFunction(filterFunction)),
+		"$reduce":          WithSignature("<afj?:j>", NativeFunction(reduceFunction)),
+		"$sift":            WithSignature("<o-f?:o>", NativeFunction(siftFunction)),
+		"$each":            WithSignature("<o-f:a>", NativeFunction(eachFunction)),
+		"$single":          WithSignature("<af?>", NativeFunction(singleFunction)),
+		"$zip":             WithSignature("<a+>", NativeFunction(zipFunction)),
+		"$keys":            WithSignature("<x-:a<s>>", NativeFunction(keysFunction)),
+		"$lookup":          WithSignature("<x-s:x>", NativeFunction(lookupKeyFunction)),
+		"$spread":          WithSignature("<x-:a<o>>", NativeFunction(spreadFunction)),
+		"$merge":           WithSignature("<a<o>:o>", &mergeFunc{}),
+		"$type":            WithSignature("<x:s>", NativeFunction(typeFunction)),
+		"$typeOf":          WithSignature("<x:s>", NativeFunction(typeFunction)),
+		"$append":          WithSignature("<xx:a>", &appendFunc{}),
+		"$reverse":         WithSignature("<a:a>", &reverseFunc{}),
+		"$shuffle":         WithSignature("<a:a>", &shuffleFunc{}),
+		"$sort":            WithSignature("<af?:a>", NativeFunction(sortFunction)),
+		"$distinct":        WithSignature("<x:x>", NativeFunction(distinctFunction)),
+		"$boolean":         WithSignature("<x-:b>", NativeFunction(booleanFunction)),
+		"$not":             WithSignature("<x-:b>", NativeFunction(notFunction)),
+		"$exists":          WithSignature("<x:b>", NativeFunction(existsFunction)),
+		"$eval":            WithSignature("<sx?:j>", NativeFunction(evalFunction)),
+		"$assert":          WithSignature("<bs?:x>", NativeFunction(assertFunction)),
+		"$error":           WithSignature("<s?:x>", NativeFunction(errorFunction)),
 	}
 }
 
@@ -23,23 +83,29 @@ type substringFunc struct{}
 
 func (s *substringFunc) Call(args ...interface{}) (interface{}, error) {
 	if len(args) < 2 {
-		return nil, fmt.Errorf("expected at least 2 arguments")
+		return nil, errorf("T0410", "expected at least 2 arguments")
+	}
+	if args[0] == nil {
+		return nil, nil
 	}
 
 	str, ok := args[0].(string)
 	if !ok {
-		return nil, fmt.Errorf("argument 0 must be a string")
+		return nil, errorf("T0410", "argument 0 must be a string")
 	}
 
 	start, ok := lookup.ToInt(args[1])
 	if !ok {
-		return nil, fmt.Errorf("argument 1 must be an integer")
+		return nil, errorf("T0410", "argument 1 must be an integer")
 	}
 
 	length := -1
 	if len(args) > 2 {
 		l, ok := lookup.ToInt(args[2])
 		if ok {
+			if l < 0 {
+				return "", nil
+			}
 			length = int(l)
 		}
 	}
@@ -51,10 +117,6 @@ func (s *substringFunc) Call(args ...interface{}) (interface{}, error) {
 		start = 0
 	}
 
-	if length != -1 && length < 0 {
-		return "", nil
-	}
-
 	targetStart := int(start)
 	targetEnd := -1
 	if length != -1 {
@@ -108,7 +170,7 @@ func (s *sumFunc) Call(args ...interface{}) (interface{}, error) {
 				// JSONata ignores non-numbers? Or errors?
 				// "It is an error if ... not a number"
 				// Let's try convert.
-				return nil, fmt.Errorf("item in array is not a number: %T %v", item, item)
+				return nil, errorf("T0412", "item in array is not a number: %T %v", item, item)
 			}
 			val += f
 		}
@@ -119,7 +181,7 @@ func (s *sumFunc) Call(args ...interface{}) (interface{}, error) {
 	default:
 		f, ok := lookup.ToFloat(v)
 		if !ok {
-			return nil, fmt.Errorf("argument must be an array of numbers or a number")
+			return nil, errorf("T0410", "argument must be an array of numbers or a number")
 		}
 		val = f
 	}
@@ -174,14 +236,14 @@ func (s *maxFunc) Call(args ...interface{}) (interface{}, error) {
 		for _, item := range v {
 			f, ok := lookup.ToFloat(item)
 			if !ok {
-				return nil, fmt.Errorf("item in array is not a number")
+				return nil, errorf("T0412", "item in array is not a number")
 			}
 			process(f)
 		}
 	default:
 		f, ok := lookup.ToFloat(v)
 		if !ok {
-			return nil, fmt.Errorf("argument must be an array of numbers or a number")
+			return nil, errorf("T0410", "argument must be an array of numbers or a number")
 		}
 		process(f)
 	}
@@ -219,14 +281,14 @@ func (s *minFunc) Call(args ...interface{}) (interface{}, error) {
 		for _, item := range v {
 			f, ok := lookup.ToFloat(item)
 			if !ok {
-				return nil, fmt.Errorf("item in array is not a number")
+				return nil, errorf("T0412", "item in array is not a number")
 			}
 			process(f)
 		}
 	default:
 		f, ok := lookup.ToFloat(v)
 		if !ok {
-			return nil, fmt.Errorf("argument must be an array of numbers or a number")
+			return nil, errorf("T0410", "argument must be an array of numbers or a number")
 		}
 		process(f)
 	}
@@ -259,7 +321,7 @@ func (s *averageFunc) Call(args ...interface{}) (interface{}, error) {
 		for _, item := range v {
 			f, ok := lookup.ToFloat(item)
 			if !ok {
-				return nil, fmt.Errorf("item in array is not a number")
+				return nil, errorf("T0412", "item in array is not a number")
 			}
 			sum += f
 			count++
@@ -267,7 +329,7 @@ func (s *averageFunc) Call(args ...interface{}) (interface{}, error) {
 	default:
 		f, ok := lookup.ToFloat(v)
 		if !ok {
-			return nil, fmt.Errorf("argument must be an array of numbers or a number")
+			return nil, errorf("T0410", "argument must be an array of numbers or a number")
 		}
 		sum = f
 		count = 1
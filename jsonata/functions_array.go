@@ -0,0 +1,177 @@
+package jsonata
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+
+	"github.com/arran4/lookup"
+)
+
+type appendFunc struct{}
+
+// Call implements $append(array1, array2), an array of the values of both arguments. Arguments which aren't arrays are
+// treated as arrays of one value and an undefined argument contributes nothing.
+func (s *appendFunc) Call(args ...interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, errorf("T0410", "$append expects 2 arguments")
+	}
+	if args[0] == nil {
+		return args[1], nil
+	}
+	if args[1] == nil {
+		return args[0], nil
+	}
+	result := appendValue(nil, lookup.Reflect(args[0]))
+	return appendValue(result, lookup.Reflect(args[1])), nil
+}
+
+type reverseFunc struct{}
+
+// Call implements $reverse(array), a copy of the array in reverse order.
+func (s *reverseFunc) Call(args ...interface{}) (interface{}, error) {
+	items, ok, err := arrayArgs("$reverse", args, 1, 1)
+	if !ok {
+		return nil, err
+	}
+	result := make([]interface{}, len(items))
+	for i, item := range items {
+		result[len(items)-1-i] = item
+	}
+	return result, nil
+}
+
+type shuffleFunc struct{}
+
+// Call implements $shuffle(array), a copy of the array in random order.
+func (s *shuffleFunc) Call(args ...interface{}) (interface{}, error) {
+	items, ok, err := arrayArgs("$shuffle", args, 1, 1)
+	if !ok {
+		return nil, err
+	}
+	result := append([]interface{}{}, items...)
+	rand.Shuffle(len(result), func(i, j int) {
+		result[i], result[j] = result[j], result[i]
+	})
+	return result, nil
+}
+
+// sortFunction implements $sort(array [, function]). Without a function the array must hold only numbers or only
+// strings, which are sorted in ascending order. The function is called with pairs of values and returns true when the
+// first should come after the second. The sort is stable.
+func sortFunction(scope *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+	if len(args) < 1 || len(args) > 2 {
+		return invalid("T0410", "$sort expects 1 to 2 arguments")
+	}
+	if IsUndefined(args[0]) {
+		return undefined()
+	}
+	items := append([]interface{}{}, sequenceOf(args[0])...)
+	if len(args) > 1 && !IsUndefined(args[1]) {
+		fn, err := functionArg("$sort", args, 1)
+		if err != nil {
+			return lookup.NewInvalidor("", err)
+		}
+		var failed lookup.Pathor
+		sort.SliceStable(items, func(i, j int) bool {
+			if failed != nil {
+				return false
+			}
+			// items[i] comes first when the function says items[j] comes after it
+			res := Call(scope, fn, lookup.Reflect(items[j]), lookup.Reflect(items[i]))
+			if errorOf(res) != nil {
+				failed = res
+				return false
+			}
+			return !IsUndefined(res) && toBoolean(res.Raw())
+		})
+		if failed != nil {
+			return failed
+		}
+	} else if err := sortValues(items); err != nil {
+		return lookup.NewInvalidor("", err)
+	}
+	return &arrayValue{lookup.Reflect(items)}
+}
+
+// sortValues sorts numbers or strings in ascending order.
+func sortValues(items []interface{}) error {
+	strings, numbers := 0, 0
+	for _, item := range items {
+		if _, ok := item.(string); ok {
+			strings++
+		} else if _, ok := lookup.ToFloat(item); ok {
+			numbers++
+		}
+	}
+	switch len(items) {
+	case strings:
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].(string) < items[j].(string)
+		})
+	case numbers:
+		sort.SliceStable(items, func(i, j int) bool {
+			a, _ := lookup.ToFloat(items[i])
+			b, _ := lookup.ToFloat(items[j])
+			return a < b
+		})
+	default:
+		return errorf("D3070", "$sort can only sort arrays of numbers or strings without a comparison function")
+	}
+	return nil
+}
+
+// distinctFunction implements $distinct(array), the array without duplicate values, keeping the first of each.
+func distinctFunction(_ *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+	if len(args) != 1 {
+		return invalid("T0410", "$distinct expects 1 argument")
+	}
+	if IsUndefined(args[0]) {
+		return undefined()
+	}
+	if !args[0].IsSlice() {
+		return args[0]
+	}
+	var results []interface{}
+	for _, item := range sequenceOf(args[0]) {
+		duplicate := false
+		for _, existing := range results {
+			if deepEqual(existing, item) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			results = append(results, item)
+		}
+	}
+	if isConstructedArray(args[0]) || isArrayValue(args[0]) {
+		return &arrayValue{lookup.Reflect(results)}
+	}
+	return sequenceResult(results)
+}
+
+// deepEqual compares values as JSON, numbers are equal whatever their Go type.
+func deepEqual(a, b interface{}) bool {
+	if fa, ok := lookup.ToFloat(a); ok {
+		fb, ok := lookup.ToFloat(b)
+		return ok && fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// arrayArgs checks an array function was given between min and max arguments and returns the values of the first,
+// a value which isn't an array is an array of one. ok is false when the function has nothing to return, either because
+// of err or because the array is undefined.
+func arrayArgs(name string, args []interface{}, min, max int) (items []interface{}, ok bool, err error) {
+	if len(args) < min || len(args) > max {
+		if min == max {
+			return nil, false, errorf("T0410", "%s expects %d arguments", name, min)
+		}
+		return nil, false, errorf("T0410", "%s expects %d to %d arguments", name, min, max)
+	}
+	if args[0] == nil {
+		return nil, false, nil
+	}
+	return sequenceOf(lookup.Reflect(args[0])), true, nil
+}
@@ -0,0 +1,73 @@
+package jsonata
+
+import (
+	"testing"
+
+	"github.com/arran4/lookup"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArrayFunctions(t *testing.T) {
+	data := map[string]interface{}{
+		"Numbers": []interface{}{3.0, 1.0, 2.0},
+		"Words":   []interface{}{"pear", "apple", "fig"},
+		"Product": []interface{}{
+			map[string]interface{}{"Name": "Hat", "Price": 12.5},
+			map[string]interface{}{"Name": "Cap", "Price": 7.0},
+			map[string]interface{}{"Name": "Fez", "Price": 12.5},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected interface{}
+	}{
+		{"Append", `$append([1, 2], 3)`, []interface{}{float64(1), float64(2), float64(3)}},
+		{"Append arrays", `$append([1], [2, 3])`, []interface{}{float64(1), float64(2), float64(3)}},
+		{"Append values", `$append(1, 2)`, []interface{}{float64(1), float64(2)}},
+		{"Append undefined", `$append(Numbers, Missing)`, []interface{}{3.0, 1.0, 2.0}},
+		{"Append to undefined", `$append(Missing, [1])`, []interface{}{float64(1)}},
+		{"Reverse", `$reverse(Numbers)`, []interface{}{2.0, 1.0, 3.0}},
+		{"Reverse single", `$reverse([1])`, []interface{}{float64(1)}},
+		{"Sort numbers", `$sort(Numbers)`, []interface{}{1.0, 2.0, 3.0}},
+		{"Sort strings", `$sort(Words)`, []interface{}{"apple", "fig", "pear"}},
+		{"Sort leaves input", `($sort(Numbers); Numbers)`, []interface{}{3.0, 1.0, 2.0}},
+		{"Sort with function", `$sort(Numbers, function($a, $b) { $a < $b })`, []interface{}{3.0, 2.0, 1.0}},
+		{"Sort is stable", `$sort(Product, function($a, $b) { $a.Price > $b.Price }).Name`, []interface{}{"Cap", "Hat", "Fez"}},
+		{"Sort undefined", `$sort(Missing)`, nil},
+		{"Distinct", `$distinct([1, 2, 1, "a", "a"])`, []interface{}{float64(1), float64(2), "a"}},
+		{"Distinct objects", `$distinct([{"a": 1}, {"a": 1}])`, []interface{}{map[string]interface{}{"a": float64(1)}}},
+		{"Distinct path", `$distinct(Product.Price)`, []interface{}{12.5, 7.0}},
+		{"Shuffle keeps items", `$sort($shuffle(Numbers))`, []interface{}{1.0, 2.0, 3.0}},
+		{"Shuffle strings", `$join($sort($shuffle(Words)), ",")`, "apple,fig,pear"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, run(t, data, tt.expr))
+		})
+	}
+}
+
+func TestArrayFunctionErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"Sort mixed types", `$sort([1, "a"])`},
+		{"Sort objects without function", `$sort([{"a": 1}, {"a": 2}])`},
+		{"Sort with non function", `$sort([1, 2], 3)`},
+		{"Reverse too many arguments", `$reverse([1], [2])`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast, err := Parse(tt.expr)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.IsType(t, &lookup.Invalidor{}, Compile(ast).Run(lookup.NewScope(nil, lookup.Reflect(nil))))
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package jsonata
+
+import (
+	"github.com/arran4/lookup"
+)
+
+// Boolean functions are NativeFunctions as they treat an undefined argument differently from null.
+
+// booleanFunction implements $boolean(arg), casting the argument to a boolean. An undefined argument gives an
+// undefined result.
+func booleanFunction(_ *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+	if len(args) != 1 {
+		return invalid("T0410", "$boolean expects 1 argument")
+	}
+	if IsUndefined(args[0]) {
+		return undefined()
+	}
+	return lookup.Reflect(toBoolean(args[0].Raw()))
+}
+
+// notFunction implements $not(arg), the negation of the argument cast to a boolean. An undefined argument gives an
+// undefined result.
+func notFunction(_ *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+	if len(args) != 1 {
+		return invalid("T0410", "$not expects 1 argument")
+	}
+	if IsUndefined(args[0]) {
+		return undefined()
+	}
+	return lookup.Reflect(!toBoolean(args[0].Raw()))
+}
+
+// existsFunction implements $exists(arg), true unless the argument is undefined. null exists.
+func existsFunction(_ *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+	if len(args) != 1 {
+		return invalid("T0410", "$exists expects 1 argument")
+	}
+	return lookup.Reflect(!IsUndefined(args[0]))
+}
@@ -0,0 +1,46 @@
+package jsonata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBooleanFunctions(t *testing.T) {
+	data := map[string]interface{}{
+		"Empty": []interface{}{},
+		"Zeros": []interface{}{0.0, 0.0},
+		"Mixed": []interface{}{0.0, 1.0},
+		"Null":  nil,
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected interface{}
+	}{
+		{"Boolean of string", `$boolean("a")`, true},
+		{"Boolean of empty string", `$boolean("")`, false},
+		{"Boolean of zero", `$boolean(0)`, false},
+		{"Boolean of null", `$boolean(null)`, false},
+		{"Boolean of empty array", `$boolean(Empty)`, false},
+		{"Boolean of false array", `$boolean(Zeros)`, false},
+		{"Boolean of mixed array", `$boolean(Mixed)`, true},
+		{"Boolean of empty object", `$boolean({})`, false},
+		{"Boolean of function", `$boolean($sum)`, false},
+		{"Boolean of undefined", `$boolean(Missing)`, nil},
+		{"Not", `$not(false)`, true},
+		{"Not of string", `$not("a")`, false},
+		{"Not of undefined", `$not(Missing)`, nil},
+		{"Exists", `$exists(Mixed)`, true},
+		{"Exists null", `$exists(Null)`, true},
+		{"Exists false", `$exists(false)`, true},
+		{"Exists undefined", `$exists(Missing)`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, run(t, data, tt.expr))
+		})
+	}
+}
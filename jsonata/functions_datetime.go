@@ -0,0 +1,137 @@
+package jsonata
+
+import (
+	"math"
+	"time"
+
+	"github.com/arran4/lookup"
+)
+
+// Date and time functions work with timestamps as milliseconds since the Unix epoch or as ISO 8601 strings. The
+// current time comes from the Clock of the scope the expression is run with, read once at the start of the run so
+// every call to $now or $millis within an evaluation returns the same time.
+
+// evaluationTime returns the time of the evaluation the scope belongs to, or the scope's current time outside of one.
+func evaluationTime(scope *lookup.Scope) time.Time {
+	if e := evaluationOf(scope); e != nil {
+		return e.now
+	}
+	return scope.Now()
+}
+
+// clockFunction adapts a function of the evaluation time and raw arguments, as an evaluator.Function would receive
+// them, to a NativeFunction.
+func clockFunction(f func(now time.Time, args ...interface{}) (interface{}, error)) NativeFunction {
+	return func(scope *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+		raw := make([]interface{}, len(args))
+		for i, arg := range args {
+			if !IsUndefined(arg) {
+				raw[i] = arg.Raw()
+			}
+		}
+		res, err := f(evaluationTime(scope), raw...)
+		if err != nil {
+			return lookup.NewInvalidor("", err)
+		}
+		if res == nil {
+			return undefined()
+		}
+		return lookup.Reflect(res)
+	}
+}
+
+// nowFunction implements $now([picture [, timezone]]), the time of the evaluation as an ISO 8601 string or formatted
+// with the picture.
+func nowFunction(now time.Time, args ...interface{}) (interface{}, error) {
+	if len(args) > 2 {
+		return nil, errorf("T0410", "$now expects at most 2 arguments")
+	}
+	return formatMillis(now, args...)
+}
+
+// millisFunction implements $millis(), the time of the evaluation in milliseconds since the Unix epoch.
+func millisFunction(now time.Time, args ...interface{}) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, errorf("T0410", "$millis expects no arguments")
+	}
+	return float64(now.UnixMilli()), nil
+}
+
+// maxMillis is the furthest from the Unix epoch in milliseconds a date/time can be, the range of a JavaScript Date.
+const maxMillis = 8.64e15
+
+type fromMillisFunc struct{}
+
+// Call implements $fromMillis(number [, picture [, timezone]]), converting milliseconds since the Unix epoch to an
+// ISO 8601 string in UTC, or to a string formatted with the picture in the timezone, given as an offset like `-0500`.
+func (s *fromMillisFunc) Call(args ...interface{}) (interface{}, error) {
+	if len(args) < 1 || len(args) > 3 {
+		return nil, errorf("T0410", "$fromMillis expects 1 to 3 arguments")
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+	ms, ok := lookup.ToFloat(args[0])
+	if !ok {
+		return nil, errorf("T0410", "argument 1 of $fromMillis must be a number")
+	}
+	if math.IsNaN(ms) || math.Abs(ms) > maxMillis {
+		return nil, errorf("D1001", "number out of range: %v is outside the range of a date/time", ms)
+	}
+	return formatMillis(time.UnixMilli(int64(math.Floor(ms))), args[1:]...)
+}
+
+// formatMillis formats t with the optional picture and timezone arguments of $fromMillis and $now.
+func formatMillis(t time.Time, args ...interface{}) (interface{}, error) {
+	picture := iso8601Picture
+	if len(args) > 0 && args[0] != nil {
+		p, ok := args[0].(string)
+		if !ok {
+			return nil, errorf("T0410", "the picture of a date/time must be a string")
+		}
+		picture = p
+	}
+	location := time.UTC
+	if len(args) > 1 && args[1] != nil {
+		tz, ok := args[1].(string)
+		if !ok {
+			return nil, errorf("T0410", "the timezone of a date/time must be a string")
+		}
+		var err error
+		if location, err = parseTimezone(tz); err != nil {
+			return nil, err
+		}
+	}
+	return formatDateTime(t.In(location), picture)
+}
+
+// toMillisFunction implements $toMillis(timestamp [, picture]), converting an ISO 8601 timestamp, or one laid out as
+// described by the picture, to milliseconds since the Unix epoch. A timestamp not matching the picture is undefined.
+func toMillisFunction(now time.Time, args ...interface{}) (interface{}, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return nil, errorf("T0410", "$toMillis expects 1 to 2 arguments")
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+	timestamp, ok := args[0].(string)
+	if !ok {
+		return nil, errorf("T0410", "argument 1 of $toMillis must be a string")
+	}
+	if len(args) < 2 || args[1] == nil {
+		t, err := parseISO8601(timestamp)
+		if err != nil {
+			return nil, err
+		}
+		return float64(t.UnixMilli()), nil
+	}
+	picture, ok := args[1].(string)
+	if !ok {
+		return nil, errorf("T0410", "argument 2 of $toMillis must be a string")
+	}
+	t, ok, err := parseDateTime(timestamp, picture, now)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return float64(t.UnixMilli()), nil
+}
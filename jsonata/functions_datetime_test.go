@@ -0,0 +1,100 @@
+package jsonata
+
+import (
+	"testing"
+	"time"
+
+	"github.com/arran4/lookup"
+	"github.com/stretchr/testify/assert"
+)
+
+// runAt evaluates an expression with a clock fixed at now.
+func runAt(t *testing.T, now time.Time, expr string) lookup.Pathor {
+	ast, err := Parse(expr)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	scope := lookup.NewScope(nil, lookup.Reflect(nil))
+	scope.Clock = func() time.Time { return now }
+	return Compile(ast).Run(scope)
+}
+
+func TestDateTimeFunctions(t *testing.T) {
+	now := time.Date(2018, time.March, 23, 10, 33, 36, 617*int(time.Millisecond), time.UTC)
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected interface{}
+	}{
+		{"Now", `$now()`, "2018-03-23T10:33:36.617Z"},
+		{"Now with picture and timezone", `$now("[H01]:[m01] [Z]", "+0530")`, "16:03 +05:30"},
+		{"Millis", `$millis()`, float64(1521801216617)},
+		{"From millis", `$fromMillis(1521801216617)`, "2018-03-23T10:33:36.617Z"},
+		{"From millis with timezone", `$fromMillis(0, undefined, "-0500")`, "1969-12-31T19:00:00.000-05:00"},
+		{"Ordinal day and month name", `$fromMillis(1521801216617, "[D1o] [MNn] [Y]")`, "23rd March 2018"},
+		{"Abbreviated names", `$fromMillis(1521801216617, "[FNn,3-3] [D01] [MN,3-3]")`, "Fri 23 MAR"},
+		{"Twelve hour clock", `$fromMillis(1521801216617, "[h]:[m01][P]")`, "10:33am"},
+		{"Day of year", `$fromMillis(1521801216617, "[Y0001]-[d001]")`, "2018-082"},
+		{"ISO week", `$fromMillis(1521801216617, "[X0001]-W[W01]-[F1]")`, "2018-W12-5"},
+		{"Two digit year", `$fromMillis(1521801216617, "[D01]/[M01]/[Y01]")`, "23/03/18"},
+		{"Words", `$fromMillis(1521801216617, "[Dwo] [MNn] [Yw]")`, "twenty-third March two thousand and eighteen"},
+		{"Roman month", `$fromMillis(1521801216617, "[MI]")`, "III"},
+		{"Latest date", `$fromMillis(8.64e15, "[Y]")`, "275760"},
+		{"Escaped brackets", `$fromMillis(0, "[[[Y]]]")`, "[1970]"},
+		{"GMT offset", `$fromMillis(0, "[z]", "+0100")`, "GMT+01:00"},
+		{"To millis", `$toMillis("2018-03-23T10:33:36.617Z")`, float64(1521801216617)},
+		{"To millis date", `$toMillis("2018-03-23")`, float64(1521763200000)},
+		{"To millis offset", `$toMillis("2018-03-23T11:33:36.617+01:00")`, float64(1521801216617)},
+		{"To millis with picture", `$toMillis("23rd March 2018", "[D1o] [MNn] [Y]")`, float64(1521763200000)},
+		{"To millis with words", `$toMillis("twenty-third March two thousand and eighteen", "[Dwo] [MNn] [Yw]")`, float64(1521763200000)},
+		{"To millis twelve hour clock", `$toMillis("10:33pm 23/03/2018", "[h]:[m01][P] [D]/[M]/[Y]")`, float64(1521844380000)},
+		{"To millis time today", `$toMillis("10:33", "[H]:[m]")`, float64(1521801180000)},
+		{"To millis not matching picture", `$toMillis("March", "[Y]")`, nil},
+		{"Round trip", `$toMillis($fromMillis(1521801216617, "[Y][M01][D01][H01][m01][s01][f001]"), "[Y0001][M01][D01][H01][m01][s01][f001]")`, float64(1521801216617)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, runAt(t, now, tt.expr).Raw())
+		})
+	}
+}
+
+func TestNowIsStableWithinAnEvaluation(t *testing.T) {
+	ast, err := Parse(`[$millis(), $map([1, 2, 3], function($v){ $millis() }), $millis()]`)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	tick := time.Unix(0, 0)
+	scope := lookup.NewScope(nil, lookup.Reflect(nil))
+	scope.Clock = func() time.Time {
+		tick = tick.Add(time.Second)
+		return tick
+	}
+	query := Compile(ast)
+
+	first := query.Run(scope).Raw()
+	assert.Equal(t, []interface{}{float64(1000), float64(1000), float64(1000), float64(1000), float64(1000)}, first)
+	second := query.Run(scope).Raw()
+	assert.Equal(t, []interface{}{float64(2000), float64(2000), float64(2000), float64(2000), float64(2000)}, second)
+}
+
+func TestDateTimeFunctionErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"To millis invalid timestamp", `$toMillis("2018-03-23 10:33:36")`},
+		{"Unknown component", `$fromMillis(0, "[Q]")`},
+		{"Unclosed marker", `$fromMillis(0, "[Y")`},
+		{"Invalid timezone", `$fromMillis(0, "[Y]", "EST")`},
+		{"From millis of string", `$fromMillis("0")`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.IsType(t, &lookup.Invalidor{}, runAt(t, time.Now(), tt.expr))
+		})
+	}
+}
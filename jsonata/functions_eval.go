@@ -0,0 +1,65 @@
+package jsonata
+
+import "github.com/arran4/lookup"
+
+// $eval evaluates expressions built at runtime through Parse like any other expression, while $assert and $error
+// raise errors of their own. Their errors are Errors like those found evaluating, so errors.As finds them in the
+// lookup.Invalidor the evaluation results in.
+
+// evalFunction implements $eval(expr [, context]), parsing the expression and evaluating it against the context, or
+// the context item of the call when there's none. The expression sees the variables bound where $eval is called. An
+// expression which doesn't parse fails with D3120 and one whose evaluation fails with D3121, wrapping the Error.
+func evalFunction(scope *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+	if len(args) == 0 || IsUndefined(args[0]) {
+		return undefined()
+	}
+	expr, ok := args[0].Raw().(string)
+	if !ok {
+		return invalid("T0410", "argument 1 of $eval must be a string")
+	}
+	ast, err := Parse(expr)
+	if err != nil {
+		return invalid("D3120", "syntax error in expression passed to function eval: %w", err)
+	}
+	input := scope.Current
+	if len(args) > 1 && !IsUndefined(args[1]) {
+		input = args[1]
+	}
+	// The input is the root of the expression, `$$`, as it would be evaluating the expression on its own
+	root := &lookup.Scope{Current: input, Position: input, Context: scope.Context, Bindings: scope.Bindings, Clock: scope.Clock}
+	res := compileNode(ast.Node).Run(root.Enclose())
+	if isNilOrNilPointer(res) {
+		return undefined()
+	}
+	if inv, ok := res.(*lookup.Invalidor); ok && !IsUndefined(res) {
+		return invalid("D3121", "dynamic error evaluating the expression passed to function eval: %w", inv)
+	}
+	return unwrapSingleton(res)
+}
+
+// assertFunction implements $assert(condition [, message]), failing with D3141 and the message when the condition is
+// false. Otherwise the result is undefined.
+func assertFunction(_ *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+	if len(args) == 0 || IsUndefined(args[0]) {
+		return invalid("T0410", "argument 1 of $assert must be a boolean")
+	}
+	if condition, _ := args[0].Raw().(bool); condition {
+		return undefined()
+	}
+	return invalid("D3141", "%s", messageArg(args, 1, "$assert() statement failed"))
+}
+
+// errorFunction implements $error([message]), failing with D3137 and the message.
+func errorFunction(_ *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+	return invalid("D3137", "%s", messageArg(args, 0, "$error() function evaluated"))
+}
+
+// messageArg is the message given as argument i, or the default message when it's left out.
+func messageArg(args []lookup.Pathor, i int, otherwise string) string {
+	if i < len(args) && !IsUndefined(args[i]) {
+		if msg, ok := args[i].Raw().(string); ok {
+			return msg
+		}
+	}
+	return otherwise
+}
@@ -0,0 +1,90 @@
+package jsonata
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/arran4/lookup"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvalFunction(t *testing.T) {
+	data := map[string]interface{}{
+		"Rule":  "Price * Quantity",
+		"Price": 2.0,
+		"Items": []interface{}{
+			map[string]interface{}{"Price": 3.0, "Quantity": 2.0},
+			map[string]interface{}{"Price": 5.0, "Quantity": 1.0},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected interface{}
+	}{
+		{"Literal", `$eval("[1, 2, 3]")`, []interface{}{1.0, 2.0, 3.0}},
+		{"Against the context item", `Items.$eval($$.Rule)`, []interface{}{6.0, 5.0}},
+		{"Against the context given", `$eval("Price", Items[1])`, 5.0},
+		{"Input is the root", `$eval("$$.Quantity", Items[0])`, 2.0},
+		{"Variables in scope", `($rate := 10; $eval("Price * $rate"))`, 20.0},
+		{"Functions", `$eval("$uppercase('a')")`, "A"},
+		{"Undefined expression", `$eval(Missing)`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, run(t, data, tt.expr))
+		})
+	}
+}
+
+func TestAssertAndError(t *testing.T) {
+	data := map[string]interface{}{"Price": 40.0}
+
+	tests := []struct {
+		expr    string
+		code    string
+		message string
+	}{
+		{expr: `$assert(Price < 35, "Too expensive")`, code: "D3141", message: "Too expensive"},
+		{expr: `$assert(false)`, code: "D3141", message: "$assert() statement failed"},
+		{expr: `$assert("yes")`, code: "T0410"},
+		{expr: `Price > 35 ? $error("Too expensive") : Price`, code: "D3137", message: "Too expensive"},
+		{expr: `($error(); Price)`, code: "D3137", message: "$error() function evaluated"},
+		{expr: `[Price, $error("in an array")]`, code: "D3137", message: "in an array"},
+		{expr: `$error(5)`, code: "T0410"},
+		{expr: `$eval("Price +")`, code: "D3120"},
+		{expr: `$eval("$error('inner')")`, code: "D3121"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			ast, err := Parse(tt.expr)
+			if !assert.NoError(t, err) {
+				return
+			}
+			root := lookup.Reflect(data)
+			res := Compile(ast).Run(lookup.NewScope(root, root))
+			evalErr, _ := res.(error)
+			var jerr *Error
+			if !assert.True(t, errors.As(evalErr, &jerr), "expected a *jsonata.Error, got %v", res.Raw()) {
+				return
+			}
+			assert.Equal(t, tt.code, jerr.Code, jerr.Error())
+			if tt.message != "" {
+				assert.Equal(t, tt.message, jerr.Message)
+			}
+		})
+	}
+
+	assert.Equal(t, 40.0, run(t, data, `($assert(Price > 35); Price)`))
+
+	// The error $eval fails with wraps the one evaluating the expression did
+	ast, _ := Parse(`$eval("$error('inner')")`)
+	res := Compile(ast).Run(lookup.NewScope(nil, lookup.Reflect(data)))
+	var jerr *Error
+	if assert.True(t, errors.As(errors.Unwrap(errorOf(res)), &jerr)) {
+		assert.Equal(t, "D3137", jerr.Code)
+	}
+}
@@ -0,0 +1,497 @@
+package jsonata
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/arran4/lookup"
+)
+
+// decimalFormat holds the properties of an XPath decimal format, which the options of $formatNumber override.
+type decimalFormat struct {
+	decimalSeparator  rune
+	groupingSeparator rune
+	exponentSeparator rune
+	minusSign         string
+	percent           string
+	perMille          string
+	zeroDigit         rune
+	digit             rune
+	patternSeparator  rune
+}
+
+func defaultDecimalFormat() decimalFormat {
+	return decimalFormat{
+		decimalSeparator:  '.',
+		groupingSeparator: ',',
+		exponentSeparator: 'e',
+		minusSign:         "-",
+		percent:           "%",
+		perMille:          "‰",
+		zeroDigit:         '0',
+		digit:             '#',
+		patternSeparator:  ';',
+	}
+}
+
+// set overrides a property by its XPath name.
+func (d *decimalFormat) set(name string, value string) error {
+	switch name {
+	case "minus-sign":
+		d.minusSign = value
+		return nil
+	case "percent":
+		d.percent = value
+		return nil
+	case "per-mille":
+		d.perMille = value
+		return nil
+	}
+	properties := map[string]*rune{
+		"decimal-separator":  &d.decimalSeparator,
+		"grouping-separator": &d.groupingSeparator,
+		"exponent-separator": &d.exponentSeparator,
+		"zero-digit":         &d.zeroDigit,
+		"digit":              &d.digit,
+		"pattern-separator":  &d.patternSeparator,
+	}
+	p, ok := properties[name]
+	if !ok {
+		// Unsupported properties, such as infinity and NaN, have no effect on JSON numbers
+		return nil
+	}
+	r := []rune(value)
+	if len(r) == 0 {
+		return errorf("T0410", "the %s property of $formatNumber must not be empty", name)
+	}
+	*p = r[0]
+	return nil
+}
+
+func (d *decimalFormat) isDecimalDigit(r rune) bool {
+	return r >= d.zeroDigit && r < d.zeroDigit+10
+}
+
+// isActive reports whether r is one of the characters which make up the number within a picture, as opposed to a
+// passive character of its prefix or suffix.
+func (d *decimalFormat) isActive(r rune) bool {
+	return d.isDecimalDigit(r) || r == d.decimalSeparator || r == d.exponentSeparator || r == d.groupingSeparator ||
+		r == d.digit || r == d.patternSeparator
+}
+
+// subPicture is one half of a $formatNumber picture, split into its parts as described by XPath F&O 4.7.3.
+type subPicture struct {
+	picture        []rune
+	prefix         []rune
+	suffix         []rune
+	active         []rune
+	mantissa       []rune
+	exponent       []rune
+	hasExponent    bool
+	integerPart    []rune
+	fractionalPart []rune
+}
+
+// numberPicture is the analysis of a sub-picture, XPath F&O 4.7.4.
+type numberPicture struct {
+	picture                    string
+	prefix                     string
+	suffix                     string
+	integerGroupingPositions   []int
+	regularGrouping            int
+	minimumIntegerPartSize     int
+	scalingFactor              int
+	fractionalGroupingPosition []int
+	minimumFractionalPartSize  int
+	maximumFractionalPartSize  int
+	minimumExponentSize        int
+}
+
+type formatNumberFunc struct{}
+
+// Call implements $formatNumber(number, picture [, options]) formatting the number with an XPath decimal format
+// picture string, such as `#,##0.00`. Options override the properties of the decimal format by name, such as
+// `{"decimal-separator": ","}`.
+func (s *formatNumberFunc) Call(args ...interface{}) (interface{}, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return nil, errorf("T0410", "$formatNumber expects 2 to 3 arguments")
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+	value, ok := lookup.ToFloat(args[0])
+	if !ok {
+		return nil, errorf("T0410", "argument 1 of $formatNumber must be a number")
+	}
+	picture, ok := args[1].(string)
+	if !ok {
+		return nil, errorf("T0410", "argument 2 of $formatNumber must be a string")
+	}
+	format := defaultDecimalFormat()
+	if len(args) > 2 && args[2] != nil {
+		options, ok := args[2].(map[string]interface{})
+		if !ok {
+			return nil, errorf("T0410", "argument 3 of $formatNumber must be an object")
+		}
+		for name, v := range options {
+			str, ok := v.(string)
+			if !ok {
+				return nil, errorf("T0410", "the %s property of $formatNumber must be a string", name)
+			}
+			if err := format.set(name, str); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return formatDecimal(value, picture, &format)
+}
+
+// formatDecimal formats value with a picture following the rules of the XPath format-number function.
+func formatDecimal(value float64, picture string, d *decimalFormat) (string, error) {
+	halves := strings.Split(picture, string(d.patternSeparator))
+	if len(halves) > 2 {
+		return "", errorf("D3080", "the picture string must not contain more than one instance of the pattern separator")
+	}
+	pictures := make([]*numberPicture, len(halves))
+	for i, half := range halves {
+		parts := d.split([]rune(half))
+		if err := d.validate(parts); err != nil {
+			return "", err
+		}
+		pictures[i] = d.analyse(parts)
+	}
+	if len(pictures) == 1 {
+		negative := *pictures[0]
+		negative.prefix = d.minusSign + negative.prefix
+		pictures = append(pictures, &negative)
+	}
+
+	pic := pictures[0]
+	if value < 0 {
+		pic = pictures[1]
+	}
+	adjusted := math.Abs(value)
+	if strings.Contains(pic.picture, d.percent) {
+		adjusted *= 100
+	} else if strings.Contains(pic.picture, d.perMille) {
+		adjusted *= 1000
+	}
+
+	mantissa, exponent, hasExponent := adjusted, 0, pic.minimumExponentSize > 0
+	if hasExponent && mantissa != 0 {
+		maxMantissa := math.Pow10(pic.scalingFactor)
+		minMantissa := math.Pow10(pic.scalingFactor - 1)
+		for mantissa < minMantissa {
+			mantissa *= 10
+			exponent--
+		}
+		for mantissa > maxMantissa {
+			mantissa /= 10
+			exponent++
+		}
+	}
+
+	str := d.digits(strconv.FormatFloat(roundHalfEven(mantissa, pic.maximumFractionalPartSize), 'f', pic.maximumFractionalPartSize, 64))
+	if i := indexRune(str, '.'); i >= 0 {
+		str[i] = d.decimalSeparator
+	} else {
+		str = append(str, d.decimalSeparator)
+	}
+	for len(str) > 0 && str[0] == d.zeroDigit {
+		str = str[1:]
+	}
+	for len(str) > 0 && str[len(str)-1] == d.zeroDigit {
+		str = str[:len(str)-1]
+	}
+
+	decimalPos := indexRune(str, d.decimalSeparator)
+	padLeft := pic.minimumIntegerPartSize - decimalPos
+	padRight := pic.minimumFractionalPartSize - (len(str) - decimalPos - 1)
+	if padLeft > 0 {
+		str = append(repeatRune(d.zeroDigit, padLeft), str...)
+	}
+	if padRight > 0 {
+		str = append(str, repeatRune(d.zeroDigit, padRight)...)
+	}
+
+	decimalPos = indexRune(str, d.decimalSeparator)
+	if pic.regularGrouping > 0 {
+		for group := 1; group <= (decimalPos-1)/pic.regularGrouping; group++ {
+			str = insertRune(str, decimalPos-group*pic.regularGrouping, d.groupingSeparator)
+		}
+	} else {
+		for _, pos := range pic.integerGroupingPositions {
+			str = insertRune(str, decimalPos-pos, d.groupingSeparator)
+			decimalPos++
+		}
+	}
+	decimalPos = indexRune(str, d.decimalSeparator)
+	for _, pos := range pic.fractionalGroupingPosition {
+		if at := pos + decimalPos + 1; at <= len(str) {
+			str = insertRune(str, at, d.groupingSeparator)
+		}
+	}
+	if decimalPos = indexRune(str, d.decimalSeparator); decimalPos == len(str)-1 {
+		str = str[:len(str)-1]
+	}
+
+	result := string(str)
+	if hasExponent {
+		exp := d.digits(strconv.Itoa(absInt(exponent)))
+		if padLeft := pic.minimumExponentSize - len(exp); padLeft > 0 {
+			exp = append(repeatRune(d.zeroDigit, padLeft), exp...)
+		}
+		sign := ""
+		if exponent < 0 {
+			sign = d.minusSign
+		}
+		result += string(d.exponentSeparator) + sign + string(exp)
+	}
+	return pic.prefix + result + pic.suffix, nil
+}
+
+// digits converts the ASCII digits of s into the format's digit family.
+func (d *decimalFormat) digits(s string) []rune {
+	r := []rune(s)
+	for i, c := range r {
+		if c >= '0' && c <= '9' {
+			r[i] = d.zeroDigit + (c - '0')
+		}
+	}
+	return r
+}
+
+// split divides a sub-picture into its prefix, active part and suffix, and the active part into its integer,
+// fractional and exponent parts.
+func (d *decimalFormat) split(picture []rune) *subPicture {
+	parts := &subPicture{picture: picture}
+	start, end := len(picture), len(picture)
+	for i, r := range picture {
+		if d.isActive(r) && r != d.exponentSeparator {
+			start = i
+			break
+		}
+	}
+	for i := len(picture) - 1; i >= start; i-- {
+		if d.isActive(picture[i]) && picture[i] != d.exponentSeparator {
+			end = i + 1
+			break
+		}
+	}
+	if end < start {
+		end = start
+	}
+	parts.prefix, parts.active, parts.suffix = picture[:start], picture[start:end], picture[end:]
+	parts.mantissa = parts.active
+	if i := indexRune(parts.active, d.exponentSeparator); i >= 0 {
+		parts.mantissa, parts.exponent, parts.hasExponent = parts.active[:i], parts.active[i+1:], true
+	}
+	if i := indexRune(parts.mantissa, d.decimalSeparator); i >= 0 {
+		parts.integerPart, parts.fractionalPart = parts.mantissa[:i], parts.mantissa[i+1:]
+	} else {
+		parts.integerPart, parts.fractionalPart = parts.mantissa, parts.suffix
+	}
+	return parts
+}
+
+// validate checks the rules of XPath F&O 4.7.3 for a sub-picture.
+func (d *decimalFormat) validate(parts *subPicture) error {
+	picture := parts.picture
+	if countRune(picture, d.decimalSeparator) > 1 {
+		return errorf("D3081", "a sub-picture must not contain more than one instance of the decimal separator")
+	}
+	str := string(picture)
+	if strings.Count(str, d.percent) > 1 {
+		return errorf("D3082", "a sub-picture must not contain more than one instance of the percent character")
+	}
+	if strings.Count(str, d.perMille) > 1 {
+		return errorf("D3083", "a sub-picture must not contain more than one instance of the per-mille character")
+	}
+	if strings.Contains(str, d.percent) && strings.Contains(str, d.perMille) {
+		return errorf("D3084", "a sub-picture must not contain both a percent and a per-mille character")
+	}
+	digits := false
+	for _, r := range parts.mantissa {
+		if d.isDecimalDigit(r) || r == d.digit {
+			digits = true
+			break
+		}
+	}
+	if !digits {
+		return errorf("D3085", "the mantissa part of a sub-picture must contain at least one character that is either an optional digit character or a member of the decimal digit family")
+	}
+	for _, r := range parts.active {
+		if !d.isActive(r) {
+			return errorf("D3086", "a sub-picture must not contain a passive character that is preceded by an active character and that is followed by another active character")
+		}
+	}
+	if i := indexRune(picture, d.decimalSeparator); i >= 0 {
+		if (i > 0 && picture[i-1] == d.groupingSeparator) || (i+1 < len(picture) && picture[i+1] == d.groupingSeparator) {
+			return errorf("D3087", "a group separator cannot be adjacent to a decimal separator")
+		}
+	} else if n := len(parts.integerPart); n > 0 && parts.integerPart[n-1] == d.groupingSeparator {
+		return errorf("D3088", "the integer part of a sub-picture must not end with a grouping separator")
+	}
+	for i := 1; i < len(picture); i++ {
+		if picture[i] == d.groupingSeparator && picture[i-1] == d.groupingSeparator {
+			return errorf("D3089", "a sub-picture must not contain two adjacent grouping separators")
+		}
+	}
+	if i := indexRune(parts.integerPart, d.digit); i >= 0 {
+		for _, r := range parts.integerPart[:i] {
+			if d.isDecimalDigit(r) {
+				return errorf("D3090", "the integer part of a sub-picture cannot contain a member of the decimal digit family that is followed by an optional digit")
+			}
+		}
+	}
+	if i := lastIndexRune(parts.fractionalPart, d.digit); i >= 0 {
+		for _, r := range parts.fractionalPart[i:] {
+			if d.isDecimalDigit(r) {
+				return errorf("D3091", "the fractional part of a sub-picture cannot contain an optional digit that is followed by a member of the decimal digit family")
+			}
+		}
+	}
+	if parts.hasExponent {
+		if len(parts.exponent) > 0 && (strings.Contains(str, d.percent) || strings.Contains(str, d.perMille)) {
+			return errorf("D3092", "a sub-picture cannot contain a percent or per-mille character and an exponent separator")
+		}
+		if len(parts.exponent) == 0 {
+			return errorf("D3093", "the exponent part of a sub-picture must contain digits")
+		}
+		for _, r := range parts.exponent {
+			if !d.isDecimalDigit(r) {
+				return errorf("D3093", "the exponent part of a sub-picture must only contain members of the decimal digit family")
+			}
+		}
+	}
+	return nil
+}
+
+// analyse works out how a valid sub-picture formats numbers, XPath F&O 4.7.4.
+func (d *decimalFormat) analyse(parts *subPicture) *numberPicture {
+	pic := &numberPicture{
+		picture: string(parts.picture),
+		prefix:  string(parts.prefix),
+		suffix:  string(parts.suffix),
+	}
+	countDigits := func(part []rune, optional bool) int {
+		n := 0
+		for _, r := range part {
+			if d.isDecimalDigit(r) || (optional && r == d.digit) {
+				n++
+			}
+		}
+		return n
+	}
+	for i, r := range parts.integerPart {
+		if r == d.groupingSeparator {
+			pic.integerGroupingPositions = append(pic.integerGroupingPositions, countDigits(parts.integerPart[i:], true))
+		}
+	}
+	pic.regularGrouping = regularGrouping(pic.integerGroupingPositions)
+	for i, r := range parts.fractionalPart {
+		if r == d.groupingSeparator {
+			pic.fractionalGroupingPosition = append(pic.fractionalGroupingPosition, countDigits(parts.fractionalPart[:i], true))
+		}
+	}
+	pic.minimumIntegerPartSize = countDigits(parts.integerPart, false)
+	pic.scalingFactor = pic.minimumIntegerPartSize
+	pic.minimumFractionalPartSize = countDigits(parts.fractionalPart, false)
+	pic.maximumFractionalPartSize = countDigits(parts.fractionalPart, true)
+	if pic.minimumIntegerPartSize == 0 && pic.maximumFractionalPartSize == 0 {
+		if parts.hasExponent {
+			pic.minimumFractionalPartSize = 1
+			pic.maximumFractionalPartSize = 1
+		} else {
+			pic.minimumIntegerPartSize = 1
+		}
+	}
+	if parts.hasExponent && pic.minimumIntegerPartSize == 0 && containsRune(parts.integerPart, d.digit) {
+		pic.minimumIntegerPartSize = 1
+	}
+	if pic.minimumIntegerPartSize == 0 && pic.minimumFractionalPartSize == 0 {
+		pic.minimumFractionalPartSize = 1
+	}
+	if parts.hasExponent {
+		pic.minimumExponentSize = countDigits(parts.exponent, false)
+	}
+	return pic
+}
+
+// regularGrouping returns the interval between grouping separators when they're evenly spaced, otherwise 0.
+func regularGrouping(positions []int) int {
+	if len(positions) == 0 {
+		return 0
+	}
+	gcd := func(a, b int) int {
+		for b != 0 {
+			a, b = b, a%b
+		}
+		return a
+	}
+	factor := positions[0]
+	for _, p := range positions[1:] {
+		factor = gcd(factor, p)
+	}
+	for i := 1; i <= len(positions); i++ {
+		found := false
+		for _, p := range positions {
+			if p == i*factor {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0
+		}
+	}
+	return factor
+}
+
+func indexRune(s []rune, r rune) int {
+	for i, c := range s {
+		if c == r {
+			return i
+		}
+	}
+	return -1
+}
+
+func lastIndexRune(s []rune, r rune) int {
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+func containsRune(s []rune, r rune) bool {
+	return indexRune(s, r) >= 0
+}
+
+func countRune(s []rune, r rune) int {
+	n := 0
+	for _, c := range s {
+		if c == r {
+			n++
+		}
+	}
+	return n
+}
+
+func repeatRune(r rune, n int) []rune {
+	s := make([]rune, n)
+	for i := range s {
+		s[i] = r
+	}
+	return s
+}
+
+func insertRune(s []rune, at int, r rune) []rune {
+	result := make([]rune, 0, len(s)+1)
+	result = append(result, s[:at]...)
+	result = append(result, r)
+	return append(result, s[at:]...)
+}
@@ -0,0 +1,487 @@
+package jsonata
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/arran4/lookup"
+)
+
+// integerPrimary is the kind of primary format token in an XPath integer picture.
+type integerPrimary int
+
+const (
+	decimalPrimary integerPrimary = iota
+	lettersPrimary
+	romanPrimary
+	wordsPrimary
+)
+
+// integerPicture is the analysis of a picture used by $formatInteger and $parseInteger, following the XPath
+// format-integer function. The primary token is a decimal digit pattern such as `#,##0`, `a` or `A` for letters,
+// `i` or `I` for roman numerals or `w`, `W` or `Ww` for words. A modifier of `;o` asks for ordinal numbers.
+type integerPicture struct {
+	primary   integerPrimary
+	upper     bool // letters, roman numerals and words in upper case
+	title     bool // words in title case
+	ordinal   bool
+	zero      rune // zero of the decimal digit family
+	mandatory int  // minimum number of digits
+	grouping  []integerGrouping
+	regular   int // interval of evenly spaced grouping separators, 0 when they're not
+}
+
+type integerGrouping struct {
+	position  int // number of digits to the right of the separator
+	separator rune
+}
+
+// decimalDigitZeros are the zero digits of the Unicode decimal digit families.
+var decimalDigitZeros = []rune{
+	0x30, 0x0660, 0x06F0, 0x07C0, 0x0966, 0x09E6, 0x0A66, 0x0AE6, 0x0B66, 0x0BE6, 0x0C66, 0x0CE6, 0x0D66, 0x0DE6,
+	0x0E50, 0x0ED0, 0x0F20, 0x1040, 0x1090, 0x17E0, 0x1810, 0x1946, 0x19D0, 0x1A80, 0x1A90, 0x1B50, 0x1BB0, 0x1C40,
+	0x1C50, 0xA620, 0xA8D0, 0xA900, 0xA9D0, 0xA9F0, 0xAA50, 0xABF0, 0xFF10,
+}
+
+// digitZero returns the zero of the decimal digit family r belongs to.
+func digitZero(r rune) (rune, bool) {
+	for _, zero := range decimalDigitZeros {
+		if r >= zero && r < zero+10 {
+			return zero, true
+		}
+	}
+	return 0, false
+}
+
+func parseIntegerPicture(picture string) (*integerPicture, error) {
+	pic := &integerPicture{zero: '0'}
+	primary := picture
+	if i := strings.LastIndex(picture, ";"); i >= 0 {
+		primary = picture[:i]
+		modifier := picture[i+1:]
+		pic.ordinal = strings.HasPrefix(modifier, "o")
+	}
+	switch primary {
+	case "a", "A":
+		pic.primary, pic.upper = lettersPrimary, primary == "A"
+		return pic, nil
+	case "i", "I":
+		pic.primary, pic.upper = romanPrimary, primary == "I"
+		return pic, nil
+	case "w", "W", "Ww":
+		pic.primary, pic.upper, pic.title = wordsPrimary, primary == "W", primary == "Ww"
+		return pic, nil
+	case "":
+		return nil, errorf("D3130", "the picture string must not be empty")
+	}
+
+	runes := []rune(primary)
+	hasDigit, optional := false, false
+	digits := 0
+	for i := len(runes) - 1; i >= 0; i-- {
+		r := runes[i]
+		if zero, ok := digitZero(r); ok {
+			if hasDigit && zero != pic.zero {
+				return nil, errorf("D3131", "the decimal digits of a picture must be from the same digit family")
+			}
+			if optional {
+				return nil, errorf("D3130", "a mandatory digit cannot precede an optional digit")
+			}
+			pic.zero, hasDigit = zero, true
+			pic.mandatory++
+			digits++
+			continue
+		}
+		if r == '#' {
+			optional = true
+			digits++
+			continue
+		}
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			return nil, errorf("D3130", "the picture string %q is not supported", picture)
+		}
+		if i == len(runes)-1 || i == 0 || len(pic.grouping) > 0 && pic.grouping[len(pic.grouping)-1].position == digits {
+			return nil, errorf("D3130", "a grouping separator must be between two digits")
+		}
+		pic.grouping = append(pic.grouping, integerGrouping{position: digits, separator: r})
+	}
+	if !hasDigit {
+		return nil, errorf("D3130", "the picture string must contain at least one decimal digit")
+	}
+	positions := make([]int, len(pic.grouping))
+	for i, g := range pic.grouping {
+		positions[i] = g.position
+		if g.separator != pic.grouping[0].separator {
+			positions = nil
+			break
+		}
+	}
+	if positions != nil {
+		pic.regular = regularGrouping(positions)
+	}
+	return pic, nil
+}
+
+type formatIntegerFunc struct{}
+
+// Call implements $formatInteger(number, picture), writing the integer as digits, letters, roman numerals or words.
+func (s *formatIntegerFunc) Call(args ...interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, errorf("T0410", "$formatInteger expects 2 arguments")
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+	f, ok := lookup.ToFloat(args[0])
+	if !ok {
+		return nil, errorf("T0410", "argument 1 of $formatInteger must be a number")
+	}
+	picture, ok := args[1].(string)
+	if !ok {
+		return nil, errorf("T0410", "argument 2 of $formatInteger must be a string")
+	}
+	pic, err := parseIntegerPicture(picture)
+	if err != nil {
+		return nil, err
+	}
+	// Negating -2^63 overflows so it's out of range too
+	f = math.Floor(f)
+	if !(f > math.MinInt64 && f < math.MaxInt64) {
+		return nil, errorf("D1001", "number out of range: %v", f)
+	}
+	if pic.primary == romanPrimary && math.Abs(f) > maxRomanNumeral {
+		return nil, errorf("D3130", "formatting %v as roman numerals is not supported", f)
+	}
+	return pic.format(int64(f)), nil
+}
+
+// format writes value as described by the picture.
+func (p *integerPicture) format(value int64) string {
+	sign := ""
+	if value < 0 {
+		sign, value = "-", -value
+	}
+	var s string
+	switch p.primary {
+	case lettersPrimary:
+		s = integerToLetters(value, p.upper)
+	case romanPrimary:
+		s = integerToRoman(value)
+		if !p.upper {
+			s = strings.ToLower(s)
+		}
+	case wordsPrimary:
+		s = integerToWords(value, p.ordinal)
+		switch {
+		case p.upper:
+			s = strings.ToUpper(s)
+		case !p.title:
+			s = strings.ToLower(s)
+		}
+	default:
+		s = p.formatDecimal(value)
+	}
+	return sign + s
+}
+
+func (p *integerPicture) formatDecimal(value int64) string {
+	digits := []rune(strconv.FormatInt(value, 10))
+	if pad := p.mandatory - len(digits); pad > 0 {
+		digits = append([]rune(strings.Repeat("0", pad)), digits...)
+	}
+	for i, r := range digits {
+		digits[i] = p.zero + (r - '0')
+	}
+	if p.regular > 0 {
+		separator := p.grouping[0].separator
+		for pos := len(digits) - p.regular; pos > 0; pos -= p.regular {
+			digits = insertRune(digits, pos, separator)
+		}
+	} else {
+		// positions are counted from the right so inserting from the right keeps them valid
+		for _, g := range p.grouping {
+			if pos := len(digits) - g.position - countSeparators(digits, p.zero); pos > 0 {
+				digits = insertRune(digits, pos, g.separator)
+			}
+		}
+	}
+	s := string(digits)
+	if p.ordinal {
+		s += ordinalSuffix(value)
+	}
+	return s
+}
+
+// countSeparators counts the runes of s which aren't digits of the family with the given zero.
+func countSeparators(s []rune, zero rune) int {
+	n := 0
+	for _, r := range s {
+		if r < zero || r >= zero+10 {
+			n++
+		}
+	}
+	return n
+}
+
+func ordinalSuffix(value int64) string {
+	if tens := value % 100; tens >= 11 && tens <= 13 {
+		return "th"
+	}
+	switch value % 10 {
+	case 1:
+		return "st"
+	case 2:
+		return "nd"
+	case 3:
+		return "rd"
+	}
+	return "th"
+}
+
+func integerToLetters(value int64, upper bool) string {
+	a := 'a'
+	if upper {
+		a = 'A'
+	}
+	var letters []rune
+	for value > 0 {
+		letters = append([]rune{a + rune((value-1)%26)}, letters...)
+		value = (value - 1) / 26
+	}
+	return string(letters)
+}
+
+var romanNumerals = []struct {
+	value   int64
+	numeral string
+}{
+	{1000, "M"}, {900, "CM"}, {500, "D"}, {400, "CD"}, {100, "C"}, {90, "XC"}, {50, "L"}, {40, "XL"}, {10, "X"},
+	{9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"},
+}
+
+// maxRomanNumeral bounds the numbers written in roman numerals, which have a letter M for every thousand.
+const maxRomanNumeral = 1000000
+
+func integerToRoman(value int64) string {
+	var b strings.Builder
+	for _, n := range romanNumerals {
+		for value >= n.value {
+			b.WriteString(n.numeral)
+			value -= n.value
+		}
+	}
+	return b.String()
+}
+
+var (
+	fewWords = []string{
+		"Zero", "One", "Two", "Three", "Four", "Five", "Six", "Seven", "Eight", "Nine", "Ten", "Eleven", "Twelve",
+		"Thirteen", "Fourteen", "Fifteen", "Sixteen", "Seventeen", "Eighteen", "Nineteen",
+	}
+	ordinalWords = []string{
+		"Zeroth", "First", "Second", "Third", "Fourth", "Fifth", "Sixth", "Seventh", "Eighth", "Ninth", "Tenth",
+		"Eleventh", "Twelfth", "Thirteenth", "Fourteenth", "Fifteenth", "Sixteenth", "Seventeenth", "Eighteenth",
+		"Nineteenth",
+	}
+	decadeWords    = []string{"Twenty", "Thirty", "Forty", "Fifty", "Sixty", "Seventy", "Eighty", "Ninety"}
+	magnitudeWords = []string{"Thousand", "Million", "Billion", "Trillion"}
+)
+
+// integerToWords writes a number in English words in title case, such as "One Hundred and Twenty-Three".
+func integerToWords(value int64, ordinal bool) string {
+	var words func(n int64, prev, ordinal bool) string
+	words = func(n int64, prev, ordinal bool) string {
+		var s string
+		if prev {
+			if n < 100 {
+				s = " and "
+			} else {
+				s = ", "
+			}
+		}
+		switch {
+		case n <= 19:
+			if ordinal {
+				return s + ordinalWords[n]
+			}
+			return s + fewWords[n]
+		case n < 100:
+			s += decadeWords[n/10-2]
+			if n%10 > 0 {
+				s += "-" + words(n%10, false, ordinal)
+			} else if ordinal {
+				s = s[:len(s)-1] + "ieth"
+			}
+		case n < 1000:
+			s += fewWords[n/100] + " Hundred"
+			if n%100 > 0 {
+				s += words(n%100, true, ordinal)
+			} else if ordinal {
+				s += "th"
+			}
+		default:
+			magnitude := int(math.Floor(math.Log10(float64(n)) / 3))
+			if magnitude > len(magnitudeWords) {
+				magnitude = len(magnitudeWords)
+			}
+			factor := int64(math.Pow(10, float64(magnitude*3)))
+			s += words(n/factor, false, false) + " " + magnitudeWords[magnitude-1]
+			if n%factor > 0 {
+				s += words(n%factor, true, ordinal)
+			} else if ordinal {
+				s += "th"
+			}
+		}
+		return s
+	}
+	return words(value, false, ordinal)
+}
+
+// wordValues maps lower case number words, cardinal and ordinal, to their values.
+var wordValues = func() map[string]int64 {
+	values := map[string]int64{"hundred": 100, "hundredth": 100}
+	for i, w := range fewWords {
+		values[strings.ToLower(w)] = int64(i)
+	}
+	for i, w := range ordinalWords {
+		values[strings.ToLower(w)] = int64(i)
+	}
+	for i, w := range decadeWords {
+		w = strings.ToLower(w)
+		values[w] = int64(i+2) * 10
+		values[w[:len(w)-1]+"ieth"] = int64(i+2) * 10
+	}
+	for i, w := range magnitudeWords {
+		w = strings.ToLower(w)
+		values[w] = int64(math.Pow10((i + 1) * 3))
+		values[w+"th"] = int64(math.Pow10((i + 1) * 3))
+	}
+	return values
+}()
+
+var wordSeparatorRegex = regexp.MustCompile(`,\s|\sand\s|[\s\-]`)
+
+func wordsToInteger(text string) (int64, error) {
+	segments := []int64{0}
+	for _, word := range wordSeparatorRegex.Split(strings.ToLower(text), -1) {
+		value, ok := wordValues[word]
+		if !ok {
+			return 0, errorf("D3130", "unable to parse %q as a number in words", text)
+		}
+		top := segments[len(segments)-1]
+		segments = segments[:len(segments)-1]
+		if value < 100 {
+			if top >= 1000 {
+				segments = append(segments, top)
+				top = 0
+			}
+			segments = append(segments, top+value)
+		} else {
+			segments = append(segments, top*value)
+		}
+	}
+	var result int64
+	for _, s := range segments {
+		result += s
+	}
+	return result, nil
+}
+
+func romanToInteger(text string) (int64, error) {
+	var result int64
+	rest := strings.ToUpper(text)
+	for _, n := range romanNumerals {
+		for strings.HasPrefix(rest, n.numeral) {
+			result += n.value
+			rest = rest[len(n.numeral):]
+		}
+	}
+	if rest != "" || text == "" {
+		return 0, errorf("D3130", "unable to parse %q as a roman numeral", text)
+	}
+	return result, nil
+}
+
+func lettersToInteger(text string, upper bool) (int64, error) {
+	a := 'a'
+	if upper {
+		a = 'A'
+	}
+	var result int64
+	for _, r := range text {
+		if r < a || r > a+25 {
+			return 0, errorf("D3130", "unable to parse %q as letters", text)
+		}
+		result = result*26 + int64(r-a+1)
+	}
+	return result, nil
+}
+
+type parseIntegerFunc struct{}
+
+// Call implements $parseInteger(string, picture), the reverse of $formatInteger.
+func (s *parseIntegerFunc) Call(args ...interface{}) (interface{}, error) {
+	str, ok, err := stringArgs("$parseInteger", args, 2, 2)
+	if !ok {
+		return nil, err
+	}
+	picture, ok := args[1].(string)
+	if !ok {
+		return nil, errorf("T0410", "argument 2 of $parseInteger must be a string")
+	}
+	pic, err := parseIntegerPicture(picture)
+	if err != nil {
+		return nil, err
+	}
+	negative := strings.HasPrefix(str, "-")
+	str = strings.TrimPrefix(str, "-")
+	var value int64
+	switch pic.primary {
+	case lettersPrimary:
+		value, err = lettersToInteger(str, pic.upper)
+	case romanPrimary:
+		value, err = romanToInteger(str)
+	case wordsPrimary:
+		value, err = wordsToInteger(str)
+	default:
+		value, err = pic.parseDecimal(str)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if negative {
+		value = -value
+	}
+	return float64(value), nil
+}
+
+func (p *integerPicture) parseDecimal(text string) (int64, error) {
+	if p.ordinal {
+		for _, suffix := range []string{"st", "nd", "rd", "th"} {
+			text = strings.TrimSuffix(text, suffix)
+		}
+	}
+	var value int64
+	digits := 0
+	for _, r := range text {
+		if r >= p.zero && r < p.zero+10 {
+			value = value*10 + int64(r-p.zero)
+			digits++
+			continue
+		}
+		separator := false
+		for _, g := range p.grouping {
+			separator = separator || g.separator == r
+		}
+		if !separator {
+			return 0, errorf("D3130", "unable to parse %q with the picture", text)
+		}
+	}
+	if digits == 0 {
+		return 0, errorf("D3130", "unable to parse %q with the picture", text)
+	}
+	return value, nil
+}
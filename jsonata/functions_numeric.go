@@ -0,0 +1,240 @@
+package jsonata
+
+import (
+	"math"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/arran4/lookup"
+)
+
+// Numeric functions give an undefined (nil) result for an undefined argument and an error for one which isn't a
+// number. Their results are float64 as all JSONata numbers are.
+
+var (
+	decimalNumberRegex  = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?([Ee][-+]?[0-9]+)?$`)
+	prefixedNumberRegex = regexp.MustCompile(`^0([xXoObB])([0-9A-Fa-f]+)$`)
+)
+
+type numberFunc struct{}
+
+// Call implements $number(arg). Numbers are returned as they are, booleans become 1 or 0, and strings holding a JSON
+// number or a hexadecimal (0x), octal (0o) or binary (0b) integer are parsed.
+func (s *numberFunc) Call(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, errorf("T0410", "$number expects 1 argument")
+	}
+	switch v := args[0].(type) {
+	case nil:
+		return nil, nil
+	case bool:
+		if v {
+			return float64(1), nil
+		}
+		return float64(0), nil
+	case string:
+		return parseNumber(v)
+	}
+	if f, ok := lookup.ToFloat(args[0]); ok {
+		return f, nil
+	}
+	return nil, errorf("D3030", "unable to cast value to a number: %v", args[0])
+}
+
+func parseNumber(s string) (interface{}, error) {
+	if decimalNumberRegex.MatchString(s) {
+		f, err := strconv.ParseFloat(s, 64)
+		if err == nil && !math.IsInf(f, 0) {
+			return f, nil
+		}
+	}
+	if m := prefixedNumberRegex.FindStringSubmatch(s); m != nil {
+		base := map[string]int{"x": 16, "o": 8, "b": 2}[strings.ToLower(m[1])]
+		if i, err := strconv.ParseUint(m[2], base, 64); err == nil {
+			return float64(i), nil
+		}
+	}
+	return nil, errorf("D3030", "unable to cast value to a number: %q", s)
+}
+
+type absFunc struct{}
+
+// Call implements $abs(number).
+func (s *absFunc) Call(args ...interface{}) (interface{}, error) {
+	f, ok, err := numberArgs("$abs", args, 1, 1)
+	if !ok {
+		return nil, err
+	}
+	return math.Abs(f), nil
+}
+
+type floorFunc struct{}
+
+// Call implements $floor(number).
+func (s *floorFunc) Call(args ...interface{}) (interface{}, error) {
+	f, ok, err := numberArgs("$floor", args, 1, 1)
+	if !ok {
+		return nil, err
+	}
+	return math.Floor(f), nil
+}
+
+type ceilFunc struct{}
+
+// Call implements $ceil(number).
+func (s *ceilFunc) Call(args ...interface{}) (interface{}, error) {
+	f, ok, err := numberArgs("$ceil", args, 1, 1)
+	if !ok {
+		return nil, err
+	}
+	return math.Ceil(f), nil
+}
+
+type roundFunc struct{}
+
+// Call implements $round(number [, precision]), rounding half to even at precision decimal places. A negative
+// precision rounds to the left of the decimal point.
+func (s *roundFunc) Call(args ...interface{}) (interface{}, error) {
+	f, ok, err := numberArgs("$round", args, 1, 2)
+	if !ok {
+		return nil, err
+	}
+	precision := 0
+	if len(args) > 1 && args[1] != nil {
+		p, ok := lookup.ToFloat(args[1])
+		if !ok {
+			return nil, errorf("T0410", "argument 2 of $round must be a number")
+		}
+		precision = int(p)
+	}
+	// Rounding to many places can scale a large number beyond the range of a float
+	if math.IsInf(shiftDecimal(f, precision), 0) {
+		return nil, errorf("D1001", "number out of range: %v rounded to %d places", f, precision)
+	}
+	return roundHalfEven(f, precision), nil
+}
+
+// roundHalfEven rounds f to precision decimal places with ties going to the even neighbour. The number is shifted by
+// rewriting its decimal exponent rather than multiplying, which would introduce binary rounding errors.
+func roundHalfEven(f float64, precision int) float64 {
+	v := shiftDecimal(f, precision)
+	result := math.Floor(v + 0.5)
+	if math.Abs(result-v) == 0.5 && math.Mod(math.Abs(result), 2) == 1 {
+		result--
+	}
+	result = shiftDecimal(result, -precision)
+	if result == 0 {
+		// avoid -0
+		return 0
+	}
+	return result
+}
+
+// shiftDecimal multiplies f by 10 to the power of places.
+func shiftDecimal(f float64, places int) float64 {
+	if places == 0 {
+		return f
+	}
+	mantissa, exponent := strconv.FormatFloat(f, 'g', -1, 64), 0
+	if i := strings.IndexByte(mantissa, 'e'); i >= 0 {
+		exponent, _ = strconv.Atoi(mantissa[i+1:])
+		mantissa = mantissa[:i]
+	}
+	shifted, err := strconv.ParseFloat(mantissa+"e"+strconv.Itoa(exponent+places), 64)
+	if err != nil {
+		return f * math.Pow10(places)
+	}
+	return shifted
+}
+
+type powerFunc struct{}
+
+// Call implements $power(base, exponent).
+func (s *powerFunc) Call(args ...interface{}) (interface{}, error) {
+	base, ok, err := numberArgs("$power", args, 2, 2)
+	if !ok {
+		return nil, err
+	}
+	exponent, ok := lookup.ToFloat(args[1])
+	if !ok {
+		return nil, errorf("T0410", "argument 2 of $power must be a number")
+	}
+	result := math.Pow(base, exponent)
+	if math.IsNaN(result) || math.IsInf(result, 0) {
+		return nil, errorf("D3061", "the power function has resulted in a value that cannot be represented as a JSON number: base=%v, exponent=%v", base, exponent)
+	}
+	return result, nil
+}
+
+type sqrtFunc struct{}
+
+// Call implements $sqrt(number).
+func (s *sqrtFunc) Call(args ...interface{}) (interface{}, error) {
+	f, ok, err := numberArgs("$sqrt", args, 1, 1)
+	if !ok {
+		return nil, err
+	}
+	if f < 0 {
+		return nil, errorf("D3060", "the sqrt function cannot be applied to a negative number: %v", f)
+	}
+	return math.Sqrt(f), nil
+}
+
+type randomFunc struct{}
+
+// Call implements $random(), a number greater than or equal to zero and less than one.
+func (s *randomFunc) Call(args ...interface{}) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, errorf("T0410", "$random expects no arguments")
+	}
+	return rand.Float64(), nil
+}
+
+type formatBaseFunc struct{}
+
+// Call implements $formatBase(number [, radix]), the number rounded to an integer and written in the radix, which is
+// between 2 and 36 and defaults to 10.
+func (s *formatBaseFunc) Call(args ...interface{}) (interface{}, error) {
+	f, ok, err := numberArgs("$formatBase", args, 1, 2)
+	if !ok {
+		return nil, err
+	}
+	radix := 10
+	if len(args) > 1 && args[1] != nil {
+		r, ok := lookup.ToFloat(args[1])
+		if !ok {
+			return nil, errorf("T0410", "argument 2 of $formatBase must be a number")
+		}
+		radix = int(math.Floor(r))
+	}
+	if radix < 2 || radix > 36 {
+		return nil, errorf("D3100", "the radix of the formatBase function must be between 2 and 36, it was %d", radix)
+	}
+	f = roundHalfEven(f, 0)
+	if !(f > math.MinInt64 && f < math.MaxInt64) {
+		return nil, errorf("D1001", "number out of range: %v", f)
+	}
+	return strconv.FormatInt(int64(f), radix), nil
+}
+
+// numberArgs checks a numeric function was given between min and max arguments and returns the first, which must
+// be a number. ok is false when the function has nothing to return, either because of err or because the number is
+// undefined.
+func numberArgs(name string, args []interface{}, min, max int) (f float64, ok bool, err error) {
+	if len(args) < min || len(args) > max {
+		if min == max {
+			return 0, false, errorf("T0410", "%s expects %d arguments", name, min)
+		}
+		return 0, false, errorf("T0410", "%s expects %d to %d arguments", name, min, max)
+	}
+	if args[0] == nil {
+		return 0, false, nil
+	}
+	f, ok = lookup.ToFloat(args[0])
+	if !ok {
+		return 0, false, errorf("T0410", "argument 1 of %s must be a number", name)
+	}
+	return f, true, nil
+}
@@ -0,0 +1,94 @@
+package jsonata
+
+import (
+	"testing"
+
+	"github.com/arran4/lookup"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNumericFunctions(t *testing.T) {
+	data := map[string]interface{}{
+		"Price":    34.45,
+		"Quantity": 3,
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected interface{}
+	}{
+		{"Number of string", `$number("1.5e2")`, float64(150)},
+		{"Number of hex", `$number("0x1F")`, float64(31)},
+		{"Number of boolean", `$number(true)`, float64(1)},
+		{"Number of int", `$number(Quantity)`, float64(3)},
+		{"Abs", `$abs(0 - Price)`, 34.45},
+		{"Floor", `$floor(Price)`, float64(34)},
+		{"Ceil", `$ceil(Price)`, float64(35)},
+		{"Round", `$round(Price)`, float64(34)},
+		{"Round half to even", `$round(2.5) + $round(3.5)`, float64(6)},
+		{"Round to precision", `$round(Price, 1)`, 34.4},
+		{"Round to tens", `$round(1250, -2)`, float64(1200)},
+		{"Round a large number", `$round(1e300, 2)`, 1e300},
+		{"Power", `$power(2, 10)`, float64(1024)},
+		{"Sqrt", `$sqrt(16)`, float64(4)},
+		{"Format base", `$formatBase(255, 16)`, "ff"},
+		{"Format number", `$formatNumber(1234.5, "#,##0.00")`, "1,234.50"},
+		{"Format number percent", `$formatNumber(0.256, "0.0%")`, "25.6%"},
+		{"Format number negative pattern", `$formatNumber(-3, "0;(0)")`, "(3)"},
+		{"Format number exponent", `$formatNumber(1234.5678, "0.00e0")`, "1.23e3"},
+		{"Format number options", `$formatNumber(1234.5, "#.##0,00", {"decimal-separator": ",", "grouping-separator": "."})`, "1.234,50"},
+		{"Format integer", `$formatInteger(1234567, "#,##0")`, "1,234,567"},
+		{"Format integer padded", `$formatInteger(7, "000")`, "007"},
+		{"Format integer ordinal", `$formatInteger(22, "0;o")`, "22nd"},
+		{"Format integer letters", `$formatInteger(28, "a")`, "ab"},
+		{"Format integer roman", `$formatInteger(2024, "I")`, "MMXXIV"},
+		{"Format integer words", `$formatInteger(1215, "w")`, "one thousand, two hundred and fifteen"},
+		{"Format integer ordinal words", `$formatInteger(40, "Ww;o")`, "Fortieth"},
+		{"Parse integer", `$parseInteger("1,234,567", "#,##0")`, float64(1234567)},
+		{"Parse integer roman", `$parseInteger("MMXXIV", "I")`, float64(2024)},
+		{"Parse integer letters", `$parseInteger("ab", "a")`, float64(28)},
+		{"Parse integer words", `$parseInteger("one thousand, two hundred and fifteen", "w")`, float64(1215)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, run(t, data, tt.expr))
+		})
+	}
+}
+
+func TestRandom(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		f, ok := run(t, nil, `$random()`).(float64)
+		assert.True(t, ok)
+		assert.True(t, f >= 0 && f < 1, "random out of range: %v", f)
+	}
+}
+
+func TestNumericFunctionErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"Number of text", `$number("ten")`},
+		{"Abs of string", `$abs("1")`},
+		{"Sqrt of negative", `$sqrt(-1)`},
+		{"Power out of range", `$power(10, 400)`},
+		{"Format base radix", `$formatBase(10, 40)`},
+		{"Format number two decimal separators", `$formatNumber(1, "0.0.0")`},
+		{"Format number passive character", `$formatNumber(1, "0x0")`},
+		{"Format integer unsupported picture", `$formatInteger(1, "q")`},
+		{"Parse integer mismatch", `$parseInteger("12a", "0")`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast, err := Parse(tt.expr)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.IsType(t, &lookup.Invalidor{}, Compile(ast).Run(lookup.NewScope(nil, lookup.Reflect(nil))))
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package jsonata
+
+import (
+	"reflect"
+
+	"github.com/arran4/lookup"
+)
+
+// keysFunction implements $keys(object), the keys of the object or, for an array of objects, the distinct keys of
+// all of them.
+func keysFunction(_ *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+	if len(args) != 1 {
+		return invalid("T0410", "$keys expects 1 argument")
+	}
+	var keys []interface{}
+	seen := map[string]bool{}
+	for _, item := range sequenceOf(args[0]) {
+		if !isObject(item) {
+			continue
+		}
+		names, _ := entriesOf(lookup.Reflect(item))
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				keys = append(keys, name)
+			}
+		}
+	}
+	return sequenceResult(keys)
+}
+
+// lookupKeyFunction implements $lookup(object, key), the value of the key in the object or, for an array of objects,
+// the values of the key in each of them. An undefined key gives an undefined result.
+func lookupKeyFunction(_ *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+	if len(args) != 2 {
+		return invalid("T0410", "$lookup expects 2 arguments")
+	}
+	if IsUndefined(args[1]) {
+		return undefined()
+	}
+	key, ok := args[1].Raw().(string)
+	if !ok {
+		return invalid("T0410", "argument 2 of $lookup must be a string")
+	}
+	var results []interface{}
+	for _, item := range sequenceOf(args[0]) {
+		names, values := entriesOf(lookup.Reflect(item))
+		for i, name := range names {
+			if name == key {
+				results = appendValue(results, lookup.Reflect(values[i]))
+			}
+		}
+	}
+	return sequenceResult(results)
+}
+
+// spreadFunction implements $spread(object), splitting an object into an array of objects each holding one of its
+// key/value pairs. An array has each of its objects spread, anything else is returned as it is.
+func spreadFunction(_ *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+	if len(args) != 1 {
+		return invalid("T0410", "$spread expects 1 argument")
+	}
+	if IsUndefined(args[0]) {
+		return undefined()
+	}
+	raw := args[0].Raw()
+	if isFunction(raw) || (!args[0].IsSlice() && !isObject(raw)) {
+		return args[0]
+	}
+	var results []interface{}
+	for _, item := range sequenceOf(args[0]) {
+		if !isObject(item) {
+			results = append(results, item)
+			continue
+		}
+		keys, values := entriesOf(lookup.Reflect(item))
+		for i, key := range keys {
+			results = append(results, map[string]interface{}{key: values[i]})
+		}
+	}
+	return sequenceResult(results)
+}
+
+type mergeFunc struct{}
+
+// Call implements $merge(objects), combining an array of objects into one. Later objects override the keys of earlier
+// ones.
+func (s *mergeFunc) Call(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, errorf("T0410", "$merge expects 1 argument")
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+	result := map[string]interface{}{}
+	for _, item := range sequenceOf(lookup.Reflect(args[0])) {
+		if !isObject(item) {
+			return nil, errorf("T0410", "argument 1 of $merge must be an array of objects")
+		}
+		keys, values := entriesOf(lookup.Reflect(item))
+		for i, key := range keys {
+			result[key] = values[i]
+		}
+	}
+	return result, nil
+}
+
+// typeFunction implements $type(value), the name of the JSON type of the value: "null", "number", "string",
+// "boolean", "array", "object" or "function". An undefined value has no type.
+func typeFunction(_ *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+	if len(args) != 1 {
+		return invalid("T0410", "$type expects 1 argument")
+	}
+	if IsUndefined(args[0]) {
+		return undefined()
+	}
+	return lookup.Reflect(typeOf(args[0].Raw()))
+}
+
+// typeOf returns the JSON type name of a value.
+func typeOf(v interface{}) string {
+	if isFunction(v) {
+		return "function"
+	}
+	if _, ok := lookup.ToFloat(v); ok {
+		return "number"
+	}
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	}
+	rv := reflect.ValueOf(v)
+	for (rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface) && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return "null"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	case reflect.Func:
+		return "function"
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	}
+	return "number"
+}
+
+// isObject reports whether v is a JSON object, a map or a struct.
+func isObject(v interface{}) bool {
+	return !isFunction(v) && typeOf(v) == "object"
+}
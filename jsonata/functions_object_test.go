@@ -0,0 +1,80 @@
+package jsonata
+
+import (
+	"testing"
+
+	"github.com/arran4/lookup"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObjectFunctions(t *testing.T) {
+	data := map[string]interface{}{
+		"Product": []interface{}{
+			map[string]interface{}{"Name": "Hat", "Price": 12.5},
+			map[string]interface{}{"Name": "Bowler", "Colour": "Black"},
+		},
+		"Order": struct {
+			ID    string
+			Total float64
+		}{ID: "A1", Total: 3},
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected interface{}
+	}{
+		{"Keys", `$keys(Product[0])`, []interface{}{"Name", "Price"}},
+		{"Keys across array", `$keys(Product)`, []interface{}{"Name", "Price", "Colour"}},
+		{"Keys of single key", `$keys({"a": 1})`, "a"},
+		{"Keys of struct", `$keys(Order)`, []interface{}{"ID", "Total"}},
+		{"Keys of function", `$keys(function(){1})`, nil},
+		{"Lookup", `$lookup(Product[0], "Price")`, 12.5},
+		{"Lookup across array", `$lookup(Product, "Name")`, []interface{}{"Hat", "Bowler"}},
+		{"Lookup missing", `$lookup(Product, "Size")`, nil},
+		{"Spread", `$spread({"a": 1, "b": 2})`, []interface{}{
+			map[string]interface{}{"a": float64(1)},
+			map[string]interface{}{"b": float64(2)},
+		}},
+		{"Spread non object", `$spread("a")`, "a"},
+		{"Merge", `$merge([{"a": 1}, {"b": 2}, {"a": 3}])`, map[string]interface{}{"a": float64(3), "b": float64(2)}},
+		{"Merge single", `$merge({"a": 1})`, map[string]interface{}{"a": float64(1)}},
+		{"Type of number", `$type(1)`, "number"},
+		{"Type of string", `$type("a")`, "string"},
+		{"Type of boolean", `$type(false)`, "boolean"},
+		{"Type of null", `$type(null)`, "null"},
+		{"Type of array", `$type([1])`, "array"},
+		{"Type of object", `$type(Order)`, "object"},
+		{"Type of function", `$type($sum)`, "function"},
+		{"Type of lambda", `$type(function(){1})`, "function"},
+		{"Type of undefined", `$type(Missing)`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, run(t, data, tt.expr))
+		})
+	}
+}
+
+func TestObjectFunctionErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"Lookup without key", `$lookup({"a": 1})`},
+		{"Lookup number key", `$lookup({"a": 1}, 1)`},
+		{"Merge non objects", `$merge([1, 2])`},
+		{"Type too many arguments", `$type(1, 2)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast, err := Parse(tt.expr)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.IsType(t, &lookup.Invalidor{}, Compile(ast).Run(lookup.NewScope(nil, lookup.Reflect(nil))))
+		})
+	}
+}
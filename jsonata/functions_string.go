@@ -0,0 +1,615 @@
+package jsonata
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/arran4/go-evaluator"
+	"github.com/arran4/lookup"
+)
+
+// String functions follow JSONata in counting characters as Unicode code points rather than bytes. An undefined
+// (nil) string argument gives an undefined result.
+
+// stringFunction implements $string(arg [, prettify]). Strings are returned as they are, numbers are formatted as in
+// JSONata and anything else, null included, is serialised as JSON, indented when prettify is true. Functions become the
+// empty string.
+func stringFunction(_ *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+	if len(args) > 2 {
+		return invalid("T0410", "$string expects at most 2 arguments")
+	}
+	if len(args) == 0 || IsUndefined(args[0]) {
+		return undefined()
+	}
+	prettify := false
+	if len(args) > 1 && !IsUndefined(args[1]) {
+		b, ok := args[1].Raw().(bool)
+		if !ok {
+			return invalid("T0410", "argument 2 of $string must be a boolean")
+		}
+		prettify = b
+	}
+	str, err := stringify(args[0].Raw(), prettify)
+	if err != nil {
+		return lookup.NewInvalidor("", err)
+	}
+	return lookup.Reflect(str)
+}
+
+// stringify casts a value to a string as $string does.
+func stringify(v interface{}, prettify bool) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return formatJSONNumber(v)
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	if isFunction(v) {
+		return "", nil
+	}
+	if f, ok := lookup.ToFloat(v); ok {
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return "", errorf("D3001", "attempting to invoke string function on Infinity or NaN")
+		}
+		return formatNumber(f), nil
+	}
+	normal, err := jsonValue(reflect.ValueOf(v))
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if prettify {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(normal); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// formatNumber formats a number the way JSONata does, to 15 significant digits and in exponent notation only when
+// very large or small.
+func formatNumber(f float64) string {
+	if r, err := strconv.ParseFloat(strconv.FormatFloat(f, 'g', 15, 64), 64); err == nil {
+		f = r
+	}
+	abs := math.Abs(f)
+	if abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		s := strconv.FormatFloat(f, 'e', -1, 64)
+		// Go pads the exponent to two digits, `1e-07`, JSONata doesn't
+		if i := strings.Index(s, "e"); i >= 0 && len(s) > i+3 && s[i+2] == '0' {
+			s = s[:i+2] + s[i+3:]
+		}
+		return s
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func formatJSONNumber(n json.Number) (string, error) {
+	if _, err := n.Int64(); err == nil {
+		return n.String(), nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return "", err
+	}
+	return formatNumber(f), nil
+}
+
+// jsonValue converts a value into one which encoding/json serialises as JSONata would, with numbers formatted by
+// formatNumber and functions replaced by the empty string.
+func jsonValue(v reflect.Value) (interface{}, error) {
+	for v.IsValid() && (v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr) {
+		if v.IsNil() {
+			return nil, nil
+		}
+		if v.CanInterface() && isFunction(v.Interface()) {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, nil
+	}
+	if v.CanInterface() {
+		switch i := v.Interface().(type) {
+		case json.Number:
+			return i, nil
+		case evaluator.Function, Callable:
+			return "", nil
+		}
+	}
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, errorf("D3001", "attempting to invoke string function on Infinity or NaN")
+		}
+		return json.Number(formatNumber(f)), nil
+	case reflect.Func:
+		return "", nil
+	case reflect.Map:
+		out := make(map[string]interface{}, v.Len())
+		for _, k := range v.MapKeys() {
+			val, err := jsonValue(v.MapIndex(k))
+			if err != nil {
+				return nil, err
+			}
+			out[fmt.Sprint(k.Interface())] = val
+		}
+		return out, nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			val, err := jsonValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			out[i] = val
+		}
+		return out, nil
+	}
+	return v.Interface(), nil
+}
+
+type lengthFunc struct{}
+
+// Call implements $length(str), the number of characters in the string.
+func (s *lengthFunc) Call(args ...interface{}) (interface{}, error) {
+	str, ok, err := stringArgs("$length", args, 1, 1)
+	if !ok {
+		return nil, err
+	}
+	return float64(utf8.RuneCountInString(str)), nil
+}
+
+type uppercaseFunc struct{}
+
+// Call implements $uppercase(str).
+func (s *uppercaseFunc) Call(args ...interface{}) (interface{}, error) {
+	str, ok, err := stringArgs("$uppercase", args, 1, 1)
+	if !ok {
+		return nil, err
+	}
+	return strings.ToUpper(str), nil
+}
+
+type lowercaseFunc struct{}
+
+// Call implements $lowercase(str).
+func (s *lowercaseFunc) Call(args ...interface{}) (interface{}, error) {
+	str, ok, err := stringArgs("$lowercase", args, 1, 1)
+	if !ok {
+		return nil, err
+	}
+	return strings.ToLower(str), nil
+}
+
+var whitespaceRegex = regexp.MustCompile(`[ \t\n\r]+`)
+
+type trimFunc struct{}
+
+// Call implements $trim(str), which collapses runs of whitespace into a single space and removes any at either end.
+func (s *trimFunc) Call(args ...interface{}) (interface{}, error) {
+	str, ok, err := stringArgs("$trim", args, 1, 1)
+	if !ok {
+		return nil, err
+	}
+	return strings.Trim(whitespaceRegex.ReplaceAllString(str, " "), " "), nil
+}
+
+// maxPadWidth is the widest $pad may pad a string to, bounding what it allocates the way maxRangeSize bounds ranges.
+const maxPadWidth = maxRangeSize
+
+type padFunc struct{}
+
+// Call implements $pad(str, width [, char]). A positive width pads on the right and a negative one on the left,
+// with char, a space by default, repeated to fill the width.
+func (s *padFunc) Call(args ...interface{}) (interface{}, error) {
+	str, ok, err := stringArgs("$pad", args, 2, 3)
+	if !ok {
+		return nil, err
+	}
+	w, ok := lookup.ToFloat(args[1])
+	if !ok {
+		return nil, errorf("T0410", "argument 2 of $pad must be a number")
+	}
+	if math.Abs(w) > maxPadWidth {
+		return nil, errorf("D1001", "the width of $pad must not exceed %d characters: %v", maxPadWidth, w)
+	}
+	width := int(math.Trunc(w))
+	char := " "
+	if len(args) > 2 && args[2] != nil {
+		if char, ok = args[2].(string); !ok {
+			return nil, errorf("T0410", "argument 3 of $pad must be a string")
+		}
+		if char == "" {
+			char = " "
+		}
+	}
+	padLength := absInt(width) - utf8.RuneCountInString(str)
+	if padLength <= 0 {
+		return str, nil
+	}
+	repeat := (padLength + utf8.RuneCountInString(char) - 1) / utf8.RuneCountInString(char)
+	padding := []rune(strings.Repeat(char, repeat))[:padLength]
+	if width < 0 {
+		return string(padding) + str, nil
+	}
+	return str + string(padding), nil
+}
+
+func absInt(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+type substringBeforeFunc struct{}
+
+// Call implements $substringBefore(str, chars), the part of str before the first occurrence of chars or all of str
+// when chars isn't found.
+func (s *substringBeforeFunc) Call(args ...interface{}) (interface{}, error) {
+	str, ok, err := stringArgs("$substringBefore", args, 2, 2)
+	if !ok {
+		return nil, err
+	}
+	chars, ok := args[1].(string)
+	if !ok {
+		return nil, errorf("T0410", "argument 2 of $substringBefore must be a string")
+	}
+	if i := strings.Index(str, chars); i >= 0 {
+		return str[:i], nil
+	}
+	return str, nil
+}
+
+type substringAfterFunc struct{}
+
+// Call implements $substringAfter(str, chars), the part of str after the first occurrence of chars or all of str
+// when chars isn't found.
+func (s *substringAfterFunc) Call(args ...interface{}) (interface{}, error) {
+	str, ok, err := stringArgs("$substringAfter", args, 2, 2)
+	if !ok {
+		return nil, err
+	}
+	chars, ok := args[1].(string)
+	if !ok {
+		return nil, errorf("T0410", "argument 2 of $substringAfter must be a string")
+	}
+	if i := strings.Index(str, chars); i >= 0 {
+		return str[i+len(chars):], nil
+	}
+	return str, nil
+}
+
+type containsFunc struct{}
+
+// Call implements $contains(str, pattern), where pattern is a string or a regular expression.
+func (s *containsFunc) Call(args ...interface{}) (interface{}, error) {
+	str, ok, err := stringArgs("$contains", args, 2, 2)
+	if !ok {
+		return nil, err
+	}
+	if pattern, ok := args[1].(string); ok {
+		return strings.Contains(str, pattern), nil
+	}
+	matches, ok, err := findMatches(args[1], str, 1)
+	if !ok {
+		return nil, errorf("T0410", "argument 2 of $contains must be a string or regular expression")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return len(matches) > 0, nil
+}
+
+type splitFunc struct{}
+
+// Call implements $split(str, separator [, limit]), where separator is a string or a regular expression. An empty
+// separator splits str into characters, and limit caps the number of parts returned.
+func (s *splitFunc) Call(args ...interface{}) (interface{}, error) {
+	str, ok, err := stringArgs("$split", args, 2, 3)
+	if !ok {
+		return nil, err
+	}
+	limit := -1
+	if len(args) > 2 {
+		l, ok := lookup.ToFloat(args[2])
+		if !ok || l < 0 {
+			return nil, errorf("D3020", "argument 3 of $split must be a non-negative number")
+		}
+		limit = int(math.Floor(l))
+	}
+	var parts []string
+	switch separator := args[1].(type) {
+	case string:
+		if separator == "" {
+			for _, r := range str {
+				parts = append(parts, string(r))
+			}
+		} else {
+			parts = strings.Split(str, separator)
+		}
+	default:
+		matches, ok, err := findMatches(separator, str, -1)
+		if !ok {
+			return nil, errorf("T0410", "argument 2 of $split must be a string or regular expression")
+		}
+		if err != nil {
+			return nil, err
+		}
+		last := 0
+		for _, m := range matches {
+			parts = append(parts, str[last:m.start])
+			last = m.end
+		}
+		parts = append(parts, str[last:])
+	}
+	if limit >= 0 && limit < len(parts) {
+		parts = parts[:limit]
+	}
+	result := make([]interface{}, len(parts))
+	for i, part := range parts {
+		result[i] = part
+	}
+	return result, nil
+}
+
+type joinFunc struct{}
+
+// Call implements $join(strings [, separator]), concatenating an array of strings.
+func (s *joinFunc) Call(args ...interface{}) (interface{}, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return nil, errorf("T0410", "$join expects 1 or 2 arguments")
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+	separator := ""
+	if len(args) > 1 && args[1] != nil {
+		var ok bool
+		if separator, ok = args[1].(string); !ok {
+			return nil, errorf("T0410", "argument 2 of $join must be a string")
+		}
+	}
+	var parts []string
+	switch v := args[0].(type) {
+	case string:
+		parts = []string{v}
+	case []interface{}:
+		for _, item := range v {
+			str, ok := item.(string)
+			if !ok {
+				return nil, errorf("T0410", "argument 1 of $join must be an array of strings")
+			}
+			parts = append(parts, str)
+		}
+	case []string:
+		parts = v
+	default:
+		return nil, errorf("T0410", "argument 1 of $join must be an array of strings")
+	}
+	return strings.Join(parts, separator), nil
+}
+
+type replaceFunc struct{}
+
+// Call implements $replace(str, pattern, replacement [, limit]). The pattern is a string or a regular expression,
+// in which case the replacement may refer to the match as $0 and to groups as $1, $2 and so on, or be a function
+// called with each match object, as returned by $match, resulting in its replacement. At most limit occurrences are
+// replaced.
+func (s *replaceFunc) Call(args ...interface{}) (interface{}, error) {
+	str, ok, err := stringArgs("$replace", args, 3, 4)
+	if !ok {
+		return nil, err
+	}
+	replacement, isString := args[2].(string)
+	if !isString && !isFunction(args[2]) {
+		return nil, errorf("T0410", "argument 3 of $replace must be a string or function")
+	}
+	limit := -1
+	if len(args) > 3 {
+		l, ok := lookup.ToFloat(args[3])
+		if !ok || l < 0 {
+			return nil, errorf("D3011", "argument 4 of $replace must be a non-negative number")
+		}
+		limit = int(math.Floor(l))
+	}
+	if pattern, ok := args[1].(string); ok {
+		if pattern == "" {
+			return nil, errorf("D3010", "second argument of $replace can't be an empty string")
+		}
+		if !isString {
+			return nil, errorf("T0410", "argument 3 of $replace must be a string when the pattern is a string")
+		}
+		return strings.Replace(str, pattern, replacement, limit), nil
+	}
+	if limit == 0 {
+		return str, nil
+	}
+	matches, ok, err := findMatches(args[1], str, limit)
+	if !ok {
+		return nil, errorf("T0410", "argument 2 of $replace must be a string or regular expression")
+	}
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		if m.match == "" {
+			return nil, errorf("D1004", "regular expression matches zero length string")
+		}
+		b.WriteString(str[last:m.start])
+		if isString {
+			b.WriteString(expandReplacement(replacement, append([]string{m.match}, m.groups...)))
+		} else {
+			res := Call(&lookup.Scope{}, args[2], lookup.Reflect(matchObject(str, m)))
+			if err := errorOf(res); err != nil {
+				return nil, err
+			}
+			r, ok := res.Raw().(string)
+			if IsUndefined(res) || !ok {
+				return nil, errorf("D3012", "the replacement function of $replace must result in a string")
+			}
+			b.WriteString(r)
+		}
+		last = m.end
+	}
+	b.WriteString(str[last:])
+	return b.String(), nil
+}
+
+// expandReplacement substitutes $0 with the match and $n with its groups in a replacement string, `$$` is a literal
+// dollar. As in JSONata, when $nn refers to a group that doesn't exist the group $n is followed by the digit.
+func expandReplacement(replacement string, match []string) string {
+	var b strings.Builder
+	for i := 0; i < len(replacement); i++ {
+		c := replacement[i]
+		if c != '$' || i+1 >= len(replacement) {
+			b.WriteByte(c)
+			continue
+		}
+		next := replacement[i+1]
+		if next == '$' {
+			b.WriteByte('$')
+			i++
+			continue
+		}
+		if !isDigit(next) {
+			b.WriteByte(c)
+			continue
+		}
+		group := int(next - '0')
+		i++
+		if i+1 < len(replacement) && isDigit(replacement[i+1]) {
+			if two := group*10 + int(replacement[i+1]-'0'); two < len(match) {
+				group = two
+				i++
+			}
+		}
+		if group < len(match) {
+			b.WriteString(match[group])
+		}
+	}
+	return b.String()
+}
+
+type matchFunc struct{}
+
+// Call implements $match(str, pattern [, limit]) returning an object for each match of the regular expression with
+// the matched string, its index and the captured groups.
+func (s *matchFunc) Call(args ...interface{}) (interface{}, error) {
+	str, ok, err := stringArgs("$match", args, 2, 3)
+	if !ok {
+		return nil, err
+	}
+	pattern := args[1]
+	if p, ok := pattern.(string); ok {
+		if pattern, err = regexp.Compile(p); err != nil {
+			return nil, err
+		}
+	}
+	limit := -1
+	if len(args) > 2 && args[2] != nil {
+		l, ok := lookup.ToFloat(args[2])
+		if !ok || l < 0 {
+			return nil, errorf("D3040", "argument 3 of $match must be a non-negative number")
+		}
+		limit = int(math.Floor(l))
+	}
+	if limit == 0 {
+		return nil, nil
+	}
+	matches, ok, err := findMatches(pattern, str, limit)
+	if !ok {
+		return nil, errorf("T0410", "argument 2 of $match must be a regular expression")
+	}
+	if err != nil {
+		return nil, err
+	}
+	var results []interface{}
+	for _, m := range matches {
+		results = append(results, matchObject(str, m))
+	}
+	if len(results) == 0 {
+		return nil, nil
+	}
+	return results, nil
+}
+
+// matchObject describes a match as $match does, with the matched string, its index and the captured groups.
+func matchObject(str string, m regexMatch) map[string]interface{} {
+	groups := make([]interface{}, len(m.groups))
+	for i, g := range m.groups {
+		groups[i] = g
+	}
+	return map[string]interface{}{
+		"match":  m.match,
+		"index":  float64(utf8.RuneCountInString(str[:m.start])),
+		"groups": groups,
+	}
+}
+
+type base64EncodeFunc struct{}
+
+// Call implements $base64encode(str), encoding the UTF-8 bytes of the string.
+func (s *base64EncodeFunc) Call(args ...interface{}) (interface{}, error) {
+	str, ok, err := stringArgs("$base64encode", args, 1, 1)
+	if !ok {
+		return nil, err
+	}
+	return base64.StdEncoding.EncodeToString([]byte(str)), nil
+}
+
+type base64DecodeFunc struct{}
+
+// Call implements $base64decode(str).
+func (s *base64DecodeFunc) Call(args ...interface{}) (interface{}, error) {
+	str, ok, err := stringArgs("$base64decode", args, 1, 1)
+	if !ok {
+		return nil, err
+	}
+	b, err := base64.StdEncoding.DecodeString(str)
+	if err != nil {
+		return nil, errorf("D3140", "malformed base64 passed to $base64decode(): %w", err)
+	}
+	return string(b), nil
+}
+
+// stringArgs checks a string function was given between min and max arguments and returns the first, which must
+// be a string. ok is false when the function has nothing to return, either because of err or because the string is
+// undefined.
+func stringArgs(name string, args []interface{}, min, max int) (str string, ok bool, err error) {
+	if len(args) < min || len(args) > max {
+		if min == max {
+			return "", false, errorf("T0410", "%s expects %d arguments", name, min)
+		}
+		return "", false, errorf("T0410", "%s expects %d to %d arguments", name, min, max)
+	}
+	if args[0] == nil {
+		return "", false, nil
+	}
+	str, ok = args[0].(string)
+	if !ok {
+		return "", false, errorf("T0410", "argument 1 of %s must be a string", name)
+	}
+	return str, true, nil
+}
@@ -0,0 +1,135 @@
+package jsonata
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/arran4/lookup"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringFunctions(t *testing.T) {
+	data := map[string]interface{}{
+		"Name":  "Café Olé",
+		"Words": []interface{}{"a", "b", "c"},
+		"Price": 34.45,
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected interface{}
+	}{
+		{"String of string", `$string("hello")`, "hello"},
+		{"String of number", `$string(5)`, "5"},
+		{"String of float", `$string(Price)`, "34.45"},
+		{"String rounds to 15 digits", `$string(0.1 + 0.2)`, "0.3"},
+		{"String of small number", `$string(1e-7)`, "1e-7"},
+		{"String of large number", `$string(1e21)`, "1e+21"},
+		{"String of boolean", `$string(true)`, "true"},
+		{"String of array", `$string([1, "two", true])`, `[1,"two",true]`},
+		{"String of object", `$string({"a": [1.5], "f": $sum})`, `{"a":[1.5],"f":""}`},
+		{"String prettified", `$string({"a": 1}, true)`, "{\n  \"a\": 1\n}"},
+		{"String keeps html", `$string(["<&>"])`, `["<&>"]`},
+		{"String of function", `$string(function(){1})`, ""},
+		{"String of null", `$string(null)`, "null"},
+		{"String of null in an array", `$string([null])`, "[null]"},
+		{"Concatenate rounds to 15 digits", `"a" & 0.1 + 0.2`, "a0.3"},
+		{"Concatenate formats as string", `1e21 & "/" & 1e-7 & "/" & [1.5, null] & true`, "1e+21/1e-7/[1.5,null]true"},
+		{"Concatenate undefined", `Missing & "a" & Missing`, "a"},
+		{"Concatenate null", `"a" & null`, "anull"},
+		{"Length", `$length(Name)`, float64(8)},
+		{"Length of escape", `$length("é😀")`, float64(2)},
+		{"Uppercase", `$uppercase(Name)`, "CAFÉ OLÉ"},
+		{"Lowercase", `$lowercase(Name)`, "café olé"},
+		{"Trim", `$trim("  a \t\n b  ")`, "a b"},
+		{"Pad right", `$pad("é", 3)`, "é  "},
+		{"Pad left", `$pad("é", -3, "#")`, "##é"},
+		{"Pad with string", `$pad("a", 4, "xy")`, "axyx"},
+		{"Pad wider than width", `$pad("abc", 2)`, "abc"},
+		{"Uppercase sharp s", `$uppercase("ß")`, "ß"},
+		{"Substring negative length", `$substring("hello", -3, -1)`, ""},
+		{"Substring before", `$substringBefore(Name, " ")`, "Café"},
+		{"Substring before missing", `$substringBefore(Name, "x")`, "Café Olé"},
+		{"Substring after", `$substringAfter(Name, "é")`, " Olé"},
+		{"Contains", `$contains(Name, "Olé")`, true},
+		{"Contains missing", `$contains(Name, "x")`, false},
+		{"Split", `$split("a,b,c", ",")`, []interface{}{"a", "b", "c"}},
+		{"Split single", `$split("abc", ",")`, []interface{}{"abc"}},
+		{"Split into characters", `$split("Olé", "")`, []interface{}{"O", "l", "é"}},
+		{"Split with limit", `$split("a,b,c", ",", 2.5)`, []interface{}{"a", "b"}},
+		{"Join", `$join(Words)`, "abc"},
+		{"Join with separator", `$join(Words, ", ")`, "a, b, c"},
+		{"Join string", `$join("a", ",")`, "a"},
+		{"Replace", `$replace("abcabc", "b", "x")`, "axcaxc"},
+		{"Replace with limit", `$replace("abcabc", "b", "x", 1)`, "axcabc"},
+		{"Base64 encode", `$base64encode("Café")`, "Q2Fmw6k="},
+		{"Base64 decode", `$base64decode("Q2Fmw6k=")`, "Café"},
+		{"Path through split", `$split("a b", " ").$uppercase($)`, []interface{}{"A", "B"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, run(t, data, tt.expr))
+		})
+	}
+}
+
+func TestStringFunctionErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"Length of number", `$length(5)`},
+		{"Join non strings", `$join([1, 2])`},
+		{"Split negative limit", `$split("a", ",", -1)`},
+		{"Replace empty pattern", `$replace("abc", "", "x")`},
+		{"Pad without width", `$pad("abc")`},
+		{"Base64 decode invalid", `$base64decode("!")`},
+		{"String too many arguments", `$string(1, true, 2)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast, err := Parse(tt.expr)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.IsType(t, &lookup.Invalidor{}, Compile(ast).Run(lookup.NewScope(nil, lookup.Reflect(nil))))
+		})
+	}
+}
+
+func TestStringFunctionsWithRegexp(t *testing.T) {
+	pattern := regexp.MustCompile(`(\w)(\d)`)
+
+	tests := []struct {
+		name     string
+		fn       string
+		args     []interface{}
+		expected interface{}
+	}{
+		{"Contains", "$contains", []interface{}{"ab1", pattern}, true},
+		{"Split", "$split", []interface{}{"xa1yb2z", pattern}, []interface{}{"x", "y", "z"}},
+		{"Replace groups", "$replace", []interface{}{"a1 b2", pattern, "$2$1"}, "1a 2b"},
+		{"Replace match and dollar", "$replace", []interface{}{"a1", pattern, "[$0]$$"}, "[a1]$"},
+		{"Replace missing group", "$replace", []interface{}{"a1", pattern, "$12"}, "a2"},
+		{"Replace with limit", "$replace", []interface{}{"a1 b2", pattern, "-", 1}, "- b2"},
+		{"Match", "$match", []interface{}{"é a1 b2", pattern}, []interface{}{
+			map[string]interface{}{"match": "a1", "index": float64(2), "groups": []interface{}{"a", "1"}},
+			map[string]interface{}{"match": "b2", "index": float64(5), "groups": []interface{}{"b", "2"}},
+		}},
+		{"Match with limit", "$match", []interface{}{"a1 b2", pattern, 1}, []interface{}{
+			map[string]interface{}{"match": "a1", "index": float64(0), "groups": []interface{}{"a", "1"}},
+		}},
+		{"No match", "$match", []interface{}{"xyz", pattern}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := Functions[tt.fn].Call(tt.args...)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, res)
+		})
+	}
+}
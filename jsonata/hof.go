@@ -0,0 +1,263 @@
+package jsonata
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/arran4/lookup"
+)
+
+// Higher-order functions take a function value as an argument. They are NativeFunctions so they receive the calling
+// scope and can call the lambdas or registered functions passed to them, with Call.
+
+// mapFunction implements $map(array, function), the function is called with each value, its index and the array.
+func mapFunction(scope *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+	fn, err := functionArg("$map", args, 1)
+	if err != nil {
+		return lookup.NewInvalidor("", err)
+	}
+	items := sequenceOf(args[0])
+	var results []interface{}
+	for i, item := range items {
+		res := Call(scope, fn, lookup.Reflect(item), lookup.Reflect(float64(i)), args[0])
+		if errorOf(res) != nil {
+			return res
+		}
+		if IsUndefined(res) {
+			continue
+		}
+		results = append(results, res.Raw())
+	}
+	return sequenceResult(results)
+}
+
+// filterFunction implements $filter(array, function), keeping the values for which the function returns true.
+func filterFunction(scope *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+	fn, err := functionArg("$filter", args, 1)
+	if err != nil {
+		return lookup.NewInvalidor("", err)
+	}
+	items := sequenceOf(args[0])
+	var results []interface{}
+	for i, item := range items {
+		res := Call(scope, fn, lookup.Reflect(item), lookup.Reflect(float64(i)), args[0])
+		if errorOf(res) != nil {
+			return res
+		}
+		if !IsUndefined(res) && toBoolean(res.Raw()) {
+			results = append(results, item)
+		}
+	}
+	return sequenceResult(results)
+}
+
+// reduceFunction implements $reduce(array, function [, init]). The function is called with the accumulated value and
+// each value in turn, along with its index and the array. Without init the first value starts the accumulation.
+func reduceFunction(scope *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+	fn, err := functionArg("$reduce", args, 1)
+	if err != nil {
+		return lookup.NewInvalidor("", err)
+	}
+	if l, ok := fn.(*Lambda); ok && len(l.Params) < 2 {
+		return lookup.NewInvalidor("", errorf("D3050", "the second argument of $reduce must be a function with at least two arguments"))
+	}
+	items := sequenceOf(args[0])
+	var acc lookup.Pathor
+	start := 0
+	if len(args) > 2 && !IsUndefined(args[2]) {
+		acc = args[2]
+	} else if len(items) > 0 {
+		acc = lookup.Reflect(items[0])
+		start = 1
+	} else {
+		return undefined()
+	}
+	for i := start; i < len(items); i++ {
+		acc = Call(scope, fn, acc, lookup.Reflect(items[i]), lookup.Reflect(float64(i)), args[0])
+		if errorOf(acc) != nil {
+			return acc
+		}
+	}
+	return acc
+}
+
+// siftFunction implements $sift(object, function), keeping the key/value pairs for which the function, called with
+// the value, key and object, returns true.
+func siftFunction(scope *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+	fn, err := functionArg("$sift", args, 1)
+	if err != nil {
+		return lookup.NewInvalidor("", err)
+	}
+	keys, values := entriesOf(args[0])
+	result := map[string]interface{}{}
+	for i, key := range keys {
+		res := Call(scope, fn, lookup.Reflect(values[i]), lookup.Reflect(key), args[0])
+		if errorOf(res) != nil {
+			return res
+		}
+		if !IsUndefined(res) && toBoolean(res.Raw()) {
+			result[key] = values[i]
+		}
+	}
+	if len(result) == 0 {
+		return undefined()
+	}
+	return lookup.Reflect(result)
+}
+
+// eachFunction implements $each(object, function), returning the results of calling the function with the value,
+// key and object of each key/value pair.
+func eachFunction(scope *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+	fn, err := functionArg("$each", args, 1)
+	if err != nil {
+		return lookup.NewInvalidor("", err)
+	}
+	keys, values := entriesOf(args[0])
+	var results []interface{}
+	for i, key := range keys {
+		res := Call(scope, fn, lookup.Reflect(values[i]), lookup.Reflect(key), args[0])
+		if errorOf(res) != nil {
+			return res
+		}
+		if IsUndefined(res) {
+			continue
+		}
+		results = append(results, res.Raw())
+	}
+	return sequenceResult(results)
+}
+
+// singleFunction implements $single(array [, function]), returning the one value for which the function is true. It's
+// an error for no value or more than one value to match. An undefined array gives an undefined result.
+func singleFunction(scope *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+	if len(args) == 0 {
+		return invalid("T0410", "$single expects an array")
+	}
+	if IsUndefined(args[0]) {
+		return undefined()
+	}
+	var fn interface{}
+	if len(args) > 1 && !IsUndefined(args[1]) {
+		var err error
+		if fn, err = functionArg("$single", args, 1); err != nil {
+			return lookup.NewInvalidor("", err)
+		}
+	}
+	var result interface{}
+	found := false
+	for i, item := range sequenceOf(args[0]) {
+		if fn != nil {
+			res := Call(scope, fn, lookup.Reflect(item), lookup.Reflect(float64(i)), args[0])
+			if errorOf(res) != nil {
+				return res
+			}
+			if IsUndefined(res) || !toBoolean(res.Raw()) {
+				continue
+			}
+		}
+		if found {
+			return lookup.NewInvalidor("", errorf("D3138", "the $single function expected exactly 1 matching result, instead it matched more"))
+		}
+		result = item
+		found = true
+	}
+	if !found {
+		return lookup.NewInvalidor("", errorf("D3139", "the $single function expected exactly 1 matching result, instead it matched 0"))
+	}
+	return lookup.Reflect(result)
+}
+
+// zipFunction implements $zip(array1, array2, ...), returning an array of arrays each holding the values at the same
+// position of every argument. The result is as long as the shortest argument.
+func zipFunction(_ *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+	var arrays [][]interface{}
+	length := -1
+	for _, arg := range args {
+		items := sequenceOf(arg)
+		arrays = append(arrays, items)
+		if length < 0 || len(items) < length {
+			length = len(items)
+		}
+	}
+	result := []interface{}{}
+	for i := 0; i < length; i++ {
+		tuple := make([]interface{}, len(arrays))
+		for j, items := range arrays {
+			tuple[j] = items[i]
+		}
+		result = append(result, tuple)
+	}
+	return &constructedArray{lookup.Reflect(result)}
+}
+
+// functionArg returns argument i of a higher-order function, which must be a function value.
+func functionArg(name string, args []lookup.Pathor, i int) (interface{}, error) {
+	if len(args) <= i || IsUndefined(args[i]) {
+		return nil, errorf("T0410", "%s expects a function as argument %d", name, i+1)
+	}
+	fn := args[i].Raw()
+	if !isFunction(fn) {
+		return nil, errorf("T0410", "%s expects a function as argument %d, got: %v", name, i+1, fn)
+	}
+	return fn, nil
+}
+
+// sequenceOf returns the values of an argument: nothing when it's undefined, the members of an array, or otherwise
+// the argument itself as a sequence of one.
+func sequenceOf(p lookup.Pathor) []interface{} {
+	if IsUndefined(p) {
+		return nil
+	}
+	if p.IsSlice() {
+		s, _ := p.AsSlice()
+		return s
+	}
+	return []interface{}{p.Raw()}
+}
+
+// sequenceResult returns the values as a sequence, which is undefined when empty.
+func sequenceResult(values []interface{}) lookup.Pathor {
+	if len(values) == 0 {
+		return undefined()
+	}
+	return lookup.Reflect(values)
+}
+
+// entriesOf returns the keys, in order, and values of an object argument: a map with string keys or a struct.
+func entriesOf(p lookup.Pathor) ([]string, []interface{}) {
+	if IsUndefined(p) {
+		return nil, nil
+	}
+	v := reflect.ValueOf(p.Raw())
+	for v.IsValid() && (v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr) && !v.IsNil() {
+		v = v.Elem()
+	}
+	var keys []string
+	var values []interface{}
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, nil
+		}
+		for _, k := range v.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			values = append(values, v.MapIndex(reflect.ValueOf(k).Convert(v.Type().Key())).Interface())
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Type().Field(i); f.IsExported() {
+				keys = append(keys, f.Name)
+				values = append(values, v.Field(i).Interface())
+			}
+		}
+	}
+	return keys, values
+}
+
+// undefined is the result of a function which has nothing to return.
+func undefined() lookup.Pathor {
+	return undefinedAt("", "nothing found")
+}
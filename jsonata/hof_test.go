@@ -0,0 +1,100 @@
+package jsonata
+
+import (
+	"testing"
+
+	"github.com/arran4/go-evaluator"
+	"github.com/arran4/lookup"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHigherOrderFunctions(t *testing.T) {
+	data := map[string]interface{}{
+		"Numbers": []interface{}{float64(1), float64(2), float64(3), float64(4)},
+		"Phone": []interface{}{
+			map[string]interface{}{"type": "home", "number": "0203"},
+			map[string]interface{}{"type": "office", "number": "01962"},
+			map[string]interface{}{"type": "mobile", "number": "077"},
+		},
+		"Address": map[string]interface{}{"City": "Winchester", "Street": "Hursley Park"},
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected interface{}
+	}{
+		{"Map", "$map(Numbers, function($v){$v * 10})", []interface{}{float64(10), float64(20), float64(30), float64(40)}},
+		{"Map with index", "$map(Numbers, function($v, $i){$i})", []interface{}{float64(0), float64(1), float64(2), float64(3)}},
+		{"Map with array", "$map([1, 2], function($v, $i, $a){$sum($a)})", []interface{}{float64(3), float64(3)}},
+		{"Map single value", "$map(5, function($v){$v + 1})", float64(6)},
+		{"Map drops undefined", "$map(Phone, function($v){$v.type = 'home' ? $v.number})", "0203"},
+		{"Map registered function", "$map([[1, 2], [3]], $sum)", []interface{}{float64(3), float64(3)}},
+		{"Filter", "$filter(Numbers, function($v){$v % 2 = 0})", []interface{}{float64(2), float64(4)}},
+		{"Filter with index", "$filter(Numbers, function($v, $i){$i < 1})", float64(1)},
+		{"Reduce", "$reduce(Numbers, function($acc, $v){$acc + $v})", float64(10)},
+		{"Reduce with init", "$reduce(Numbers, function($acc, $v){$acc * $v}, 10)", float64(240)},
+		{"Reduce with index", "$reduce(Numbers, function($acc, $v, $i){$acc + $i}, 0)", float64(6)},
+		{"Sift", "$sift(Address, function($v){$v = 'Winchester'})", map[string]interface{}{"City": "Winchester"}},
+		{"Sift by key", "$sift(Address, function($v, $k){$k = 'Street'})", map[string]interface{}{"Street": "Hursley Park"}},
+		{"Each", "$each(Address, function($v, $k){$k & '=' & $v})", []interface{}{"City=Winchester", "Street=Hursley Park"}},
+		{"Single", "$single(Phone, function($p){$p.type = 'office'}).number", "01962"},
+		{"Single without function", "$single([7])", float64(7)},
+		{"Zip", "$zip([1, 2, 3], ['a', 'b'])", []interface{}{
+			[]interface{}{float64(1), "a"}, []interface{}{float64(2), "b"},
+		}},
+		{"Zip single", "$zip(1, 2)", []interface{}{[]interface{}{float64(1), float64(2)}}},
+		{"Map of zip", "$map($zip([1, 2], [3, 4]), $sum)", []interface{}{float64(4), float64(6)}},
+		{"Lambda passed through variable", "($double := function($v){$v * 2}; $map([1, 2], $double))", []interface{}{float64(2), float64(4)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, run(t, data, tt.expr))
+		})
+	}
+}
+
+func TestHigherOrderFunctionErrors(t *testing.T) {
+	data := map[string]interface{}{
+		"Numbers": []interface{}{float64(1), float64(2)},
+	}
+	for _, expr := range []string{
+		"$map(Numbers, 1)",
+		"$filter(Numbers)",
+		"$reduce(Numbers, function($v){$v})",
+		"$reduce([], function($a, $b){$a + $b})",
+		"$single(Numbers)",
+		"$single(Numbers, function($v){$v > 5})",
+		"$map(Missing, function($v){$v})",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			ast, err := Parse(expr)
+			if !assert.NoError(t, err) {
+				return
+			}
+			res := Compile(ast).Run(lookup.NewScope(nil, lookup.Reflect(data)))
+			assert.IsType(t, &lookup.Invalidor{}, res)
+		})
+	}
+}
+
+func TestNativeFunction(t *testing.T) {
+	twice := NativeFunction(func(scope *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+		return Call(scope, args[0].Raw(), Call(scope, args[0].Raw(), args[1]))
+	})
+	ctx := &evaluator.Context{Functions: map[string]evaluator.Function{"$twice": twice}}
+
+	ast, err := Parse("$twice(function($x){$x * 3}, 2)")
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	res := Compile(ast).Run(lookup.NewScopeWithContext(nil, lookup.Reflect(nil), ctx))
+	assert.Equal(t, float64(18), res.Raw())
+
+	// As an evaluator.Function it can be called with raw values
+	v, err := twice.Call(&sumFunc{}, []interface{}{float64(1), float64(2)})
+	if assert.NoError(t, err) {
+		assert.Equal(t, float64(3), v)
+	}
+}
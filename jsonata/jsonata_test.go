@@ -53,3 +53,43 @@ func TestJSONQueries(t *testing.T) {
 
 	assert.Equal(t, 7, runQuery(t, v, "Users[Name='sam'].Age"))
 }
+
+func TestQuotedNames(t *testing.T) {
+	data := map[string]interface{}{
+		"first name":   "Fred",
+		"content-type": "text/plain",
+		"a.b":          map[string]interface{}{"c": 1.0},
+		"Order":        map[string]interface{}{"Order ID": "o1", "items": []interface{}{map[string]interface{}{"unit price": 2.0}}},
+		"Español":      "sí",
+	}
+
+	tests := []struct {
+		expr     string
+		expected interface{}
+	}{
+		{"`first name`", "Fred"},
+		{"`content-type`", "text/plain"},
+		{"`a.b`.c", 1.0},
+		{`"a.b".c`, 1.0},
+		{`Order."Order ID"`, "o1"},
+		{`Order.'Order ID'`, "o1"},
+		{"Order.items[0].`unit price` * 2", 4.0},
+		{"Order.items[`unit price` > 1].`unit price`", 2.0},
+		{`"first name"`, "first name"},
+		{"`first name` & ' ' & `content-type`", "Fred text/plain"},
+		{"Español", "sí"},
+		{"$uppercase(Español)", "SÍ"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			assert.Equal(t, tt.expected, runQuery(t, data, tt.expr))
+		})
+	}
+
+	_, err := Parse("Order.`Order ID")
+	var jerr *Error
+	if assert.ErrorAs(t, err, &jerr) {
+		assert.Equal(t, "S0105", jerr.Code)
+	}
+}
@@ -0,0 +1,103 @@
+package jsonata
+
+import "github.com/arran4/lookup"
+
+// Limits bound the evaluation of an expression, so a runaway recursive lambda fails with U1001 rather than exhausting
+// the Go stack or running forever. A limit of 0 is no limit.
+type Limits struct {
+	MaxDepth int // how deeply lambda calls may nest, tail calls replacing the call they're made from
+	MaxSteps int // how many lambda calls, tail calls included, an evaluation may make
+}
+
+// DefaultLimits are the limits of expressions compiled by Compile.
+var DefaultLimits = Limits{MaxDepth: 1000, MaxSteps: 1000000}
+
+// Lambda is a function defined within an expression, `function($a, $b){ body }`. It closes over the scope it was
+// defined in, so the body sees the variables bound at the point of definition and, as in JSONata, evaluates against
+// the context item of that point rather than that of the caller.
+type Lambda struct {
+	Params    []string
+	Signature *Signature // validates the arguments when not nil
+	body      lookup.Runner
+	scope     *lookup.Scope
+}
+
+// tailCall is a call to a lambda made in tail position within the body of another, `$n = 0 ? 1 : $f($n - 1)`. The
+// body results in the call rather than making it, leaving the lambda invoking the body to make it in place of its
+// own, so tail recursion runs in constant stack space.
+type tailCall struct {
+	lambda *Lambda
+	caller *lookup.Scope
+	args   []lookup.Pathor
+}
+
+// Invoke runs the body in a new frame with the arguments bound to the parameters, then makes the tail call the body
+// results in, if any, and so on until one results in a value.
+func (l *Lambda) Invoke(caller *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+	e := evaluationOf(l.scope)
+	if e != nil {
+		if max := e.limits.MaxDepth; max > 0 && e.depth >= max {
+			return lookup.NewInvalidor("", errorf("U1001", "stack overflow: lambda calls nested deeper than %d", max))
+		}
+		e.depth++
+		defer func() { e.depth-- }()
+	}
+	for {
+		if e != nil {
+			if max := e.limits.MaxSteps; max > 0 && e.steps >= max {
+				return lookup.NewInvalidor("", errorf("U1001", "evaluation exceeded %d lambda calls, check for non-terminating recursion", max))
+			}
+			e.steps++
+		}
+		res := l.run(caller, args)
+		call, ok := res.Raw().(*tailCall)
+		if !ok {
+			return res
+		}
+		l, caller, args = call.lambda, call.caller, call.args
+	}
+}
+
+// run evaluates the body with the arguments bound to the parameters. Parameters without a matching argument are
+// undefined. The body is evaluated in the scope the lambda was defined in, not that of the caller, which only provides
+// the context item for arguments the signature allows to be left out.
+func (l *Lambda) run(caller *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+	if l.Signature != nil {
+		context := undefined()
+		if caller != nil && !isNilOrNilPointer(caller.Current) {
+			context = caller.Current
+		}
+		var err error
+		if args, err = l.Signature.Validate(args, context); err != nil {
+			return lookup.NewInvalidor("", err)
+		}
+	}
+	scope := l.scope.Enclose()
+	for i, name := range l.Params {
+		if i < len(args) && !isNilOrNilPointer(args[i]) {
+			scope.Bind(name, args[i])
+		} else {
+			scope.Bind(name, undefinedAt("$"+name, "argument not supplied"))
+		}
+	}
+	res := l.body.Run(scope)
+	if isNilOrNilPointer(res) {
+		return undefined()
+	}
+	return unwrapSingleton(res)
+}
+
+type lambdaRunner struct {
+	params    []string
+	signature *Signature
+	body      lookup.Runner
+}
+
+func (r *lambdaRunner) Run(scope *lookup.Scope) lookup.Pathor {
+	return lookup.Reflect(&Lambda{
+		Params:    r.params,
+		Signature: r.signature,
+		body:      r.body,
+		scope:     scope,
+	})
+}
@@ -0,0 +1,161 @@
+package jsonata
+
+import (
+	"testing"
+
+	"github.com/arran4/lookup"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLambdasAndVariables(t *testing.T) {
+	data := map[string]interface{}{
+		"Price":    float64(10),
+		"Quantity": float64(3),
+		"Items":    []interface{}{float64(1), float64(2), float64(3)},
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected interface{}
+	}{
+		{"Immediate invocation", "function($x){$x*$x}(5)", float64(25)},
+		{"Lambda symbol", "λ($x){$x+1}(1)", float64(2)},
+		{"Bind returns value", "$a := 5", float64(5)},
+		{"Chained bind", "($a := $b := 5; $b)", float64(5)},
+		{"Rebind", "( $a := 5; $a := $a + 2; $a )", float64(7)},
+		{"Block result is last expression", "(1; 2; 3)", float64(3)},
+		{"Nested block frames", "($a:=1; $b:=2; $c:=($a:=4; $a+$b); $a+$c)", float64(7)},
+		{"Inner block does not leak", "( $foo := 'outer'; ( $foo := 'inner' ); $foo )", "outer"},
+		{"Context variable", "$.Price", float64(10)},
+		{"Root variable", "Items.($$.Price)", []interface{}{float64(10), float64(10), float64(10)}},
+		{"Variables over context", "($p := Price; $q := Quantity; $p * $q)", float64(30)},
+		{"Named lambda", "($sq := function($x){$x*$x}; $sq(4))", float64(16)},
+		{"Lambda mapped over path", "($sq := function($x){$x*$x}; Items.$sq($))", []interface{}{float64(1), float64(4), float64(9)}},
+		{"Closure captures variable", "($n := 3; $add := function($x){$x+$n}; $add(1))", float64(4)},
+		{"Closure captures context", "($f := function(){Price}; Items.$f())", []interface{}{float64(10), float64(10), float64(10)}},
+		{"Lambda returning lambda", "($adder := function($a){function($b){$a+$b}}; $adder(2)(3))", float64(5)},
+		{"Lambda passed as value", "($apply := function($f, $v){$f($v)}; $apply(function($x){$x*2}, 21))", float64(42)},
+		{"Registered function as value", "($f := $substring; $f('hello', 1, 3))", "ell"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, run(t, data, tt.expr))
+		})
+	}
+}
+
+func TestUndefinedVariables(t *testing.T) {
+	for _, expr := range []string{
+		"$missing",
+		"()",
+		"function($x){$x}()",
+		"( $foo := 'defined'; ( $foo := nothing; $foo ) )",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			ast, err := Parse(expr)
+			if !assert.NoError(t, err) {
+				return
+			}
+			res := Compile(ast).Run(lookup.NewScope(nil, lookup.Reflect(map[string]interface{}{})))
+			assert.IsType(t, &lookup.Invalidor{}, res)
+		})
+	}
+}
+
+func TestScopeBindingsAreVisible(t *testing.T) {
+	ast, err := Parse("$price.foo.bar")
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	scope := lookup.NewScope(nil, lookup.Reflect(nil))
+	scope.Bind("price", lookup.Reflect(map[string]interface{}{"foo": map[string]interface{}{"bar": 45}}))
+	assert.Equal(t, 45, Compile(ast).Run(scope).Raw())
+
+	// Assignments made by the expression don't leak into the caller's frame.
+	ast, err = Parse("$price := 1")
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	Compile(ast).Run(scope)
+	v, _ := scope.Lookup("price")
+	assert.NotEqual(t, 1.0, v.Raw())
+}
+
+func TestRecursionDepthLimit(t *testing.T) {
+	ast, err := Parse("( $inf := function(){$inf()}; $inf() )")
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	res := Compile(ast).Run(lookup.NewScope(nil, lookup.Reflect(nil)))
+	assert.IsType(t, &lookup.Invalidor{}, res)
+}
+
+func TestTailCalls(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     string
+		expected interface{}
+	}{
+		{"Tail recursion", `($count := function($n, $acc){ $n = 0 ? $acc : $count($n - 1, $acc + 1) }; $count(100000, 0))`, 100000.0},
+		{"Mutual recursion", `($even := function($n){ $n = 0 ? true : $odd($n - 1) }; $odd := function($n){ $n = 0 ? false : $even($n - 1) }; $even(10001))`, false},
+		{"Last expression of a block", `($loop := function($n){ $n = 0 ? "done" : ($next := $n - 1; $loop($next)) }; $loop(5000))`, "done"},
+		{"Signature checked on each call", `($f := function($n)<n:s>{ $n = 0 ? "done" : $f($n - 1) }; $f(3000))`, "done"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, run(t, nil, tt.expr))
+		})
+	}
+}
+
+func TestRecursionLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		expr   string
+		limits Limits
+		code   string
+	}{
+		{"Nested calls", `($f := function($n){ $n = 0 ? 0 : 1 + $f($n - 1) }; $f(2000))`, DefaultLimits, "U1001"},
+		{"Nested calls within the depth", `($f := function($n){ $n = 0 ? 0 : 1 + $f($n - 1) }; $f(20))`, Limits{MaxDepth: 25}, ""},
+		{"Nested calls beyond the depth", `($f := function($n){ $n = 0 ? 0 : 1 + $f($n - 1) }; $f(30))`, Limits{MaxDepth: 25}, "U1001"},
+		{"Tail calls beyond the depth", `($f := function($n){ $n = 0 ? 0 : $f($n - 1) }; $f(30))`, Limits{MaxDepth: 25}, ""},
+		{"Tail calls beyond the steps", `($f := function($n){ $n = 0 ? 0 : $f($n - 1) }; $f(30))`, Limits{MaxSteps: 25}, "U1001"},
+		{"Non-terminating", `($inf := function(){ $inf() }; $inf())`, Limits{MaxSteps: 1000}, "U1001"},
+		{"Steps within $eval", `($f := function($n){ $n = 0 ? 0 : $f($n - 1) }; $eval("$f(30)"))`, Limits{MaxSteps: 25}, "D3121"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast, err := Parse(tt.expr)
+			if !assert.NoError(t, err) {
+				return
+			}
+			res := CompileWithLimits(ast, tt.limits).Run(lookup.NewScope(nil, undefined()))
+			if tt.code == "" {
+				assert.Nil(t, errorOf(res))
+				return
+			}
+			if jerr := errorOf(res); assert.NotNil(t, jerr) {
+				assert.Equal(t, tt.code, jerr.Code)
+			}
+		})
+	}
+}
+
+func TestParseLambdaErrors(t *testing.T) {
+	for _, expr := range []string{
+		"function($x{$x}",
+		"function(x){x}",
+		"function($x){$x",
+		"$a[1] := 3",
+		"(1; 2",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := Parse(expr)
+			assert.Error(t, err)
+		})
+	}
+}
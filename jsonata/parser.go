@@ -1,9 +1,11 @@
 package jsonata
 
 import (
-	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
 // Parse converts a JSONata expression into an AST.
@@ -18,7 +20,7 @@ func Parse(expr string) (*AST, error) {
 		return nil, err
 	}
 	if p.i < len(p.s) {
-		return nil, fmt.Errorf("unexpected token at position %d: %c", p.i, p.s[p.i])
+		return nil, p.syntaxError()
 	}
 
 	return &AST{Node: node}, nil
@@ -30,16 +32,73 @@ type parser struct {
 }
 
 // Precedence levels:
-// 1. Or
-// 2. And
-// 3. Comparison
-// 4. String Concat (&)
-// 5. Additive (+, -)
-// 6. Multiplicative (*, /, %)
-// 7. Term (path, literal, parens)
+// 0. Bind (:=)
+// 1. Conditional (? :)
+// 2. Or
+// 3. And
+// 4. Comparison, default (?:), coalescing (??) and function application (~>)
+// 5. String Concat (&)
+// 6. Additive (+, -)
+// 7. Multiplicative (*, /, %)
+// 8. Term (path, literal, parens)
 
 func (p *parser) parseExpression() (Node, error) {
-	return p.parseOr()
+	lhs, err := p.parseConditional()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.consumeWhitespace(); err != nil {
+		return nil, err
+	}
+
+	if p.checkStr(":=") {
+		v, ok := lhs.(*VariableNode)
+		if !ok {
+			return nil, p.errorAt(p.i, "S0212", "the left side of := must be a variable name (start with $)")
+		}
+		p.i += 2
+		// Right associative: `$a := $b := 5`
+		rhs, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		return &BindNode{Name: v.Name, Value: rhs}, nil
+	}
+	return lhs, nil
+}
+
+// parseConditional parses `condition ? then : else`, the else branch is optional.
+func (p *parser) parseConditional() (Node, error) {
+	cond, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.consumeWhitespace(); err != nil {
+		return nil, err
+	}
+
+	if p.peek() != '?' {
+		return cond, nil
+	}
+	p.i++
+	then, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.consumeWhitespace(); err != nil {
+		return nil, err
+	}
+	node := &ConditionNode{Condition: cond, Then: then}
+	if p.peek() == ':' && !p.checkStr(":=") {
+		p.i++
+		node.Else, err = p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return node, nil
 }
 
 func (p *parser) parseOr() (Node, error) {
@@ -108,31 +167,43 @@ func (p *parser) parseComparison() (Node, error) {
 		return nil, err
 	}
 
-	op := ""
-	if p.checkStr("!=") {
-		op = "!="
-		p.i += 2
-	} else if p.checkStr(">=") {
-		op = ">="
-		p.i += 2
-	} else if p.checkStr("<=") {
-		op = "<="
-		p.i += 2
-	} else if p.checkStr("=") {
-		op = "="
-		p.i += 1
-	} else if p.checkStr(">") {
-		op = ">"
-		p.i += 1
-	} else if p.checkStr("<") {
-		op = "<"
-		p.i += 1
-	} else if p.checkKeyword("in") {
-		op = "in"
-		p.i += 2
-	}
+	for {
+		op := ""
+		if p.checkStr("~>") {
+			op = "~>"
+			p.i += 2
+		} else if p.checkStr("!=") {
+			op = "!="
+			p.i += 2
+		} else if p.checkStr(">=") {
+			op = ">="
+			p.i += 2
+		} else if p.checkStr("<=") {
+			op = "<="
+			p.i += 2
+		} else if p.checkStr("?:") {
+			op = "?:"
+			p.i += 2
+		} else if p.checkStr("??") {
+			op = "??"
+			p.i += 2
+		} else if p.checkStr("=") {
+			op = "="
+			p.i += 1
+		} else if p.checkStr(">") {
+			op = ">"
+			p.i += 1
+		} else if p.checkStr("<") {
+			op = "<"
+			p.i += 1
+		} else if p.checkKeyword("in") {
+			op = "in"
+			p.i += 2
+		}
+		if op == "" {
+			return lhs, nil
+		}
 
-	if op != "" {
 		rhs, err := p.parseStringConcat()
 		if err != nil {
 			return nil, err
@@ -142,8 +213,10 @@ func (p *parser) parseComparison() (Node, error) {
 			Left:     lhs,
 			Right:    rhs,
 		}
+		if err := p.consumeWhitespace(); err != nil {
+			return nil, err
+		}
 	}
-	return lhs, nil
 }
 
 func (p *parser) parseStringConcat() (Node, error) {
@@ -282,85 +355,42 @@ func (p *parser) parseTerm() (Node, error) {
 		return &LiteralNode{Value: nil}, nil
 	}
 
-	// Parentheses
-	if p.peek() == '(' {
-		p.i++ // consume '('
-		expr, err := p.parseExpression()
-		if err != nil {
-			return nil, err
-		}
-		if err := p.consumeWhitespace(); err != nil {
-			return nil, err
-		}
-		if p.peek() != ')' {
-			return nil, fmt.Errorf("expected )")
-		}
-		p.i++ // consume ')'
-
-		return expr, nil
-	}
-
 	// Literal: String
 	if p.peek() == '"' || p.peek() == '\'' {
+		start := p.i
 		val, err := p.parseValue()
 		if err != nil {
 			return nil, err
 		}
-		return &LiteralNode{Value: val}, nil
+		// A string followed by a dot is the name of the first step of a path, `"Account"."Order ID"`
+		if p.peek() == '.' && !p.checkStr("..") {
+			p.i = start
+			return p.parsePath()
+		}
+		return p.parseLiteralPredicates(&LiteralNode{Value: val})
 	}
 
 	// Literal: Number
-	if isDigit(p.peek()) || p.peek() == '-' {
+	if isDigit(p.peek()) || p.peek() == '-' && p.i+1 < len(p.s) && isDigit(p.s[p.i+1]) {
 		val, err := p.parseValue()
 		if err == nil {
 			if f, err := strconv.ParseFloat(val, 64); err == nil {
-				return &LiteralNode{Value: f}, nil
+				return p.parseLiteralPredicates(&LiteralNode{Value: f})
 			}
-			return &LiteralNode{Value: val}, nil
+			return p.parseLiteralPredicates(&LiteralNode{Value: val})
 		}
 	}
 
-	// Array Constructor `[...]`
-	if p.peek() == '[' {
-		p.i++ // consume [
-		// Try to parse as list of expressions (literals for now as per previous attempt)
-		var litItems []interface{}
-
-		for {
-			if err := p.consumeWhitespace(); err != nil {
-				return nil, err
-			}
-			if p.peek() == ']' {
-				p.i++
-				break
-			}
-
-			// Recursive parse
-			item, err := p.parseExpression()
-			if err != nil {
-				return nil, err
-			}
-
-			if lit, ok := item.(*LiteralNode); ok {
-				litItems = append(litItems, lit.Value)
-			} else {
-				return nil, fmt.Errorf("complex array constructors not supported yet")
-			}
-
-			if err := p.consumeWhitespace(); err != nil {
-				return nil, err
-			}
-			if p.peek() == ',' {
-				p.i++
-			} else if p.peek() != ']' {
-				return nil, fmt.Errorf("expected , or ]")
-			}
+	if p.peek() == '-' {
+		p.i++
+		operand, err := p.parseTerm()
+		if err != nil {
+			return nil, err
 		}
-
-		return &LiteralNode{Value: litItems}, nil
+		return &NegationNode{Operand: operand}, nil
 	}
 
-	// Path
+	// Path, including blocks, constructors, lambdas and variables which may start one
 	return p.parsePath()
 }
 
@@ -372,70 +402,108 @@ func (p *parser) parsePath() (Node, error) {
 			return nil, err
 		}
 
-		// Refactored Loop Body:
 		var step Step
 		var hasStep bool
 
-		// Try Ident
-		ident, err := p.parseIdent()
-		if err == nil {
+		if p.peek() == '(' {
+			// Block or parenthesised expression
+			block, err := p.parseBlock()
+			if err != nil {
+				return nil, err
+			}
+			step = Step{SubExpr: block}
+			hasStep = true
+		} else if p.peek() == '[' {
+			array, err := p.parseArray()
+			if err != nil {
+				return nil, err
+			}
+			step = Step{SubExpr: array}
+			hasStep = true
+		} else if p.peek() == '{' {
+			object, err := p.parseObject()
+			if err != nil {
+				return nil, err
+			}
+			step = Step{SubExpr: object}
+			hasStep = true
+		} else if p.checkStr("**") {
+			p.i += 2
+			step = Step{Wildcard: "**"}
+			hasStep = true
+		} else if p.peek() == '*' {
+			p.i++
+			step = Step{Wildcard: "*"}
+			hasStep = true
+		} else if p.peek() == '%' {
+			// A `%` where an operand is expected is the parent operator, elsewhere it's the remainder
+			p.i++
+			step = Step{SubExpr: &ParentNode{}}
+			hasStep = true
+		} else if p.peek() == '/' {
+			// A `/` where an operand is expected starts a regular expression, elsewhere it's division
+			regex, err := p.parseRegex()
+			if err != nil {
+				return nil, err
+			}
+			step = Step{SubExpr: regex}
+			hasStep = true
+		} else if p.peek() == '|' {
+			transform, err := p.parseTransform()
+			if err != nil {
+				return nil, err
+			}
+			step = Step{SubExpr: transform}
+			hasStep = true
+		} else if p.checkLambda() {
+			lambda, err := p.parseLambda()
+			if err != nil {
+				return nil, err
+			}
+			step = Step{SubExpr: lambda}
+			hasStep = true
+		} else if p.peek() == '`' || p.peek() == '"' || p.peek() == '\'' {
+			// A quoted name, `` `first name` ``, and a string within a path, `Account."Order ID"`, name a field
+			name, err := p.parseQuotedName()
+			if err != nil {
+				return nil, err
+			}
+			step = Step{Name: name}
+			hasStep = true
+		} else if ident, err := p.parseIdent(); err == nil {
 			// Check for function call
 			if p.peek() == '(' {
-				p.i++ // consume '('
-				var args []Node
-				if p.peek() != ')' {
-					for {
-						if err := p.consumeWhitespace(); err != nil {
-							return nil, err
-						}
-						arg, err := p.parseExpression()
-						if err != nil {
-							return nil, err
-						}
-						args = append(args, arg)
-						if err := p.consumeWhitespace(); err != nil {
-							return nil, err
-						}
-						if p.peek() == ')' {
-							break
-						}
-						if p.peek() != ',' {
-							return nil, fmt.Errorf("expected , or )")
-						}
-						p.i++ // consume ','
-					}
+				args, err := p.parseArgs()
+				if err != nil {
+					return nil, err
 				}
-				p.i++ // consume ')'
 				step = Step{FunctionCall: &FunctionCallNode{Name: ident, Args: args}}
+			} else if strings.HasPrefix(ident, "$") {
+				step = Step{SubExpr: &VariableNode{Name: ident[1:]}}
 			} else {
 				step = Step{Name: ident}
 			}
 			hasStep = true
-		} else {
-			// Try SubExpr
-			if p.peek() == '(' {
-				p.i++
-				sub, err := p.parseExpression()
-				if err != nil {
-					return nil, err
-				}
-				if err := p.consumeWhitespace(); err != nil {
-					return nil, err
-				}
-				if p.peek() != ')' {
-					return nil, fmt.Errorf("expected )")
-				}
-				p.i++
-				step = Step{SubExpr: sub}
-				hasStep = true
+		}
+
+		// Invocation of the step's result, eg `function($x){$x}(1)` or `$f(1)(2)`
+		for hasStep && p.peek() == '(' {
+			callee := step.SubExpr
+			if step.FunctionCall != nil {
+				callee = step.FunctionCall
+			}
+			args, err := p.parseArgs()
+			if err != nil {
+				return nil, err
 			}
+			step = Step{FunctionCall: &FunctionCallNode{Callee: callee, Args: args}}
 		}
 
 		if !hasStep {
 			if len(steps) > 0 {
 				break
 			}
-			return nil, fmt.Errorf("expected identifier or (")
+			return nil, p.unexpected()
 		}
 
 		// Parse Brackets
@@ -443,99 +511,539 @@ func (p *parser) parsePath() (Node, error) {
 			return nil, err
 		}
 
-		for p.peek() == '[' {
+		if err := p.parsePredicates(&step); err != nil {
+			return nil, err
+		}
+		if err := p.parseStepBindings(&step); err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+
+		for p.peek() == '^' {
+			sort, err := p.parseSort()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, sort)
+		}
+
+		if p.i >= len(p.s) {
+			break
+		}
+
+		if p.peek() == '.' {
+			p.i++
+			// Continue to next step
+		} else {
+			// Not a dot, break (could be & or other operator handled by caller)
+			break
+		}
+	}
+
+	var node Node = &PathNode{Steps: steps}
+	// A lone expression step without brackets isn't a path, eg `$x` or `(1 + 2)`
+	if len(steps) == 1 && steps[0].SubExpr != nil && len(steps[0].Predicates) == 0 && !steps[0].usesTuples() {
+		node = steps[0].SubExpr
+	}
+
+	// Grouping, `Account.Order{ OrderID: Product.Price }`
+	if p.peek() == '{' {
+		object, err := p.parseObject()
+		if err != nil {
+			return nil, err
+		}
+		object.Input = node
+		node = object
+		if err := p.consumeWhitespace(); err != nil {
+			return nil, err
+		}
+		switch p.peek() {
+		case '[':
+			return nil, p.errorAt(p.i, "S0209", "a predicate cannot follow a grouping expression in a step")
+		case '{':
+			return nil, p.errorAt(p.i, "S0210", "each step can only have one grouping expression")
+		}
+	}
+
+	return node, nil
+}
+
+// parseLiteralPredicates parses predicates following a literal, `"Red"[$$ = "Bus"]`, into a path over the literal.
+// A literal can also be invoked, `2()`, which fails when evaluated as it isn't a function.
+func (p *parser) parseLiteralPredicates(lit *LiteralNode) (Node, error) {
+	if err := p.consumeWhitespace(); err != nil {
+		return nil, err
+	}
+	step := Step{SubExpr: lit}
+	for p.peek() == '(' {
+		callee := step.SubExpr
+		if step.FunctionCall != nil {
+			callee = step.FunctionCall
+		}
+		args, err := p.parseArgs()
+		if err != nil {
+			return nil, err
+		}
+		step = Step{FunctionCall: &FunctionCallNode{Callee: callee, Args: args}}
+	}
+	if err := p.parsePredicates(&step); err != nil {
+		return nil, err
+	}
+	if len(step.Predicates) == 0 && step.FunctionCall == nil {
+		return lit, nil
+	}
+	return &PathNode{Steps: []Step{step}}, nil
+}
+
+// parsePredicates parses any predicates, `[Price > 10]`, and indexes, `[0]`, following a step.
+func (p *parser) parsePredicates(step *Step) error {
+	for p.peek() == '[' {
+		p.i++
+		if err := p.consumeWhitespace(); err != nil {
+			return err
+		}
+		if p.peek() == ']' {
+			return p.errorAt(p.i, "S0201", "syntax error: ]")
+		}
+		predicate, err := p.parseExpression()
+		if err != nil {
+			return err
+		}
+		if err := p.consumeWhitespace(); err != nil {
+			return err
+		}
+		if p.peek() != ']' {
+			return p.expected("]")
+		}
+		p.i++
+		step.Predicates = append(step.Predicates, predicate)
+		if err := p.consumeWhitespace(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseStepBindings parses the positional, `#$i`, and context, `@$o`, variable bindings following a step along with
+// any predicates after them, which filter the results of the whole step.
+func (p *parser) parseStepBindings(step *Step) error {
+	for {
+		switch c := p.peek(); {
+		case c == '#' || c == '@':
 			p.i++
 			if err := p.consumeWhitespace(); err != nil {
-				return nil, err
+				return err
+			}
+			start := p.i
+			name, err := p.parseIdent()
+			if err != nil || !strings.HasPrefix(name, "$") || len(name) == 1 {
+				return p.errorAt(start, "S0214", "the right side of %c must be a variable name (start with $)", c)
 			}
-			if p.peek() == ']' {
-				return nil, fmt.Errorf("empty brackets")
+			if c == '#' {
+				step.Index = name[1:]
+			} else {
+				step.Focus = name[1:]
+			}
+		case c == '[' && step.usesTuples():
+			var filters Step
+			if err := p.parsePredicates(&filters); err != nil {
+				return err
 			}
+			step.Stages = append(step.Stages, filters.Predicates...)
+		default:
+			return nil
+		}
+		if err := p.consumeWhitespace(); err != nil {
+			return err
+		}
+	}
+}
 
-			if isDigit(p.peek()) || p.peek() == '-' {
-				// Index
-				start := p.i
-				if p.peek() == '-' {
-					p.i++
-				}
-				for isDigit(p.peek()) {
-					p.i++
-				}
-				numStr := p.s[start:p.i]
-				if err := p.consumeWhitespace(); err != nil {
-					return nil, err
-				}
-				if p.peek() != ']' {
-					return nil, fmt.Errorf("expected ]")
-				}
-				p.i++
+// parseSort parses an order-by step, `^(>Price, <Quantity)`. Terms are ascending unless prefixed with `>`.
+func (p *parser) parseSort() (Step, error) {
+	p.i++ // consume ^
+	if err := p.consumeWhitespace(); err != nil {
+		return Step{}, err
+	}
+	if p.peek() != '(' {
+		return Step{}, p.expected("(")
+	}
+	p.i++
+	step := Step{Sort: []SortTerm{}}
+	for {
+		if err := p.consumeWhitespace(); err != nil {
+			return Step{}, err
+		}
+		var term SortTerm
+		switch p.peek() {
+		case '>':
+			term.Descending = true
+			p.i++
+		case '<':
+			p.i++
+		}
+		expr, err := p.parseExpression()
+		if err != nil {
+			return Step{}, err
+		}
+		term.Expr = expr
+		step.Sort = append(step.Sort, term)
+		if err := p.consumeWhitespace(); err != nil {
+			return Step{}, err
+		}
+		if p.peek() == ')' {
+			p.i++
+			break
+		}
+		if p.peek() != ',' {
+			return Step{}, p.expected(")")
+		}
+		p.i++
+	}
+	if err := p.consumeWhitespace(); err != nil {
+		return Step{}, err
+	}
+	if err := p.parseStepBindings(&step); err != nil {
+		return Step{}, err
+	}
+	return step, nil
+}
 
-				num, err := strconv.Atoi(numStr)
-				if err != nil {
-					return nil, err
-				}
-				step.Index = &num
-			} else {
-				// Filter
-				field, err := p.parseIdent()
-				if err != nil {
-					return nil, err
-				}
-				if err := p.consumeWhitespace(); err != nil {
-					return nil, err
-				}
+// parseArray parses an array constructor, `[a, b, c]`.
+func (p *parser) parseArray() (*ArrayNode, error) {
+	p.i++ // consume [
+	array := &ArrayNode{}
+	for {
+		if err := p.consumeWhitespace(); err != nil {
+			return nil, err
+		}
+		if p.peek() == ']' {
+			p.i++
+			break
+		}
 
-				var op string
-				switch p.peek() {
-				case '=', '>', '<', '!':
-					op = string(p.peek())
-					p.i++
-					if p.peek() == '=' {
-						op += string(p.peek())
-						p.i++
-					}
-				default:
-					return nil, fmt.Errorf("expected operator")
-				}
+		item, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		array.Items = append(array.Items, item)
 
-				if err := p.consumeWhitespace(); err != nil {
-					return nil, err
-				}
-				val, err := p.parseValue()
+		if err := p.consumeWhitespace(); err != nil {
+			return nil, err
+		}
+		if p.peek() == ',' {
+			p.i++
+		} else if p.peek() != ']' {
+			return nil, p.expected("]")
+		}
+	}
+	return array, nil
+}
+
+// parseObject parses the pairs of an object constructor, `{ key: value, ... }`.
+func (p *parser) parseObject() (*ObjectNode, error) {
+	p.i++ // consume {
+	object := &ObjectNode{}
+	for {
+		if err := p.consumeWhitespace(); err != nil {
+			return nil, err
+		}
+		if p.peek() == '}' {
+			p.i++
+			break
+		}
+
+		key, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.consumeWhitespace(); err != nil {
+			return nil, err
+		}
+		if p.peek() != ':' {
+			return nil, p.expected(":")
+		}
+		p.i++
+		value, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		object.Pairs = append(object.Pairs, ObjectPair{Key: key, Value: value})
+
+		if err := p.consumeWhitespace(); err != nil {
+			return nil, err
+		}
+		if p.peek() == ',' {
+			p.i++
+		} else if p.peek() != '}' {
+			return nil, p.expected("}")
+		}
+	}
+	return object, nil
+}
+
+// parseArgs parses a parenthesised, comma separated argument list.
+func (p *parser) parseArgs() ([]Node, error) {
+	p.i++ // consume '('
+	var args []Node
+	if p.peek() != ')' {
+		for {
+			if err := p.consumeWhitespace(); err != nil {
+				return nil, err
+			}
+			if p.checkPlaceholder() {
+				p.i++
+				args = append(args, &PlaceholderNode{})
+			} else {
+				arg, err := p.parseExpression()
 				if err != nil {
 					return nil, err
 				}
-				if err := p.consumeWhitespace(); err != nil {
-					return nil, err
-				}
-				if p.peek() != ']' {
-					return nil, fmt.Errorf("expected ]")
-				}
-				p.i++
-
-				step.Filter = &Predicate{Field: field, Operator: op, Value: val}
+				args = append(args, arg)
 			}
 			if err := p.consumeWhitespace(); err != nil {
 				return nil, err
 			}
+			if p.peek() == ')' {
+				break
+			}
+			if p.peek() != ',' {
+				return nil, p.expected(")")
+			}
+			p.i++ // consume ','
 		}
+	}
+	p.i++ // consume ')'
+	return args, nil
+}
 
-		steps = append(steps, step)
+// checkPlaceholder reports whether the argument starting at the current position is the placeholder of a partial
+// application, a lone `?`.
+func (p *parser) checkPlaceholder() bool {
+	if p.peek() != '?' {
+		return false
+	}
+	i := p.i + 1
+	for i < len(p.s) && (p.s[i] == ' ' || p.s[i] == '\t' || p.s[i] == '\n' || p.s[i] == '\r') {
+		i++
+	}
+	return i < len(p.s) && (p.s[i] == ',' || p.s[i] == ')')
+}
 
-		if p.i >= len(p.s) {
+// parseBlock parses `( expr; expr; ... )`. Every parenthesised expression is a block so that variables bound inside
+// don't leak out of it.
+func (p *parser) parseBlock() (Node, error) {
+	p.i++ // consume '('
+	block := &BlockNode{}
+	for {
+		if err := p.consumeWhitespace(); err != nil {
+			return nil, err
+		}
+		if p.peek() == ')' {
+			p.i++
 			break
 		}
+		expr, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		block.Expressions = append(block.Expressions, expr)
+		if err := p.consumeWhitespace(); err != nil {
+			return nil, err
+		}
+		if p.peek() == ';' {
+			p.i++
+		} else if p.peek() != ')' {
+			return nil, p.expected(")")
+		}
+	}
+	return block, nil
+}
 
-		if p.peek() == '.' {
+// checkLambda reports whether a lambda definition starts at the current position.
+func (p *parser) checkLambda() bool {
+	if p.checkStr("λ") {
+		return true
+	}
+	if !p.checkKeyword("function") {
+		return false
+	}
+	i := p.i + len("function")
+	for i < len(p.s) && (p.s[i] == ' ' || p.s[i] == '\t' || p.s[i] == '\n' || p.s[i] == '\r') {
+		i++
+	}
+	return i < len(p.s) && p.s[i] == '('
+}
+
+// parseLambda parses `function($a, $b){ body }`, `λ` may be used in place of `function`. A signature may follow the
+// parameters, `function($a, $b)<nn:n>{ body }`.
+func (p *parser) parseLambda() (Node, error) {
+	if p.checkStr("λ") {
+		p.i += len("λ")
+	} else {
+		p.i += len("function")
+	}
+	if err := p.consumeWhitespace(); err != nil {
+		return nil, err
+	}
+	if p.peek() != '(' {
+		return nil, p.expected("(")
+	}
+	p.i++
+
+	lambda := &LambdaNode{}
+	for {
+		if err := p.consumeWhitespace(); err != nil {
+			return nil, err
+		}
+		if p.peek() == ')' {
 			p.i++
-			// Continue to next step
-		} else {
-			// Not a dot, break (could be & or other operator handled by caller)
 			break
 		}
+		if p.peek() != '$' {
+			return nil, p.errorAt(p.i, "S0208", "parameter %s of function definition must be a variable name (start with $)", tokenAt(p.s, p.i))
+		}
+		ident, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+		lambda.Params = append(lambda.Params, ident[1:])
+		if err := p.consumeWhitespace(); err != nil {
+			return nil, err
+		}
+		if p.peek() == ',' {
+			p.i++
+		} else if p.peek() != ')' {
+			return nil, p.expected(")")
+		}
 	}
 
-	return &PathNode{Steps: steps}, nil
+	if err := p.consumeWhitespace(); err != nil {
+		return nil, err
+	}
+	if p.peek() == '<' {
+		end := closingBracket(p.s, p.i)
+		if end < 0 {
+			return nil, p.errorAt(p.i, "S0401", "unterminated function signature")
+		}
+		sig, err := ParseSignature(p.s[p.i : end+1])
+		if err != nil {
+			err.(*Error).Position = characterOffset(p.s, p.i)
+			err.(*Error).Token = p.s[p.i : end+1]
+			return nil, err
+		}
+		lambda.Signature = sig
+		p.i = end + 1
+		if err := p.consumeWhitespace(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek() != '{' {
+		return nil, p.expected("{")
+	}
+	p.i++
+	body, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.consumeWhitespace(); err != nil {
+		return nil, err
+	}
+	if p.peek() != '}' {
+		return nil, p.expected("}")
+	}
+	p.i++
+	lambda.Body = body
+	return lambda, nil
+}
+
+// parseTransform parses the transform operator, `|pattern|update|` or `|pattern|update, delete|`.
+func (p *parser) parseTransform() (*TransformNode, error) {
+	p.i++ // consume '|'
+	pattern, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectTransformBar(); err != nil {
+		return nil, err
+	}
+	update, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	node := &TransformNode{Pattern: pattern, Update: update}
+	if err := p.consumeWhitespace(); err != nil {
+		return nil, err
+	}
+	if p.peek() == ',' {
+		p.i++
+		if node.Delete, err = p.parseExpression(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expectTransformBar(); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+func (p *parser) expectTransformBar() error {
+	if err := p.consumeWhitespace(); err != nil {
+		return err
+	}
+	if p.peek() != '|' {
+		return p.expected("|")
+	}
+	p.i++
+	return nil
+}
+
+// parseRegex parses a regular expression literal, `/pattern/flags`. The pattern ends at the first `/` that isn't
+// escaped or within brackets, and may be followed by the flags `i` for case insensitive and `m` for multi-line
+// matching.
+func (p *parser) parseRegex() (*RegexNode, error) {
+	p.i++ // consume /
+	start := p.i
+	depth := 0
+	for ; p.i < len(p.s); p.i++ {
+		c := p.s[p.i]
+		if c == '\\' {
+			p.i++
+			continue
+		}
+		if c == '/' && depth == 0 {
+			break
+		}
+		switch c {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		}
+	}
+	if p.i >= len(p.s) {
+		return nil, p.errorAt(start-1, "S0302", "no terminating / in regular expression")
+	}
+	pattern := p.s[start:p.i]
+	if pattern == "" {
+		return nil, p.errorAt(start-1, "S0301", "empty regular expressions are not allowed")
+	}
+	p.i++ // consume /
+	flags := ""
+	for p.i < len(p.s) && (p.s[p.i] == 'i' || p.s[p.i] == 'm') {
+		if !strings.ContainsRune(flags, rune(p.s[p.i])) {
+			flags += string(p.s[p.i])
+		}
+		p.i++
+	}
+	if flags != "" {
+		pattern = "(?" + flags + ")" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, p.errorAt(start-1, "S0201", "invalid regular expression: %w", err)
+	}
+	return &RegexNode{Regexp: re}, nil
 }
 
 func (p *parser) consumeWhitespace() error {
@@ -546,12 +1054,13 @@ func (p *parser) consumeWhitespace() error {
 			continue
 		}
 		if c == '/' && p.i+1 < len(p.s) && p.s[p.i+1] == '*' {
+			start := p.i
 			p.i += 2
 			for p.i+1 < len(p.s) && (p.s[p.i] != '*' || p.s[p.i+1] != '/') {
 				p.i++
 			}
 			if p.i+1 >= len(p.s) {
-				return fmt.Errorf("unclosed comment")
+				return p.errorAt(start, "S0106", "unclosed comment")
 			}
 			p.i += 2
 			continue
@@ -565,30 +1074,52 @@ func (p *parser) parseIdent() (string, error) {
 	start := p.i
 	for p.i < len(p.s) {
 		c := p.s[p.i]
-		if c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (p.i > start && c >= '0' && c <= '9') {
+		if isIdentChar(c) && (p.i > start || !isDigit(c)) {
 			p.i++
 			continue
 		}
 		break
 	}
 	if start == p.i {
-		return "", fmt.Errorf("expected identifier at %d", start)
+		return "", p.unexpected()
 	}
 	return p.s[start:p.i], nil
 }
 
+// parseQuotedName parses a field name in backquotes, which may contain any character but a backquote, or a string
+// literal in a path.
+func (p *parser) parseQuotedName() (string, error) {
+	if p.peek() != '`' {
+		return p.parseValue()
+	}
+	end := strings.IndexByte(p.s[p.i+1:], '`')
+	if end < 0 {
+		return "", p.errorAt(p.i, "S0105", "quoted property name must be terminated with a backquote (`)")
+	}
+	name := p.s[p.i+1 : p.i+1+end]
+	p.i += end + 2
+	return name, nil
+}
+
 func (p *parser) parseValue() (string, error) {
 	if p.peek() == '\'' || p.peek() == '"' {
 		quote := p.s[p.i]
 		p.i++
 		start := p.i
 		for p.i < len(p.s) && p.s[p.i] != quote {
+			if p.s[p.i] == '\\' {
+				p.i++ // skip the escaped character
+			}
 			p.i++
 		}
 		if p.i >= len(p.s) {
-			return "", fmt.Errorf("unterminated string")
+			return "", p.errorAt(start-1, "S0101", "string literal must be terminated by a matching quote")
+		}
+		val, err := unescapeString(p.s[start:p.i])
+		if err != nil {
+			err.Position = characterOffset(p.s, start)
+			return "", err
 		}
-		val := p.s[start:p.i]
 		p.i++ // consume closing quote
 		return val, nil
 	}
@@ -615,12 +1146,169 @@ func (p *parser) parseValue() (string, error) {
 			break
 		}
 	}
+	// Exponent, `1e-7`
+	if p.i > start && (p.peek() == 'e' || p.peek() == 'E') {
+		end := p.i + 1
+		if end < len(p.s) && (p.s[end] == '+' || p.s[end] == '-') {
+			end++
+		}
+		if end < len(p.s) && isDigit(p.s[end]) {
+			for end < len(p.s) && isDigit(p.s[end]) {
+				end++
+			}
+			p.i = end
+		}
+	}
 	if start == p.i {
-		return "", fmt.Errorf("expected value")
+		return "", p.unexpected()
 	}
 	return p.s[start:p.i], nil
 }
 
+// unescapeString decodes the JSON escape sequences in the body of a string literal, including `\uXXXX` escapes of
+// UTF-16 surrogate pairs.
+func unescapeString(s string) (string, *Error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		if i >= len(s) {
+			return "", errorf("S0103", "unterminated escape sequence")
+		}
+		switch s[i] {
+		case '"', '\'', '\\', '/':
+			b.WriteByte(s[i])
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'u':
+			r, ok := parseHex4(s, i+1)
+			if !ok {
+				return "", errorf("S0104", "the escape sequence \\u must be followed by 4 hex digits")
+			}
+			i += 4
+			if utf16.IsSurrogate(r) && i+6 < len(s) && s[i+1] == '\\' && s[i+2] == 'u' {
+				if r2, ok := parseHex4(s, i+3); ok {
+					if d := utf16.DecodeRune(r, r2); d != utf8.RuneError {
+						r = d
+						i += 6
+					}
+				}
+			}
+			b.WriteRune(r)
+		default:
+			return "", errorf("S0103", "unsupported escape sequence: \\%c", s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// parseHex4 parses the four hex digits of a `\u` escape starting at s[i].
+func parseHex4(s string, i int) (rune, bool) {
+	if i+4 > len(s) {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(s[i:i+4], 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	return rune(n), true
+}
+
+// errorAt creates an Error found at byte offset i of the expression, taking the token there as the offending one.
+func (p *parser) errorAt(i int, code string, format string, args ...interface{}) *Error {
+	err := errorf(code, format, args...)
+	err.Position = characterOffset(p.s, i)
+	err.Token = tokenAt(p.s, i)
+	return err
+}
+
+// unexpected reports the token at the current position, where an operand was expected.
+func (p *parser) unexpected() *Error {
+	if p.i >= len(p.s) {
+		return p.errorAt(p.i, "S0207", "unexpected end of expression")
+	}
+	if p.s[p.i] == '`' && strings.IndexByte(p.s[p.i+1:], '`') < 0 {
+		return p.errorAt(p.i, "S0105", "quoted property name must be terminated with a backquote (`)")
+	}
+	token := tokenAt(p.s, p.i)
+	if strings.Contains(operatorSymbols, token[:1]) {
+		return p.errorAt(p.i, "S0211", "the symbol %s cannot be used as a unary operator", token)
+	}
+	return p.errorAt(p.i, "S0204", "unknown operator: %s", token)
+}
+
+// syntaxError reports the token at the current position, which can't follow what came before it.
+func (p *parser) syntaxError() *Error {
+	c := p.s[p.i]
+	if !strings.ContainsRune(operatorSymbols+"\"'`", rune(c)) && !isIdentChar(c) {
+		return p.errorAt(p.i, "S0204", "unknown operator: %s", tokenAt(p.s, p.i))
+	}
+	return p.errorAt(p.i, "S0201", "syntax error: %s", tokenAt(p.s, p.i))
+}
+
+// expected reports that want was expected at the current position.
+func (p *parser) expected(want string) *Error {
+	if p.i >= len(p.s) {
+		return p.errorAt(p.i, "S0203", "expected %s before end of expression", want)
+	}
+	return p.errorAt(p.i, "S0202", "expected %s, got %s", want, tokenAt(p.s, p.i))
+}
+
+// operatorSymbols are the characters operators are made of.
+const operatorSymbols = ".[]{}(),@#;:?+-*/%|=<>^&!~"
+
+// twoCharOperators are the operators of two characters, which tokenAt takes as a single token.
+var twoCharOperators = []string{":=", "!=", "<=", ">=", "~>", "..", "**", "?:", "??"}
+
+// tokenAt finds the token starting at byte offset i of s for reporting in an error.
+func tokenAt(s string, i int) string {
+	if i >= len(s) {
+		return ""
+	}
+	switch c := s[i]; {
+	case c == '"' || c == '\'' || c == '`':
+		end := strings.IndexByte(s[i+1:], c)
+		if end < 0 {
+			return s[i:]
+		}
+		return s[i : i+end+2]
+	case isIdentChar(c):
+		end := i
+		for end < len(s) && isIdentChar(s[end]) {
+			end++
+		}
+		return s[i:end]
+	}
+	for _, op := range twoCharOperators {
+		if strings.HasPrefix(s[i:], op) {
+			return op
+		}
+	}
+	_, size := utf8.DecodeRuneInString(s[i:])
+	return s[i : i+size]
+}
+
+// isIdentChar is true of the bytes of a name. As in JSONata any character other than an operator or whitespace, so
+// any byte of a multibyte character, can be part of one, `$lowercase(Español)`.
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || isDigit(c) || c >= utf8.RuneSelf
+}
+
 func (p *parser) peek() byte {
 	if p.i >= len(p.s) {
 		return 0
@@ -641,12 +1329,7 @@ func (p *parser) checkKeyword(s string) bool {
 	if next >= len(p.s) {
 		return true
 	}
-	c := p.s[next]
-	// ident chars: a-z, A-Z, 0-9, _, $
-	if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' || c == '$' {
-		return false
-	}
-	return true
+	return !isIdentChar(p.s[next])
 }
 
 func (p *parser) checkStr(s string) bool {
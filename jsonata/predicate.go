@@ -0,0 +1,138 @@
+package jsonata
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/arran4/go-evaluator"
+	"github.com/arran4/lookup"
+)
+
+// predicateRunner filters the context sequence by a `[expr]` predicate. The expression is evaluated against each item,
+// when it results in a number, or an array of numbers, the items at those indexes are kept, negative indexes counting
+// back from the end of the sequence. Any other result keeps the items for which it's true by the rules of $boolean.
+type predicateRunner struct {
+	expr  lookup.Runner
+	index *float64 // set when the predicate is a numeric literal so it selects a single item without evaluation
+}
+
+func (r *predicateRunner) Run(scope *lookup.Scope) lookup.Pathor {
+	curr := scope.Current
+	if IsUndefined(curr) || curr.IsNil() {
+		return undefinedAt(lookup.ExtractPath(curr), "nothing found")
+	}
+
+	items := []interface{}{curr.Raw()}
+	if curr.IsSlice() {
+		items, _ = curr.AsSlice()
+	}
+
+	if r.index != nil {
+		i := sequenceIndex(*r.index, len(items))
+		if i < 0 || i >= len(items) {
+			return undefinedAt(lookup.ExtractPath(curr), fmt.Sprintf("index %v out of range", *r.index))
+		}
+		return lookup.Reflect(items[i])
+	}
+
+	var results []interface{}
+	for i, item := range items {
+		res := r.expr.Run(scope.Nest(lookup.Reflect(item)))
+		if errorOf(res) != nil {
+			return res
+		}
+		if selects(res, i, len(items)) {
+			results = append(results, item)
+		}
+	}
+	if len(results) == 0 {
+		return undefinedAt(lookup.ExtractPath(curr), "nothing found")
+	}
+	return lookup.Reflect(results)
+}
+
+// selects reports whether a predicate resulting in res keeps the item at position i of a sequence of n items.
+func selects(res lookup.Pathor, i, n int) bool {
+	if IsUndefined(res) {
+		return false
+	}
+	if indexes, ok := numbersOf(res.Raw()); ok {
+		for _, index := range indexes {
+			if sequenceIndex(index, n) == i {
+				return true
+			}
+		}
+		return false
+	}
+	return toBoolean(res.Raw())
+}
+
+// sequenceIndex converts a JSONata index, which is rounded down and may count back from the end, into a position.
+func sequenceIndex(index float64, length int) int {
+	i := int(math.Floor(index))
+	if i < 0 {
+		i += length
+	}
+	return i
+}
+
+// numbersOf returns the value as a list of numbers when it's a number or a non-empty array of numbers.
+func numbersOf(v interface{}) ([]float64, bool) {
+	if f, ok := lookup.ToFloat(v); ok {
+		return []float64{f}, true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array || rv.Len() == 0 {
+		return nil, false
+	}
+	numbers := make([]float64, rv.Len())
+	for i := range numbers {
+		f, ok := lookup.ToFloat(rv.Index(i).Interface())
+		if !ok {
+			return nil, false
+		}
+		numbers[i] = f
+	}
+	return numbers, true
+}
+
+// toBoolean casts a value to a boolean following JSONata's $boolean. Empty strings, zero, null, empty arrays and
+// objects and functions are false, an array is true when any of its members are.
+func toBoolean(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case Callable, evaluator.Function:
+		return false
+	}
+	if f, ok := lookup.ToFloat(v); ok {
+		return f != 0
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if toBoolean(rv.Index(i).Interface()) {
+				return true
+			}
+		}
+		return false
+	case reflect.Map:
+		return rv.Len() > 0
+	case reflect.Func:
+		return false
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return false
+		}
+		return toBoolean(rv.Elem().Interface())
+	case reflect.String:
+		return rv.Len() > 0
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package jsonata
+
+import (
+	"testing"
+
+	"github.com/arran4/lookup"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPredicates(t *testing.T) {
+	data := map[string]interface{}{
+		"Orders": []interface{}{
+			map[string]interface{}{"ID": "o1", "Price": float64(5), "Qty": float64(1), "Tags": []interface{}{"a", "b"}},
+			map[string]interface{}{"ID": "o2", "Price": float64(20), "Qty": float64(2), "Tags": []interface{}{}},
+			map[string]interface{}{"ID": "o3", "Price": float64(15), "Qty": float64(9), "Tags": []interface{}{"c"}},
+		},
+		"Address": map[string]interface{}{"City": "Winchester"},
+		"Numbers": []interface{}{float64(10), float64(20), float64(30), float64(40)},
+		"Nested":  []interface{}{float64(1), float64(2), []interface{}{float64(3), float64(4)}},
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected interface{}
+	}{
+		{"Index", "Numbers[1]", float64(20)},
+		{"Negative index", "Numbers[-1]", float64(40)},
+		{"Fractional index rounds down", "Numbers[1.9]", float64(20)},
+		{"Computed index", "Numbers[1 + 1]", float64(30)},
+		{"Array of indexes", "Numbers[[0, 2]]", []interface{}{float64(10), float64(30)}},
+		{"Array of negative indexes", "Numbers[[-1, 0]]", []interface{}{float64(10), float64(40)}},
+		{"Context comparison", "Numbers[$ > 15]", []interface{}{float64(20), float64(30), float64(40)}},
+		{"Comparison", "Orders[Price > 10].ID", []interface{}{"o2", "o3"}},
+		{"Boolean operators", "Orders[Price > 10 and Qty < 5].ID", "o2"},
+		{"Function call", "Orders[$count(Tags) > 1].ID", "o1"},
+		{"Truthy field", "Orders[Tags].ID", []interface{}{"o1", "o3"}},
+		{"Multiple predicates", "Orders[Price > 1][Qty > 1][0].ID", "o2"},
+		{"Predicate on object", "Address[City = 'Winchester'].City", "Winchester"},
+		{"Index of nested array", "Nested[-1][-1]", float64(4)},
+		{"Index per step item", "Orders.Tags[0]", []interface{}{"a", "c"}},
+		{"Index on expression result", "(Orders.Tags)[1]", "b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, run(t, data, tt.expr))
+		})
+	}
+}
+
+func TestPredicatesWithoutMatch(t *testing.T) {
+	data := map[string]interface{}{
+		"Numbers": []interface{}{float64(10), float64(20)},
+	}
+	for _, expr := range []string{
+		"Numbers[2]",
+		"Numbers[-3]",
+		"Numbers[$ > 100]",
+		"Missing[0]",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			ast, err := Parse(expr)
+			if !assert.NoError(t, err) {
+				return
+			}
+			res := Compile(ast).Run(lookup.NewScope(nil, lookup.Reflect(data)))
+			assert.IsType(t, &lookup.Invalidor{}, res)
+		})
+	}
+}
+
+func TestToBoolean(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected bool
+	}{
+		{nil, false},
+		{true, true},
+		{"", false},
+		{"false", true},
+		{float64(0), false},
+		{-1, true},
+		{[]interface{}{}, false},
+		{[]interface{}{false, float64(0)}, false},
+		{[]interface{}{false, "x"}, true},
+		{map[string]interface{}{}, false},
+		{map[string]interface{}{"a": false}, true},
+		{&Lambda{}, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, toBoolean(tt.value), "%#v", tt.value)
+	}
+}
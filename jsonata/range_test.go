@@ -99,3 +99,38 @@ func TestRangePrecedence(t *testing.T) {
 		t.Errorf("Expected string, got %T: %v", val, val)
 	}
 }
+
+func TestRangeLimit(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected float64
+		code     string
+	}{
+		{expr: `$count([1..200000])`, expected: 200000},
+		{expr: `$count([1..10000000])`, expected: 10000000},
+		{expr: `[1..10000001]`, code: "D2014"},
+		{expr: `$count([0..10000000])`, code: "D2014"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			node, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			res := Compile(node).Run(&lookup.Scope{})
+			if tt.code != "" {
+				if jerr := errorOf(res); jerr == nil || jerr.Code != tt.code {
+					t.Fatalf("Expected error %s, got %v", tt.code, res.Raw())
+				}
+				return
+			}
+			if jerr := errorOf(res); jerr != nil {
+				t.Fatalf("Runtime error: %v", jerr)
+			}
+			if f, ok := lookup.ToFloat(res.Raw()); !ok || f != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, res.Raw())
+			}
+		})
+	}
+}
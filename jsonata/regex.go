@@ -0,0 +1,165 @@
+package jsonata
+
+import (
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/arran4/lookup"
+)
+
+// Matcher is the value of a regular expression literal, `/ab+/i`. As in JSONata it's a function: called with a string
+// it results in an object describing the first match, with the matched string, its start and end, the captured
+// groups and a next function returning the following match, or undefined when there is no match.
+//
+// Functions taking a pattern, $contains, $split, $replace and $match, accept a Matcher, a Go *regexp.Regexp or any
+// function following the same protocol, so custom matchers can be supplied as lambdas or NativeFunctions.
+type Matcher struct {
+	Regexp *regexp.Regexp
+}
+
+// Invoke matches the regular expression against the string argument.
+func (m *Matcher) Invoke(_ *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+	if len(args) == 0 || IsUndefined(args[0]) {
+		return undefined()
+	}
+	str, ok := args[0].Raw().(string)
+	if !ok {
+		return invalid("T0410", "a regular expression can only be matched against a string")
+	}
+	return m.matchFrom(str, 0)
+}
+
+// matchFrom results in the match object for the first match at or after the byte offset.
+func (m *Matcher) matchFrom(str string, offset int) lookup.Pathor {
+	if offset > len(str) {
+		return undefined()
+	}
+	loc := m.Regexp.FindStringSubmatchIndex(str[offset:])
+	if loc == nil {
+		return undefined()
+	}
+	for i := range loc {
+		if loc[i] >= 0 {
+			loc[i] += offset
+		}
+	}
+	match := submatches(str, loc)
+	groups := make([]interface{}, len(match.groups))
+	for i, g := range match.groups {
+		groups[i] = g
+	}
+	next := loc[1]
+	if loc[0] == loc[1] {
+		// Step over an empty match so next makes progress
+		_, size := utf8.DecodeRuneInString(str[next:])
+		next += size
+		if size == 0 {
+			next++
+		}
+	}
+	return lookup.Reflect(map[string]interface{}{
+		"match":  match.match,
+		"start":  float64(utf8.RuneCountInString(str[:match.start])),
+		"end":    float64(utf8.RuneCountInString(str[:match.end])),
+		"groups": groups,
+		"next": NativeFunction(func(_ *lookup.Scope, _ []lookup.Pathor) lookup.Pathor {
+			return m.matchFrom(str, next)
+		}),
+	})
+}
+
+// regexMatch is a match of a pattern in a string, start and end are byte offsets into the string.
+type regexMatch struct {
+	match      string
+	start, end int
+	groups     []string // captured groups, empty when a group didn't participate in the match
+}
+
+// submatches converts a location returned by regexp's FindStringSubmatchIndex to a regexMatch.
+func submatches(str string, loc []int) regexMatch {
+	m := regexMatch{match: str[loc[0]:loc[1]], start: loc[0], end: loc[1], groups: []string{}}
+	for i := 2; i < len(loc); i += 2 {
+		if loc[i] < 0 {
+			m.groups = append(m.groups, "")
+		} else {
+			m.groups = append(m.groups, str[loc[i]:loc[i+1]])
+		}
+	}
+	return m
+}
+
+// findMatches finds at most limit matches of pattern in str, a negative limit finds all of them. ok is false when
+// pattern isn't a regular expression or matcher function. Matcher functions defined within an expression carry their
+// own scope, other functions are called with an empty one.
+func findMatches(pattern interface{}, str string, limit int) (matches []regexMatch, ok bool, err error) {
+	var re *regexp.Regexp
+	switch p := pattern.(type) {
+	case *regexp.Regexp:
+		re = p
+	case *Matcher:
+		re = p.Regexp
+	default:
+		if !isFunction(pattern) {
+			return nil, false, nil
+		}
+		matches, err := callMatcher(pattern, str, limit)
+		return matches, true, err
+	}
+	for _, loc := range re.FindAllStringSubmatchIndex(str, limit) {
+		matches = append(matches, submatches(str, loc))
+	}
+	return matches, true, nil
+}
+
+// callMatcher finds matches by calling a matcher function and following the next function of each match.
+func callMatcher(fn interface{}, str string, limit int) ([]regexMatch, error) {
+	var matches []regexMatch
+	res := Call(&lookup.Scope{}, fn, lookup.Reflect(str))
+	for !IsUndefined(res) && (limit < 0 || len(matches) < limit) {
+		if err := errorOf(res); err != nil {
+			return nil, err
+		}
+		keys, values := entriesOf(res)
+		fields := map[string]interface{}{}
+		for i, key := range keys {
+			fields[key] = values[i]
+		}
+		match, ok := fields["match"].(string)
+		start, startOk := lookup.ToFloat(fields["start"])
+		end, endOk := lookup.ToFloat(fields["end"])
+		if !ok || !startOk || !endOk {
+			return nil, errorf("T1010", "a matcher function must result in an object with match, start and end")
+		}
+		m := regexMatch{match: match, start: runeOffset(str, int(start)), end: runeOffset(str, int(end)), groups: []string{}}
+		if m.start > m.end {
+			return nil, errorf("T1010", "a matcher function must result in a match ending after it starts")
+		}
+		if fields["groups"] != nil {
+			for _, g := range sequenceOf(lookup.Reflect(fields["groups"])) {
+				s, _ := g.(string)
+				m.groups = append(m.groups, s)
+			}
+		}
+		matches = append(matches, m)
+		next, ok := fields["next"]
+		if !ok || !isFunction(next) {
+			break
+		}
+		res = Call(&lookup.Scope{}, next)
+	}
+	return matches, nil
+}
+
+// runeOffset converts an offset in characters, as matcher functions give, to a byte offset into str.
+func runeOffset(str string, n int) int {
+	if n <= 0 {
+		return 0
+	}
+	for i := range str {
+		if n == 0 {
+			return i
+		}
+		n--
+	}
+	return len(str)
+}
@@ -0,0 +1,126 @@
+package jsonata
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/arran4/lookup"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRegex(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		pattern string
+		wantErr bool
+	}{
+		{name: "Pattern", expr: "/ab+/", pattern: "ab+"},
+		{name: "Flags", expr: "/ab+/im", pattern: "(?im)ab+"},
+		{name: "Escaped slash", expr: `/a\/b/`, pattern: `a\/b`},
+		{name: "Slash within brackets", expr: "/[/]+/", pattern: "[/]+"},
+		{name: "Empty", expr: "//", wantErr: true},
+		{name: "Unterminated", expr: "/ab", wantErr: true},
+		{name: "Invalid", expr: "/(ab/", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast, err := Parse(tt.expr)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			if assert.NoError(t, err) && assert.IsType(t, &RegexNode{}, ast.Node) {
+				assert.Equal(t, tt.pattern, ast.Node.(*RegexNode).Regexp.String())
+			}
+		})
+	}
+}
+
+func TestParseDivisionIsNotRegex(t *testing.T) {
+	ast, err := Parse("a / b / 2")
+	if assert.NoError(t, err) {
+		assert.IsType(t, &BinaryNode{}, ast.Node)
+	}
+	assert.Equal(t, float64(3), run(t, map[string]interface{}{"a": 12.0, "b": 2.0}, "a / b / 2"))
+	assert.Equal(t, float64(2), run(t, nil, "4 /* comment */ / 2"))
+}
+
+func TestRegexFunctions(t *testing.T) {
+	data := map[string]interface{}{
+		"Names": []interface{}{"Bowler Hat", "Trilby hat", "Cloak"},
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected interface{}
+	}{
+		{"Contains", `$contains("ababbx", /ab+x/)`, true},
+		{"Contains missing", `$contains("ababbx", /ax+/)`, false},
+		{"Predicate", `Names[$contains($, /hat/)]`, "Trilby hat"},
+		{"Predicate case insensitive", `Names[$contains($, /hat/i)]`, []interface{}{"Bowler Hat", "Trilby hat"}},
+		{"Split", `$split("ababbxabbcc", /b+/)`, []interface{}{"a", "a", "xa", "cc"}},
+		{"Split with limit", `$split("ababbxabbcc", /b+/, 2)`, []interface{}{"a", "a"}},
+		{"Replace", `$replace("ababbxabbcc", /b+/, "yy")`, "ayyayyxayycc"},
+		{"Replace with limit", `$replace("ababbxabbcc", /b+/, "yy", 2)`, "ayyayyxabbcc"},
+		{"Replace groups", `$replace("John Smith", /(\w+)\s(\w+)/, "$2, $1")`, "Smith, John"},
+		{"Replace with function", `$replace("a1 b22", /\d+/, function($m) { $string($number($m.match) * 2) })`, "a2 b44"},
+		{"Replace with function index", `$replace("xyz", /y/, function($m) { $string($m.index) })`, "x1z"},
+		{"Match", `$match("a1 b2", /([a-z])\d/).groups`, []interface{}{"a", "b"}},
+		{"Matcher invoked", `/b(\d)/("ab1b2").[match, start, end, groups]`, []interface{}{"b1", float64(1), float64(3), "1"}},
+		{"Matcher next", `($next := /b(\d)/("ab1b2").next; $next().match)`, "b2"},
+		{"Matcher no match", `/x/("ab")`, nil},
+		{"Matcher type", `$type(/x/)`, "function"},
+		{"Matcher variable", `($re := /a+/; $split("baab", $re))`, []interface{}{"b", "b"}},
+		{"Custom matcher", `(
+			$x := function($s) {
+				$contains($s, "x") ? (
+					$i := $length($substringBefore($s, "x"));
+					{"match": "x", "start": $i, "end": $i + 1, "groups": []}
+				)
+			};
+			$replace("axb", $x, "-")
+		)`, "a-b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, run(t, data, tt.expr))
+		})
+	}
+}
+
+func TestRegexFunctionErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"Replace zero length match", `$replace("abc", /x*/, "y")`},
+		{"Replace function not resulting in a string", `$replace("abc", /b/, function($m) { 42 })`},
+		{"Split with non matcher function", `$split("some text", $uppercase)`},
+		{"Matcher of number", `/a/(1)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast, err := Parse(tt.expr)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.IsType(t, &lookup.Invalidor{}, Compile(ast).Run(lookup.NewScope(nil, lookup.Reflect(nil))))
+		})
+	}
+}
+
+func TestMatcherWithGoRegexp(t *testing.T) {
+	m := &Matcher{Regexp: regexp.MustCompile(`é(\w)`)}
+	res := m.Invoke(nil, []lookup.Pathor{lookup.Reflect("aébéc")}).Raw().(map[string]interface{})
+	assert.Equal(t, "éb", res["match"])
+	assert.Equal(t, float64(1), res["start"])
+	assert.Equal(t, float64(3), res["end"])
+	next := Call(nil, res["next"]).Raw().(map[string]interface{})
+	assert.Equal(t, "éc", next["match"])
+	assert.Equal(t, float64(3), next["start"])
+}
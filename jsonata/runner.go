@@ -2,24 +2,60 @@ package jsonata
 
 import (
 	"fmt"
+	"math"
 	"reflect"
+	"strings"
+	"time"
 
 	"github.com/arran4/go-evaluator"
 	"github.com/arran4/lookup"
 )
 
 type jsonataRunner struct {
-	inner lookup.Runner
+	inner  lookup.Runner
+	limits Limits
+}
+
+// evaluationKey binds the state of a run in the outermost frame of its scope. It isn't a valid variable name so
+// expressions can't reach it.
+const evaluationKey = "#evaluation"
+
+// evaluation is the state shared by everything evaluated during a single run of an expression.
+type evaluation struct {
+	limits Limits
+	depth  int       // current depth of lambda invocations
+	steps  int       // lambda invocations so far, including tail calls
+	now    time.Time // the time the evaluation started, used by $now and $millis
+}
+
+func evaluationOf(scope *lookup.Scope) *evaluation {
+	if v, ok := scope.Lookup(evaluationKey); ok {
+		if e, ok := v.Raw().(*evaluation); ok {
+			return e
+		}
+	}
+	return nil
 }
 
 func (r *jsonataRunner) Run(scope *lookup.Scope) lookup.Pathor {
+	if scope == nil {
+		scope = &lookup.Scope{}
+	}
+	// Variables assigned by the expression go into their own frame leaving the caller's bindings untouched.
+	scope = scope.Enclose()
+	scope.Bind(evaluationKey, lookup.Reflect(&evaluation{limits: r.limits, now: scope.Now()}))
 	res := r.inner.Run(scope)
 	if isNilOrNilPointer(res) {
-		return lookup.NewInvalidor("", fmt.Errorf("result is nil"))
+		return undefined()
 	}
 
-	// Singleton unwrapping: JSONata unwraps single-element arrays resulting from path expressions.
-	if res.IsSlice() {
+	return unwrapSingleton(res)
+}
+
+// unwrapSingleton unwraps a sequence of one item, as JSONata does with single-element arrays resulting from path
+// expressions. Arrays built by constructors or returned by functions are kept as they are.
+func unwrapSingleton(res lookup.Pathor) lookup.Pathor {
+	if res.IsSlice() && !isConstructedArray(res) && !isArrayValue(res) {
 		slice, _ := res.AsSlice()
 		if len(slice) == 1 {
 			return lookup.Reflect(slice[0])
@@ -40,6 +76,7 @@ func (r *rootRunner) Run(scope *lookup.Scope) lookup.Pathor {
 
 // jsonataMapRunner executes a step on each item of the input if it's a sequence,
 // flattening the results. If input is not a sequence, it executes on the input.
+// Items the step finds nothing for are left out of the results while nulls are kept.
 type jsonataMapRunner struct {
 	stepRunner lookup.Runner
 	name       string
@@ -49,10 +86,11 @@ func (r *jsonataMapRunner) Run(scope *lookup.Scope) lookup.Pathor {
 	curr := scope.Current
 
 	if isNilOrNilPointer(curr) {
-		return lookup.NewInvalidor(r.name, fmt.Errorf("current context is nil"))
+		return undefinedAt(r.name, "current context is nil")
 	}
 	if curr.IsNil() {
-		return curr
+		// Nothing can be navigated to from null
+		return undefinedAt(r.name, "nothing found")
 	}
 
 	if curr.IsSlice() {
@@ -71,14 +109,16 @@ func (r *jsonataMapRunner) Run(scope *lookup.Scope) lookup.Pathor {
 			res := r.stepRunner.Run(subScope)
 
 			if !isNilOrNilPointer(res) {
-				if _, ok := res.(*lookup.Invalidor); ok {
-					continue
+				if errorOf(res) != nil {
+					// Errors aren't skipped like the items nothing was found for
+					return res
 				}
-				if res.IsNil() {
+				// Nothing found for the item adds nothing to the results, a null does
+				if _, ok := res.(*lookup.Invalidor); ok {
 					continue
 				}
 
-				if res.IsSlice() {
+				if res.IsSlice() && !isConstructedArray(res) {
 					s, _ := res.AsSlice()
 					results = append(results, s...)
 				} else {
@@ -87,7 +127,7 @@ func (r *jsonataMapRunner) Run(scope *lookup.Scope) lookup.Pathor {
 			}
 		}
 		if len(results) == 0 {
-			return lookup.NewInvalidor(r.name, fmt.Errorf("nothing found"))
+			return undefinedAt(r.name, "nothing found")
 		}
 		return lookup.Reflect(results)
 	}
@@ -95,10 +135,11 @@ func (r *jsonataMapRunner) Run(scope *lookup.Scope) lookup.Pathor {
 	// Not a slice.
 	res := r.stepRunner.Run(scope)
 	if isNilOrNilPointer(res) {
-		return lookup.NewInvalidor(r.name, fmt.Errorf("nothing found"))
+		return undefinedAt(r.name, "nothing found")
 	}
-	if _, ok := res.(*lookup.Invalidor); ok {
-		return res
+	if _, ok := res.(*lookup.Invalidor); ok && r.name != "" && errorOf(res) == nil {
+		// A field or wildcard that isn't there is undefined rather than the failure lookup navigates to
+		return undefinedAt(r.name, "nothing found")
 	}
 	return res
 }
@@ -114,7 +155,7 @@ func (c *jsonataChain) Run(scope *lookup.Scope) lookup.Pathor {
 	res := c.first.Run(scope)
 
 	if isNilOrNilPointer(res) {
-		return lookup.NewInvalidor("", fmt.Errorf("chain broken"))
+		return undefinedAt("", "chain broken")
 	}
 	if _, ok := res.(*lookup.Invalidor); ok {
 		return res
@@ -123,68 +164,204 @@ func (c *jsonataChain) Run(scope *lookup.Scope) lookup.Pathor {
 	return c.second.Run(scope.Nest(res))
 }
 
-// jsonataSingletonRunner wraps a runner (like Index or Filter) and ensures that if the input context
-// is not a sequence (array/slice), it is treated as a singleton array.
-type jsonataSingletonRunner struct {
-	inner lookup.Runner
+type jsonataFunctionRunner struct {
+	Name    string
+	Callee  lookup.Runner
+	Args    []lookup.Runner // nil for the placeholders of a partial application
+	partial bool
+	tail    bool // the call is in tail position within a lambda, so a call to a lambda is left to the caller
 }
 
-func (r *jsonataSingletonRunner) Run(scope *lookup.Scope) lookup.Pathor {
-	curr := scope.Current
-	if isNilOrNilPointer(curr) {
-		return r.inner.Run(scope)
+func (r *jsonataFunctionRunner) Run(scope *lookup.Scope) lookup.Pathor {
+	return r.call(scope, nil)
+}
+
+// apply makes the call with value inserted before the other arguments, as `value ~> $f(args)` does.
+func (r *jsonataFunctionRunner) apply(scope *lookup.Scope, value lookup.Pathor) lookup.Pathor {
+	return r.call(scope, []lookup.Pathor{value})
+}
+
+func (r *jsonataFunctionRunner) call(scope *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+	fn := r.resolve(scope)
+	if r.partial && (fn == nil || !isFunction(fn)) {
+		if r.suggestion(scope) {
+			return lookup.NewInvalidor("", errorf("T1007", "attempted to partially apply a non-function, did you mean $%s?", r.Name).withToken(r.Name))
+		}
+		return lookup.NewInvalidor("", errorf("T1008", "attempted to partially apply a non-function").withToken(r.Name))
 	}
-	if curr.IsNil() {
-		return r.inner.Run(scope)
+	if fn == nil {
+		if r.suggestion(scope) {
+			return lookup.NewInvalidor("", errorf("T1005", "attempted to invoke a non-function, did you mean $%s?", r.Name).withToken(r.Name))
+		}
+		return lookup.NewInvalidor("", errorf("T1006", "attempted to invoke a non-function").withToken(r.Name))
 	}
 
-	if !curr.IsSlice() {
-		// Wrap in singleton slice
-		singleton := []interface{}{curr.Raw()}
-		pathor := lookup.Reflect(singleton)
-		// Update scope to point to singleton
-		scope = scope.Nest(pathor)
+	for _, arg := range r.Args {
+		if arg == nil {
+			args = append(args, nil)
+			continue
+		}
+		v := arg.Run(scope)
+		if errorOf(v) != nil {
+			return v
+		}
+		args = append(args, v)
+	}
+	if r.partial {
+		return lookup.Reflect(&partialFunction{fn: fn, args: args})
+	}
+	if lambda, ok := fn.(*Lambda); ok && r.tail {
+		return lookup.Reflect(&tailCall{lambda: lambda, caller: scope, args: args})
 	}
+	return callFunction(scope, fn, args)
+}
 
-	return r.inner.Run(scope)
+// suggestion reports whether the name of a call which isn't a function, `sum(x)`, is a registered function without
+// its `$`.
+func (r *jsonataFunctionRunner) suggestion(scope *lookup.Scope) bool {
+	return r.Callee == nil && !strings.HasPrefix(r.Name, "$") && lookupFunction(scope, "$"+r.Name) != nil
 }
 
-type jsonataFunctionRunner struct {
-	Name string
-	Args []lookup.Runner
+// partialFunction is the function resulting from a partial application, `$substring(?, 0, 5)`. Its arguments fill
+// the placeholders, nil in args, in order.
+type partialFunction struct {
+	fn   interface{}
+	args []lookup.Pathor
 }
 
-func (r *jsonataFunctionRunner) Run(scope *lookup.Scope) lookup.Pathor {
-	var fn evaluator.Function
-	if scope.Context != nil && scope.Context.Functions != nil {
-		fn = scope.Context.Functions[r.Name]
-	}
-	if fn == nil {
-		return lookup.NewInvalidor("", fmt.Errorf("function %s not implemented", r.Name))
+func (f *partialFunction) Invoke(scope *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+	full := make([]lookup.Pathor, len(f.args))
+	for i, arg := range f.args {
+		if arg == nil {
+			arg = undefined()
+			if len(args) > 0 {
+				arg, args = args[0], args[1:]
+			}
+		}
+		full[i] = arg
 	}
+	return callFunction(scope, f.fn, full)
+}
 
-	args := make([]interface{}, len(r.Args))
-	for i, arg := range r.Args {
-		res := arg.Run(scope)
+// resolve finds the function being called. A variable bound to a function shadows registered functions of the same
+// name, which are looked up in the scope's context and then in Functions.
+func (r *jsonataFunctionRunner) resolve(scope *lookup.Scope) interface{} {
+	if r.Callee != nil {
+		res := r.Callee.Run(scope)
 		if isNilOrNilPointer(res) {
-			args[i] = nil
-		} else {
-			if res.IsSlice() {
-				// JSONata functions often expect arguments to be unwrapped if singleton?
-				// Or raw values? evaluator.Function expects interface{}
-				// Let's pass the raw value (could be slice or single)
-				args[i] = res.Raw()
-			} else {
-				args[i] = res.Raw()
+			return nil
+		}
+		return res.Raw()
+	}
+	if strings.HasPrefix(r.Name, "$") {
+		if v, ok := scope.Lookup(r.Name[1:]); ok && !isNilOrNilPointer(v) {
+			return v.Raw()
+		}
+	}
+	if fn := lookupFunction(scope, r.Name); fn != nil {
+		return fn
+	}
+	return nil
+}
+
+// lookupFunction finds a registered function by name, including the leading `$`.
+func lookupFunction(scope *lookup.Scope, name string) evaluator.Function {
+	if scope != nil && scope.Context != nil && scope.Context.Functions != nil {
+		if fn, ok := scope.Context.Functions[name]; ok {
+			return fn
+		}
+	}
+	return Functions[name]
+}
+
+// callFunction applies a function value, either a Callable or an evaluator.Function, to the arguments.
+func callFunction(scope *lookup.Scope, fn interface{}, args []lookup.Pathor) lookup.Pathor {
+	switch fn := fn.(type) {
+	case Callable:
+		return fn.Invoke(scope, args)
+	case evaluator.Function:
+		// Both undefined and null arguments are passed as nil, functions which need to tell them apart are
+		// NativeFunctions. A nil result is undefined.
+		raw := make([]interface{}, len(args))
+		for i, arg := range args {
+			if !isNilOrNilPointer(arg) {
+				raw[i] = arg.Raw()
 			}
 		}
+		res, err := fn.Call(raw...)
+		if err != nil {
+			return lookup.NewInvalidor("", err)
+		}
+		if res == nil {
+			return undefined()
+		}
+		result := lookup.Reflect(res)
+		if result.IsSlice() {
+			return &arrayValue{result}
+		}
+		return result
+	}
+	return invalid("T1006", "attempted to invoke a non-function")
+}
+
+// variableRunner resolves `$name`. `$` is the context item, `$$` the root of the input, and any other name is looked up
+// in the scope's bindings falling back to registered functions so they can be passed around as values.
+type variableRunner struct {
+	name string
+}
+
+func (r *variableRunner) Run(scope *lookup.Scope) lookup.Pathor {
+	switch r.name {
+	case "":
+		if isNilOrNilPointer(scope.Current) {
+			return undefinedAt("$", "no context item")
+		}
+		return scope.Current
+	case "$":
+		if isNilOrNilPointer(scope.Current) {
+			return undefinedAt("$$", "no context item")
+		}
+		return (&rootRunner{}).Run(scope)
+	}
+	if v, ok := scope.Lookup(r.name); ok {
+		return v
 	}
+	if fn := lookupFunction(scope, "$"+r.name); fn != nil {
+		return lookup.Reflect(fn)
+	}
+	return undefinedAt("$"+r.name, fmt.Sprintf("variable $%s is not defined", r.name))
+}
+
+// bindRunner assigns a value to a variable in the current frame, the result is the value assigned.
+type bindRunner struct {
+	name  string
+	value lookup.Runner
+}
 
-	res, err := fn.Call(args...)
-	if err != nil {
-		return lookup.NewInvalidor("", err)
+func (r *bindRunner) Run(scope *lookup.Scope) lookup.Pathor {
+	v := r.value.Run(scope)
+	if isNilOrNilPointer(v) {
+		v = undefinedAt("$"+r.name, "nothing to assign")
 	}
-	return lookup.Reflect(res)
+	scope.Bind(r.name, v)
+	return v
+}
+
+// blockRunner evaluates expressions in order within a new frame and results in the last of them.
+type blockRunner struct {
+	expressions []lookup.Runner
+}
+
+func (r *blockRunner) Run(scope *lookup.Scope) lookup.Pathor {
+	inner := scope.Enclose()
+	var res lookup.Pathor = undefinedAt("", "empty block")
+	for _, e := range r.expressions {
+		res = e.Run(inner)
+		if errorOf(res) != nil {
+			return res
+		}
+	}
+	return res
 }
 
 func isNilOrNilPointer(i interface{}) bool {
@@ -197,3 +374,255 @@ func isNilOrNilPointer(i interface{}) bool {
 	}
 	return false
 }
+
+// booleanRunner casts the result of inner to a boolean following $boolean, undefined is false.
+type booleanRunner struct {
+	inner lookup.Runner
+}
+
+func (r *booleanRunner) Run(scope *lookup.Scope) lookup.Pathor {
+	res := r.inner.Run(scope)
+	if errorOf(res) != nil {
+		return res
+	}
+	if IsUndefined(res) {
+		return lookup.NewConstantor(scope.Path(), false)
+	}
+	return lookup.NewConstantor(scope.Path(), toBoolean(res.Raw()))
+}
+
+// operandRunner fails with an Error when the operand of an operator is defined but of a type the operator doesn't
+// accept. Undefined operands are left to the operator.
+type operandRunner struct {
+	inner    lookup.Runner
+	accepts  func(lookup.Pathor) bool
+	code     string
+	message  string // given the operator
+	operator string
+}
+
+func (r *operandRunner) Run(scope *lookup.Scope) lookup.Pathor {
+	res := r.inner.Run(scope)
+	if errorOf(res) != nil || IsUndefined(res) || r.accepts(res) {
+		return res
+	}
+	return lookup.NewInvalidor("", errorf(r.code, r.message, r.operator).withToken(r.operator))
+}
+
+// arithmeticRunner fails with D1001 when an arithmetic operator doesn't result in a finite number, which lookup's
+// operators fail for when dividing by zero and result in an infinity for when overflowing.
+type arithmeticRunner struct {
+	inner    lookup.Runner
+	operator string
+}
+
+func (r *arithmeticRunner) Run(scope *lookup.Scope) lookup.Pathor {
+	res := r.inner.Run(scope)
+	if errorOf(res) != nil || IsUndefined(res) {
+		return res
+	}
+	if inv, failed := res.(*lookup.Invalidor); failed {
+		return lookup.NewInvalidor("", errorf("D1001", "number out of range: %w", inv).withToken(r.operator))
+	}
+	if f, ok := lookup.ToFloat(res.Raw()); ok && (math.IsInf(f, 0) || math.IsNaN(f)) {
+		return lookup.NewInvalidor("", errorf("D1001", "number out of range: %v", f).withToken(r.operator))
+	}
+	return res
+}
+
+// numberOperands checks the operands of an arithmetic operator are numbers.
+func numberOperands(operator string, left, right lookup.Runner) (lookup.Runner, lookup.Runner) {
+	return &operandRunner{inner: left, accepts: isNumber, code: "T2001", message: "the left side of the %s operator must evaluate to a number", operator: operator},
+		&operandRunner{inner: right, accepts: isNumber, code: "T2002", message: "the right side of the %s operator must evaluate to a number", operator: operator}
+}
+
+// integerOperands checks the operands of the range operator are integers.
+func integerOperands(operator string, left, right lookup.Runner) (lookup.Runner, lookup.Runner) {
+	return &operandRunner{inner: left, accepts: isInteger, code: "T2003", message: "the left side of the %s operator must evaluate to an integer", operator: operator},
+		&operandRunner{inner: right, accepts: isInteger, code: "T2004", message: "the right side of the %s operator must evaluate to an integer", operator: operator}
+}
+
+func isNumber(p lookup.Pathor) bool {
+	return signatureSymbol(p) == 'n'
+}
+
+func isInteger(p lookup.Pathor) bool {
+	f, ok := lookup.ToFloat(p.Raw())
+	return ok && f == math.Trunc(f)
+}
+
+// maxRangeSize is the most items the range operator may produce, as in JSONata.
+const maxRangeSize = 10000000
+
+// rangeRunner implements the range operator, `[1..5]`, which is undefined when either operand is and empty when the
+// end is before the start.
+type rangeRunner struct {
+	start, end lookup.Runner
+}
+
+func (r *rangeRunner) Run(scope *lookup.Scope) lookup.Pathor {
+	start := r.start.Run(scope)
+	if errorOf(start) != nil || IsUndefined(start) {
+		return start
+	}
+	end := r.end.Run(scope)
+	if errorOf(end) != nil || IsUndefined(end) {
+		return end
+	}
+	s, _ := lookup.ToInt(start.Raw())
+	e, _ := lookup.ToInt(end.Raw())
+	if e < s {
+		return lookup.Reflect([]interface{}{})
+	}
+	size := e - s + 1
+	if size > maxRangeSize {
+		return lookup.NewInvalidor("", errorf("D2014", "the size of the sequence allocated by the range operator (..) must not exceed 1e7, attempted to allocate %d", size).withToken(".."))
+	}
+	result := make([]interface{}, 0, size)
+	for i := s; i <= e; i++ {
+		result = append(result, int(i))
+	}
+	return lookup.Reflect(result)
+}
+
+// comparisonRunner checks the operands of an ordering comparison, `<`, `<=`, `>` or `>=`, are both numbers or both
+// strings before comparing them.
+type comparisonRunner struct {
+	operator    string
+	left, right lookup.Runner
+	compare     func(left, right lookup.Runner) lookup.Runner
+}
+
+func (r *comparisonRunner) Run(scope *lookup.Scope) lookup.Pathor {
+	left := r.left.Run(scope)
+	if errorOf(left) != nil {
+		return left
+	}
+	right := r.right.Run(scope)
+	if errorOf(right) != nil {
+		return right
+	}
+	ls, rs := signatureSymbol(left), signatureSymbol(right)
+	comparable := func(symbol byte) bool { return symbol == 'n' || symbol == 's' || symbol == 'm' }
+	if !comparable(ls) || !comparable(rs) {
+		return lookup.NewInvalidor("", errorf("T2010", "the expressions either side of operator %s must evaluate to numeric or string values", r.operator).withToken(r.operator))
+	}
+	if IsUndefined(left) {
+		return left
+	}
+	if IsUndefined(right) {
+		return right
+	}
+	if ls != rs {
+		return lookup.NewInvalidor("", errorf("T2009", "the values %v and %v either side of operator %s must be of the same data type", left.Raw(), right.Raw(), r.operator).withToken(r.operator))
+	}
+	return r.compare(lookup.Constant(left.Raw()), lookup.Constant(right.Raw())).Run(scope)
+}
+
+// concatRunner implements the string concatenation operator, `&`. Each side is cast to a string as $string does, an
+// undefined side being the empty string, and an error raised by either side is the result.
+type concatRunner struct {
+	left, right lookup.Runner
+}
+
+func (r *concatRunner) Run(scope *lookup.Scope) lookup.Pathor {
+	var b strings.Builder
+	for _, operand := range []lookup.Runner{r.left, r.right} {
+		res := operand.Run(scope)
+		if errorOf(res) != nil {
+			return res
+		}
+		if IsUndefined(res) {
+			continue
+		}
+		s, err := stringify(res.Raw(), false)
+		if err != nil {
+			return lookup.NewInvalidor("", err)
+		}
+		b.WriteString(s)
+	}
+	return lookup.NewConstantor(scope.Path(), b.String())
+}
+
+// equalityRunner implements `=` and `!=`, which are both false when either side is undefined. null is a value, equal
+// only to null.
+type equalityRunner struct {
+	left, right lookup.Runner
+	compare     func(left, right lookup.Runner) lookup.Runner
+}
+
+func (r *equalityRunner) Run(scope *lookup.Scope) lookup.Pathor {
+	left := r.left.Run(scope)
+	if errorOf(left) != nil {
+		return left
+	}
+	right := r.right.Run(scope)
+	if errorOf(right) != nil {
+		return right
+	}
+	if IsUndefined(left) || IsUndefined(right) {
+		return lookup.NewConstantor(scope.Path(), false)
+	}
+	return r.compare(lookup.Constant(left.Raw()), lookup.Constant(right.Raw())).Run(scope)
+}
+
+// negationRunner implements the unary minus, `-Price`.
+type negationRunner struct {
+	operand lookup.Runner
+}
+
+func (r *negationRunner) Run(scope *lookup.Scope) lookup.Pathor {
+	res := r.operand.Run(scope)
+	if IsUndefined(res) {
+		return res
+	}
+	f, ok := lookup.ToFloat(res.Raw())
+	if !ok || !isNumber(res) {
+		return lookup.NewInvalidor("", errorf("D1002", "cannot negate a non-numeric value: %v", res.Raw()).withToken("-"))
+	}
+	return lookup.NewConstantor(scope.Path(), -f)
+}
+
+// defaultRunner implements `value ?: otherwise`, the value is kept when it's true by the rules of $boolean.
+type defaultRunner struct {
+	value     lookup.Runner
+	otherwise lookup.Runner
+}
+
+func (r *defaultRunner) Run(scope *lookup.Scope) lookup.Pathor {
+	res := r.value.Run(scope)
+	if errorOf(res) != nil || !IsUndefined(res) && toBoolean(res.Raw()) {
+		return res
+	}
+	return r.otherwise.Run(scope)
+}
+
+// coalesceRunner implements `value ?? otherwise`, the value is kept unless it's undefined. Unlike `?:` false, zero,
+// empty values and null are kept.
+type coalesceRunner struct {
+	value     lookup.Runner
+	otherwise lookup.Runner
+}
+
+func (r *coalesceRunner) Run(scope *lookup.Scope) lookup.Pathor {
+	res := r.value.Run(scope)
+	if errorOf(res) != nil || !IsUndefined(res) {
+		return res
+	}
+	return r.otherwise.Run(scope)
+}
+
+// inclusionRunner implements `value in array`, which is false rather than undefined when either side is undefined.
+type inclusionRunner struct {
+	value lookup.Runner
+	in    lookup.Runner
+}
+
+func (r *inclusionRunner) Run(scope *lookup.Scope) lookup.Pathor {
+	value := r.value.Run(scope)
+	in := r.in.Run(scope)
+	if IsUndefined(value) || IsUndefined(in) {
+		return lookup.Reflect(false)
+	}
+	return lookup.BinaryIn(lookup.Constant(value.Raw()), lookup.Constant(in.Raw())).Run(scope)
+}
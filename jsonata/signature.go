@@ -0,0 +1,320 @@
+package jsonata
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/arran4/go-evaluator"
+	"github.com/arran4/lookup"
+)
+
+// Signature is a JSONata function signature such as `<s-nn?:s>`, describing the types of a function's parameters and
+// of its result. Each parameter is one of the type symbols
+//
+//	b boolean, n number, s string, l null, a array, o object, f function, j any JSON value, x anything
+//
+// or a choice of them, `(ns)`. An array or function type may be followed by the type of its items or its own
+// signature, `a<n>` or `f<n:n>`. A parameter followed by `?` is optional, by `+` takes one or more arguments and by
+// `-` takes the context item when the argument isn't given. The result type, following `:`, is documentation only.
+type Signature struct {
+	source string
+	params []signatureParam
+	regex  *regexp.Regexp
+}
+
+type signatureParam struct {
+	symbol   byte           // the type symbol, '(' for a choice
+	regex    string         // matching the symbols of the arguments the parameter accepts
+	optional bool           // the parameter may be left out
+	context  *regexp.Regexp // matching the symbol of a context item which can stand in for the argument, or nil
+	subtype  string         // the type of the items of an array parameter
+}
+
+// arrayTypeNames names the item types of arrays in errors.
+var arrayTypeNames = map[byte]string{
+	'a': "arrays",
+	'b': "booleans",
+	'f': "functions",
+	'n': "numbers",
+	'o': "objects",
+	's': "strings",
+}
+
+// ParseSignature parses a signature, including the enclosing `<` and `>`.
+func ParseSignature(signature string) (*Signature, error) {
+	if !strings.HasPrefix(signature, "<") || !strings.HasSuffix(signature, ">") {
+		return nil, errorf("S0401", "a function signature must be enclosed in < and >: %s", signature)
+	}
+	sig := &Signature{source: signature}
+	var prev *signatureParam
+	add := func(symbol byte, regex string) {
+		sig.params = append(sig.params, signatureParam{symbol: symbol, regex: regex})
+		prev = &sig.params[len(sig.params)-1]
+	}
+	for i := 1; i < len(signature)-1; i++ {
+		symbol := signature[i]
+		if symbol == ':' {
+			break
+		}
+		switch symbol {
+		case 's', 'n', 'b', 'l', 'o':
+			add(symbol, "["+string(symbol)+"m]")
+		case 'a', 'x':
+			add(symbol, "[asnblfom]")
+		case 'f':
+			add(symbol, "f")
+		case 'j':
+			add(symbol, "[asnblom]")
+		case '(':
+			end := strings.IndexByte(signature[i:], ')')
+			if end < 0 {
+				return nil, errorf("S0401", "unterminated choice in function signature %s", signature)
+			}
+			choice := signature[i+1 : i+end]
+			if strings.ContainsAny(choice, "<>") {
+				return nil, errorf("S0402", "choice groups containing parameterized types are not supported: %s", signature)
+			}
+			add('(', "["+choice+"m]")
+			i += end
+		case '-', '?', '+':
+			if prev == nil {
+				return nil, errorf("S0401", "%c must follow a parameter type in function signature %s", symbol, signature)
+			}
+			switch symbol {
+			case '-':
+				prev.context = regexp.MustCompile("^" + prev.regex + "$")
+				prev.regex += "?"
+			case '?':
+				prev.optional = true
+				prev.regex += "?"
+			case '+':
+				prev.regex += "+"
+			}
+		case '<':
+			if prev == nil || (prev.symbol != 'a' && prev.symbol != 'f') {
+				return nil, errorf("S0401", "type parameters can only be applied to functions and arrays: %s", signature)
+			}
+			end := closingBracket(signature, i)
+			if end < 0 {
+				return nil, errorf("S0401", "unterminated type parameter in function signature %s", signature)
+			}
+			if prev.symbol == 'a' {
+				prev.subtype = signature[i+1 : end]
+			}
+			i = end
+		default:
+			return nil, errorf("S0401", "unknown type %q in function signature %s", symbol, signature)
+		}
+	}
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	for _, param := range sig.params {
+		pattern.WriteString("(" + param.regex + ")")
+	}
+	pattern.WriteString("$")
+	sig.regex = regexp.MustCompile(pattern.String())
+	return sig, nil
+}
+
+// MustParseSignature is like ParseSignature but panics if the signature isn't valid.
+func MustParseSignature(signature string) *Signature {
+	sig, err := ParseSignature(signature)
+	if err != nil {
+		panic(err)
+	}
+	return sig
+}
+
+// closingBracket finds the `>` matching the `<` at position i.
+func closingBracket(s string, i int) int {
+	depth := 0
+	for ; i < len(s); i++ {
+		switch s[i] {
+		case '<':
+			depth++
+		case '>':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func (s *Signature) String() string {
+	return s.source
+}
+
+// arity is the number of arguments a function with the signature needs, which is how many a higher-order function
+// passes it.
+func (s *Signature) arity() int {
+	n := 0
+	for _, param := range s.params {
+		if !param.optional {
+			n++
+		}
+	}
+	return n
+}
+
+// Validate checks the arguments of a call against the signature, resulting in the arguments the function should be
+// called with. A missing argument of a `-` parameter is replaced by the context item and an argument other than an
+// array given for an array parameter is wrapped in an array of one.
+func (s *Signature) Validate(args []lookup.Pathor, context lookup.Pathor) ([]lookup.Pathor, error) {
+	symbols := make([]byte, len(args))
+	for i, arg := range args {
+		symbols[i] = signatureSymbol(arg)
+	}
+	match := s.regex.FindStringSubmatch(string(symbols))
+	if match == nil {
+		return nil, s.mismatch(string(symbols))
+	}
+	var validated []lookup.Pathor
+	argIndex := 0
+	for i, param := range s.params {
+		group := match[i+1]
+		if group == "" {
+			if param.context != nil {
+				if !param.context.MatchString(string(signatureSymbol(context))) {
+					return nil, errorf("T0411", "the context item, used as argument %d, does not match function signature %s", argIndex+1, s.source)
+				}
+				validated = append(validated, context)
+				continue
+			}
+			// An optional parameter which was left out
+			validated = append(validated, nil)
+			continue
+		}
+		for j := range group {
+			arg := args[argIndex]
+			if param.symbol == 'a' && group[j] != 'm' {
+				if !param.itemsMatch(arg, group[j]) {
+					return nil, errorf("T0412", "argument %d must be an array of %s to match function signature %s", argIndex+1, arrayTypeNames[param.subtype[0]], s.source)
+				}
+				if group[j] != 'a' {
+					arg = &arrayValue{lookup.Reflect([]interface{}{arg.Raw()})}
+				}
+			}
+			validated = append(validated, arg)
+			argIndex++
+		}
+	}
+	for len(validated) > 0 && validated[len(validated)-1] == nil {
+		validated = validated[:len(validated)-1]
+	}
+	for i, arg := range validated {
+		if arg == nil {
+			validated[i] = undefined()
+		}
+	}
+	return validated, nil
+}
+
+// itemsMatch checks the items of an array argument, or a single value standing in for one, against the subtype.
+func (p signatureParam) itemsMatch(arg lookup.Pathor, symbol byte) bool {
+	if p.subtype == "" {
+		return true
+	}
+	if symbol != 'a' {
+		return string(symbol) == p.subtype
+	}
+	items := sequenceOf(arg)
+	if len(items) == 0 {
+		return true
+	}
+	first := signatureSymbol(lookup.Reflect(items[0]))
+	if first != p.subtype[0] {
+		return false
+	}
+	for _, item := range items[1:] {
+		if signatureSymbol(lookup.Reflect(item)) != first {
+			return false
+		}
+	}
+	return true
+}
+
+// mismatch reports the first argument which doesn't match the signature.
+func (s *Signature) mismatch(symbols string) error {
+	pattern := "^"
+	good := 0
+	for _, param := range s.params {
+		pattern += "(" + param.regex + ")"
+		loc := regexp.MustCompile(pattern).FindStringIndex(symbols)
+		if loc == nil {
+			break
+		}
+		good = loc[1]
+	}
+	return errorf("T0410", "argument %d does not match function signature %s", good+1, s.source)
+}
+
+// signatureSymbol is the type symbol of a value, `m` for a missing or undefined one. A Go *regexp.Regexp counts as a
+// function, as the functions taking a pattern accept one in place of a matcher function.
+func signatureSymbol(p lookup.Pathor) byte {
+	if IsUndefined(p) {
+		return 'm'
+	}
+	v := p.Raw()
+	switch v.(type) {
+	case json.Number:
+		return 'n'
+	case *regexp.Regexp:
+		return 'f'
+	}
+	switch typeOf(v) {
+	case "function":
+		return 'f'
+	case "string":
+		return 's'
+	case "number":
+		return 'n'
+	case "boolean":
+		return 'b'
+	case "null":
+		return 'l'
+	case "array":
+		return 'a'
+	}
+	return 'o'
+}
+
+// SignedFunction is a function whose arguments are validated against a Signature before it's called, so a registered
+// function can rely on the types of its arguments and have a missing first argument taken from the context item.
+type SignedFunction struct {
+	Signature *Signature
+	Function  interface{} // a Callable or evaluator.Function
+}
+
+// WithSignature attaches a signature to a function, it panics if the signature isn't valid.
+//
+//	jsonata.Functions["$repeat"] = jsonata.WithSignature("<s-n:s>", jsonata.NativeFunction(repeat))
+func WithSignature(signature string, fn interface{}) *SignedFunction {
+	if !isFunction(fn) {
+		panic(fmt.Sprintf("WithSignature: %T is not a function", fn))
+	}
+	return &SignedFunction{Signature: MustParseSignature(signature), Function: fn}
+}
+
+// Invoke validates the arguments, using the context item of the scope for a missing argument, and calls the function.
+func (f *SignedFunction) Invoke(scope *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+	context := undefined()
+	if scope != nil && !isNilOrNilPointer(scope.Current) {
+		context = scope.Current
+	}
+	validated, err := f.Signature.Validate(args, context)
+	if err != nil {
+		return lookup.NewInvalidor("", err)
+	}
+	return callFunction(scope, f.Function, validated)
+}
+
+// Call validates the arguments and calls the function without a scope, nil arguments are passed as undefined.
+func (f *SignedFunction) Call(args ...interface{}) (interface{}, error) {
+	return NativeFunction(f.Invoke).Call(args...)
+}
+
+var _ evaluator.Function = (*SignedFunction)(nil)
@@ -0,0 +1,198 @@
+package jsonata
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/arran4/lookup"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSignature(t *testing.T) {
+	tests := []struct {
+		signature string
+		err       string
+	}{
+		{signature: "<s-nn?:s>"},
+		{signature: "<a<s>s?:s>"},
+		{signature: "<(nsb)-:n>"},
+		{signature: "<f<n:n>a+>"},
+		{signature: "<>"},
+		{signature: "s-n", err: "S0401"},
+		{signature: "<n<s>>", err: "S0401"},
+		{signature: "<(sa<n>)>", err: "S0402"},
+		{signature: "<q>", err: "S0401"},
+		{signature: "<?s>", err: "S0401"},
+		{signature: "<(sn>", err: "S0401"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.signature, func(t *testing.T) {
+			sig, err := ParseSignature(tt.signature)
+			if tt.err != "" {
+				if assert.Error(t, err) {
+					assert.True(t, strings.HasPrefix(err.Error(), tt.err), err.Error())
+				}
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.signature, sig.String())
+			}
+		})
+	}
+}
+
+func TestSignatureValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		signature string
+		args      []interface{}
+		context   interface{}
+		expected  []interface{}
+		err       string
+	}{
+		{name: "Exact", signature: "<sn:s>", args: []interface{}{"a", float64(1)}, expected: []interface{}{"a", float64(1)}},
+		{name: "Optional left out", signature: "<sn?:s>", args: []interface{}{"a"}, expected: []interface{}{"a"}},
+		{name: "Context item", signature: "<s-:s>", context: "ctx", expected: []interface{}{"ctx"}},
+		{name: "Context item not used when given", signature: "<s-:s>", args: []interface{}{"a"}, context: "ctx", expected: []interface{}{"a"}},
+		{name: "Array wrapping", signature: "<a<n>:n>", args: []interface{}{float64(1)}, expected: []interface{}{[]interface{}{float64(1)}}},
+		{name: "Array of the subtype", signature: "<a<n>:n>", args: []interface{}{[]interface{}{float64(1), float64(2)}}, expected: []interface{}{[]interface{}{float64(1), float64(2)}}},
+		{name: "Variadic", signature: "<a+>", args: []interface{}{[]interface{}{"a"}, []interface{}{"b"}}, expected: []interface{}{[]interface{}{"a"}, []interface{}{"b"}}},
+		{name: "Choice", signature: "<(ns):s>", args: []interface{}{true}, err: "T0410: argument 1"},
+		{name: "Wrong type", signature: "<sn:s>", args: []interface{}{"a", "b"}, err: "T0410: argument 2"},
+		{name: "Too many", signature: "<s:s>", args: []interface{}{"a", "b"}, err: "T0410: argument 2"},
+		{name: "Context item of the wrong type", signature: "<s-:s>", context: float64(1), err: "T0411"},
+		{name: "Array of the wrong items", signature: "<a<n>:n>", args: []interface{}{[]interface{}{float64(1), "b"}}, err: "T0412"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var args []lookup.Pathor
+			for _, arg := range tt.args {
+				args = append(args, lookup.Reflect(arg))
+			}
+			context := undefined()
+			if tt.context != nil {
+				context = lookup.Reflect(tt.context)
+			}
+			validated, err := MustParseSignature(tt.signature).Validate(args, context)
+			if tt.err != "" {
+				if assert.Error(t, err) {
+					assert.True(t, strings.HasPrefix(err.Error(), tt.err), err.Error())
+				}
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			var got []interface{}
+			for _, arg := range validated {
+				got = append(got, arg.Raw())
+			}
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestSignaturesAndPartialApplication(t *testing.T) {
+	data := map[string]interface{}{
+		"Name":  "hello",
+		"Items": []interface{}{float64(1), float64(2), float64(3)},
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected interface{}
+	}{
+		{"Lambda signature", `function($x, $y)<nn:n>{ $x + $y }(1, 2)`, float64(3)},
+		{"Lambda signature context item", `Name.function($s)<s-:s>{ $uppercase($s) }()`, "HELLO"},
+		{"Lambda signature wraps arrays", `function($a)<a:b>{ $a = [5] }(5)`, true},
+		{"Context item defaults the first argument", `Name.$uppercase()`, "HELLO"},
+		{"Partial application", `($first := $substring(?, 0, 1); $first(Name))`, "h"},
+		{"Partial application of a lambda", `($add := function($a, $b){ $a + $b }; $inc := $add(?, 1); Items.$inc($))`, []interface{}{float64(2), float64(3), float64(4)}},
+		{"Several placeholders", `($f := $substring(?, 1, ?); $f(Name, 3))`, "ell"},
+		{"Partial applied by a higher-order function", `$map(Items, $string(?))`, []interface{}{"1", "2", "3"}},
+		{"Optional parameters aren't passed by $map", `$map(Items, function($v)<n:n>{ $v * 2 })`, []interface{}{float64(2), float64(4), float64(6)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, run(t, data, tt.expr))
+		})
+	}
+}
+
+func TestSignatureErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     string
+		err      string
+		parseErr bool
+	}{
+		{name: "Wrong argument type", expr: `$uppercase(1)`, err: "T0410"},
+		{name: "Lambda wrong argument type", expr: `function($x)<n:n>{ $x }("a")`, err: "T0410"},
+		{name: "Context item of the wrong type", expr: `$uppercase()`, err: "T0411"},
+		{name: "Array of the wrong items", expr: `$sum([1, "a"])`, err: "T0412"},
+		{name: "Partial application of a non-function", expr: `($f := 1; $f(?))`, err: "T1008"},
+		{name: "Partial application of an unknown function", expr: `$nothing(?, 1)`, err: "T1008"},
+		{name: "Partial application of a field named like a function", expr: `uppercase(?)`, err: "T1007"},
+		{name: "Invalid lambda signature", expr: `function($x)<q>{ $x }`, parseErr: true},
+	}
+
+	data := map[string]interface{}{"Items": []interface{}{float64(1)}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast, err := Parse(tt.expr)
+			if tt.parseErr {
+				assert.Error(t, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			root := lookup.Reflect(data)
+			res := Compile(ast).Run(lookup.NewScope(root, root))
+			if assert.IsType(t, &lookup.Invalidor{}, res) {
+				assert.Contains(t, res.(*lookup.Invalidor).Error(), tt.err)
+			}
+		})
+	}
+}
+
+func TestWithSignature(t *testing.T) {
+	repeat := WithSignature("<s-n:s>", NativeFunction(func(_ *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+		return lookup.Reflect(strings.Repeat(args[0].Raw().(string), int(args[1].Raw().(float64))))
+	}))
+	Functions["$repeatTest"] = repeat
+	defer delete(Functions, "$repeatTest")
+
+	data := map[string]interface{}{"Name": "ab"}
+	assert.Equal(t, "abab", run(t, data, `$repeatTest(Name, 2)`))
+	assert.Equal(t, "ababab", run(t, data, `Name.$repeatTest(3)`))
+
+	res, err := repeat.Call("x", float64(2))
+	assert.NoError(t, err)
+	assert.Equal(t, "xx", res)
+	_, err = repeat.Call("x", "y")
+	assert.Error(t, err)
+
+	assert.Panics(t, func() { WithSignature("<q>", repeat) })
+	assert.Panics(t, func() { WithSignature("<s>", "not a function") })
+}
+
+func TestCallArity(t *testing.T) {
+	var got []int
+	record := func() interface{} {
+		return NativeFunction(func(_ *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+			got = append(got, len(args))
+			return lookup.Reflect(fmt.Sprint(len(args)))
+		})
+	}
+	args := []lookup.Pathor{lookup.Reflect(1.0), lookup.Reflect(2.0), lookup.Reflect(3.0)}
+	Call(nil, record(), args...)
+	Call(nil, WithSignature("<n:n>", record()), args...)
+	Call(nil, WithSignature("<nn?n?:n>", record()), args...)
+	assert.Equal(t, []int{3, 1, 1}, got)
+}
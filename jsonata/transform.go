@@ -0,0 +1,136 @@
+package jsonata
+
+import (
+	"github.com/arran4/lookup"
+)
+
+// transformRunner results in the function of a transform expression, closing over the scope it's evaluated in so the
+// pattern, update and delete expressions see the variables bound there.
+type transformRunner struct {
+	pattern lookup.Runner
+	update  lookup.Runner
+	delete  lookup.Runner // nil when the transform doesn't delete
+}
+
+func (r *transformRunner) Run(scope *lookup.Scope) lookup.Pathor {
+	return lookup.Reflect(&transformFunction{transformRunner: r, scope: scope})
+}
+
+// transformFunction is the function a transform expression results in. It modifies a deep copy of its argument,
+// leaving the argument itself untouched.
+type transformFunction struct {
+	*transformRunner
+	scope *lookup.Scope
+}
+
+func (f *transformFunction) Invoke(_ *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+	if len(args) == 0 || IsUndefined(args[0]) {
+		return undefined()
+	}
+	result := lookup.Reflect(lookup.DeepCopy(args[0]))
+	matches := f.pattern.Run(f.scope.Nest(result))
+	if errorOf(matches) != nil {
+		return matches
+	}
+	for _, match := range sequenceOf(matches) {
+		item := lookup.Reflect(match)
+		if err := f.apply(item); err != nil {
+			return lookup.NewInvalidor("", err)
+		}
+	}
+	return result
+}
+
+// apply merges the update into a matched item and removes the deleted fields from it. Matches which aren't objects
+// are left as they are.
+func (f *transformFunction) apply(item lookup.Pathor) error {
+	scope := f.scope.Nest(item)
+	update := f.update.Run(scope)
+	if err := errorOf(update); err != nil {
+		return err
+	}
+	if !IsUndefined(update) {
+		if !isObject(update.Raw()) {
+			return errorf("T2011", "the insert/update clause of the transform expression must evaluate to an object: %v", update.Raw())
+		}
+		if isObject(item.Raw()) {
+			keys, values := entriesOf(update)
+			for i, key := range keys {
+				if err := lookup.SetField(item, key, values[i]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if f.delete == nil {
+		return nil
+	}
+	deleted := f.delete.Run(scope)
+	if err := errorOf(deleted); err != nil {
+		return err
+	}
+	if IsUndefined(deleted) {
+		return nil
+	}
+	var names []string
+	for _, name := range sequenceOf(deleted) {
+		s, ok := name.(string)
+		if !ok {
+			return errorf("T2012", "the delete clause of the transform expression must evaluate to a string or array of strings: %v", deleted.Raw())
+		}
+		names = append(names, s)
+	}
+	if !isObject(item.Raw()) {
+		return nil
+	}
+	for _, name := range names {
+		if err := lookup.DeleteField(item, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyRunner implements the function application operator, `value ~> $f(args)`, which calls $f with value as its
+// first argument. When the right hand side isn't a call it must result in a function to call with value alone, or
+// when value is itself a function the two are composed, `$trim ~> $uppercase`.
+type applyRunner struct {
+	value lookup.Runner
+	call  *jsonataFunctionRunner // the call on the right hand side, nil when it's another expression
+	fn    lookup.Runner
+}
+
+func (r *applyRunner) Run(scope *lookup.Scope) lookup.Pathor {
+	value := r.value.Run(scope)
+	if errorOf(value) != nil {
+		return value
+	}
+	if r.call != nil {
+		return r.call.apply(scope, value)
+	}
+	fn := r.fn.Run(scope)
+	if errorOf(fn) != nil {
+		return fn
+	}
+	if IsUndefined(fn) || !isFunction(fn.Raw()) {
+		return lookup.NewInvalidor("~>", errorf("T2006", "the right side of the function application operator ~> must be a function"))
+	}
+	if !IsUndefined(value) && isFunction(value.Raw()) {
+		return lookup.Reflect(&composition{first: value.Raw(), second: fn.Raw()})
+	}
+	return Call(scope, fn.Raw(), value)
+}
+
+// composition is the function resulting from applying one function to another, `$f ~> $g`, which calls $g with the
+// result of $f.
+type composition struct {
+	first, second interface{}
+}
+
+func (c *composition) Invoke(scope *lookup.Scope, args []lookup.Pathor) lookup.Pathor {
+	res := Call(scope, c.first, args...)
+	if errorOf(res) != nil {
+		return res
+	}
+	return Call(scope, c.second, res)
+}
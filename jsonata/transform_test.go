@@ -0,0 +1,127 @@
+package jsonata
+
+import (
+	"testing"
+
+	"github.com/arran4/lookup"
+	"github.com/stretchr/testify/assert"
+)
+
+func transformTestData() interface{} {
+	return map[string]interface{}{
+		"Order": []interface{}{
+			map[string]interface{}{"ID": "o1", "Price": float64(2), "Qty": float64(3), "Temp": "x"},
+			map[string]interface{}{"ID": "o2", "Price": float64(5), "Qty": float64(1), "Temp": "y"},
+		},
+		"Status": "open",
+	}
+}
+
+type transformOrder struct {
+	ID     string
+	Status string
+	Qty    int
+}
+
+func TestTransform(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     interface{}
+		expr     string
+		expected interface{}
+	}{
+		{"Update and delete", transformTestData(), `$ ~> |Order|{"Total": Price * Qty}, ["Temp", "Qty"]|`, map[string]interface{}{
+			"Order": []interface{}{
+				map[string]interface{}{"ID": "o1", "Price": float64(2), "Total": float64(6)},
+				map[string]interface{}{"ID": "o2", "Price": float64(5), "Total": float64(5)},
+			},
+			"Status": "open",
+		}},
+		{"Delete a single field", transformTestData(), `$ ~> |$|{}, "Order"|`, map[string]interface{}{"Status": "open"}},
+		{"Replaces existing fields", transformTestData(), `Order ~> |$[ID = "o2"]|{"Price": Price * 2}|`, []interface{}{
+			map[string]interface{}{"ID": "o1", "Price": float64(2), "Qty": float64(3), "Temp": "x"},
+			map[string]interface{}{"ID": "o2", "Price": float64(10), "Qty": float64(1), "Temp": "y"},
+		}},
+		{"No match leaves a copy", transformTestData(), `Order[0] ~> |Missing|{"a": 1}|`, map[string]interface{}{"ID": "o1", "Price": float64(2), "Qty": float64(3), "Temp": "x"}},
+		{"Undefined update", transformTestData(), `Order[0] ~> |$|nothing, "Temp"|`, map[string]interface{}{"ID": "o1", "Price": float64(2), "Qty": float64(3)}},
+		{"Variables from the enclosing scope", transformTestData(), `($s := "closed"; $ ~> |$|{"Status": $s}, "Order"|)`, map[string]interface{}{"Status": "closed"}},
+		{"As a function value", transformTestData(), `($close := |$|{"Status": "closed"}|; $close(Order[1]).Status)`, "closed"},
+		{"Undefined input", transformTestData(), `Missing ~> |$|{"a": 1}|`, nil},
+		{"Struct pointers", []*transformOrder{{ID: "a", Qty: 1}}, `$ ~> |$|{"Status": "done", "Qty": 4}|`, &transformOrder{ID: "a", Status: "done", Qty: 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, run(t, tt.data, tt.expr))
+		})
+	}
+}
+
+func TestTransformLeavesInputUnchanged(t *testing.T) {
+	data := transformTestData()
+	run(t, data, `$ ~> |Order|{"Price": 0}, "Temp"|`)
+	assert.Equal(t, transformTestData(), data)
+
+	orders := []*transformOrder{{ID: "a"}}
+	run(t, orders, `$ ~> |$|{"Status": "done"}|`)
+	assert.Equal(t, "", orders[0].Status)
+}
+
+func TestFunctionApplication(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     string
+		expected interface{}
+	}{
+		{"Inserts the first argument", `Order[0].ID ~> $uppercase()`, "O1"},
+		{"Chained", `"  Hello " ~> $trim() ~> $uppercase() ~> $substring(1, 2)`, "EL"},
+		{"With other arguments", `Order.ID ~> $join(", ")`, "o1, o2"},
+		{"Function value", `Order.Qty ~> $sum`, float64(4)},
+		{"Lambda", `Status ~> function($s){ $s & "!" }`, "open!"},
+		{"Composition", `($f := $trim ~> $uppercase; $f(" a "))`, "A"},
+		{"Composition of lambdas", `($inc := function($x){ $x + 1 }; $twice := $inc ~> $inc; $twice(1))`, float64(3)},
+		{"Undefined input", `Missing ~> $uppercase()`, nil},
+		{"Regular expression", `Status ~> /p(e)n/`, map[string]interface{}{"match": "pen", "start": float64(1), "end": float64(4), "groups": []interface{}{"e"}}},
+		{"Lower precedence than concatenation", `"a" & "b" ~> $uppercase()`, "AB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := run(t, transformTestData(), tt.expr)
+			if m, ok := res.(map[string]interface{}); ok {
+				delete(m, "next")
+			}
+			assert.Equal(t, tt.expected, res)
+		})
+	}
+}
+
+func TestTransformErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     string
+		parseErr bool
+	}{
+		{name: "Update isn't an object", expr: `Order ~> |$|5|`},
+		{name: "Delete isn't a string", expr: `Order ~> |$|{}, 5|`},
+		{name: "Delete array of numbers", expr: `Order ~> |$|{}, [1]|`},
+		{name: "Apply a non-function", expr: `42 ~> "hello"`},
+		{name: "Unterminated transform", expr: `$ ~> |Order|{}`, parseErr: true},
+		{name: "Missing update", expr: `$ ~> |Order|`, parseErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast, err := Parse(tt.expr)
+			if tt.parseErr {
+				assert.Error(t, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			root := lookup.Reflect(transformTestData())
+			assert.IsType(t, &lookup.Invalidor{}, Compile(ast).Run(lookup.NewScope(root, root)))
+		})
+	}
+}
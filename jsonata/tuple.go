@@ -0,0 +1,301 @@
+package jsonata
+
+import (
+	"sort"
+
+	"github.com/arran4/lookup"
+)
+
+// tupleKey binds each scope of a tuple stream to itself so the parent operator can find the tuple its context item
+// belongs to from any scope nested within it. It isn't a valid variable name so expressions can't reach it.
+const tupleKey = "#tuple"
+
+// tuplePathRunner evaluates a path as a stream of tuples, used when the path sorts, binds positional or context
+// variables or is navigated back up with the parent operator. Each tuple is a scope nested in the scope of the tuple
+// its item was navigated from, with the item as Current and a frame holding the variables bound by `#$i` and `@$o`,
+// so later steps see them and `%` follows the Parent chain back up the path.
+type tuplePathRunner struct {
+	steps []tupleStep
+}
+
+type tupleStep struct {
+	runner     lookup.Runner      // evaluated against each tuple resulting in the items of the step
+	predicates []*predicateRunner // filtering the items of the step in each context
+	leading    bool               // evaluated once in the context of the path rather than for each of its items
+	parent     bool               // the parent operator, moving each tuple back to the one it was navigated from
+	sort       []sortTermRunner
+	index      string // variable bound to the position of each item, `#$i`
+	focus      string // variable bound to each item leaving the context unchanged, `@$o`
+	stages     []*predicateRunner
+}
+
+type sortTermRunner struct {
+	expr       lookup.Runner
+	descending bool
+}
+
+func (r *tuplePathRunner) Run(scope *lookup.Scope) lookup.Pathor {
+	tuples := []*lookup.Scope{scope}
+	for _, step := range r.steps {
+		var err error
+		if tuples, err = step.run(tuples); err != nil {
+			return lookup.NewInvalidor("", err)
+		}
+		if len(tuples) == 0 {
+			return undefined()
+		}
+	}
+	if len(tuples) == 1 {
+		return tuples[0].Current
+	}
+	items := make([]interface{}, len(tuples))
+	for i, t := range tuples {
+		items[i] = t.Current.Raw()
+	}
+	return lookup.Reflect(items)
+}
+
+func (s *tupleStep) run(tuples []*lookup.Scope) ([]*lookup.Scope, error) {
+	var out []*lookup.Scope
+	switch {
+	case s.sort != nil:
+		var items []*lookup.Scope
+		for _, t := range tuples {
+			items = append(items, contextsOf(t)...)
+		}
+		sorted, err := sortTuples(items, s.sort)
+		if err != nil {
+			return nil, err
+		}
+		for i, t := range sorted {
+			if s.index != "" {
+				t = t.Enclose()
+				t.Bind(tupleKey, lookup.Reflect(t))
+				t.Bind(s.index, lookup.Reflect(float64(i)))
+			}
+			out = append(out, t)
+		}
+	case s.parent:
+		for _, t := range tuples {
+			p := parentTuple(t)
+			if p == nil {
+				return nil, errorf("S0217", "the parent operator %% has no parent to refer to")
+			}
+			out = append(out, p)
+		}
+	default:
+		for _, t := range tuples {
+			contexts := []*lookup.Scope{t}
+			if !s.leading {
+				contexts = contextsOf(t)
+			}
+			for _, c := range contexts {
+				results, err := s.results(c)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, results...)
+			}
+		}
+	}
+	for _, stage := range s.stages {
+		var err error
+		if out, err = filterTuples(out, stage); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// contextsOf returns the contexts a step is evaluated in for a tuple, one for each item when its item is an array.
+func contextsOf(t *lookup.Scope) []*lookup.Scope {
+	if t.Current == nil || !t.Current.IsSlice() {
+		return []*lookup.Scope{t}
+	}
+	items, _ := t.Current.AsSlice()
+	contexts := make([]*lookup.Scope, len(items))
+	for i, item := range items {
+		contexts[i] = t.Nest(lookup.Reflect(item))
+	}
+	return contexts
+}
+
+// results evaluates the step in the context c, resulting in a tuple for each item kept by its predicates.
+func (s *tupleStep) results(c *lookup.Scope) ([]*lookup.Scope, error) {
+	res := s.runner.Run(c)
+	if err := errorOf(res); err != nil {
+		return nil, err
+	}
+	if _, failed := res.(*lookup.Invalidor); failed || IsUndefined(res) {
+		// Nothing found, such as a field which isn't there
+		return nil, nil
+	}
+	items := []lookup.Pathor{res}
+	if res.IsSlice() && (!isConstructedArray(res) || len(s.predicates) > 0) {
+		raw, _ := res.AsSlice()
+		items = items[:0]
+		for _, item := range raw {
+			items = append(items, lookup.Reflect(item))
+		}
+	}
+	tuples := make([]*lookup.Scope, len(items))
+	for i, item := range items {
+		tuples[i] = newTuple(c, item)
+	}
+	for _, predicate := range s.predicates {
+		var err error
+		if tuples, err = filterTuples(tuples, predicate); err != nil {
+			return nil, err
+		}
+	}
+	for i, t := range tuples {
+		if s.focus != "" {
+			// The item is bound to the variable and the context stays as it was
+			item := t.Current
+			t = newTuple(c, c.Current)
+			t.Bind(s.focus, item)
+			tuples[i] = t
+		}
+		if s.index != "" {
+			t.Bind(s.index, lookup.Reflect(float64(i)))
+		}
+	}
+	return tuples, nil
+}
+
+// newTuple creates the tuple for an item navigated to from the context c.
+func newTuple(c *lookup.Scope, item lookup.Pathor) *lookup.Scope {
+	t := c.Nest(item).Enclose()
+	t.Bind(tupleKey, lookup.Reflect(t))
+	return t
+}
+
+// parentTuple finds the scope of the item the context item of scope was navigated from, or nil outside of a path.
+func parentTuple(scope *lookup.Scope) *lookup.Scope {
+	if v, ok := scope.Lookup(tupleKey); ok {
+		if t, ok := v.Raw().(*lookup.Scope); ok && t.Parent != nil {
+			return t.Parent
+		}
+	}
+	return nil
+}
+
+// parentRunner implements the parent operator, `%`, outside of a path.
+type parentRunner struct{}
+
+func (r *parentRunner) Run(scope *lookup.Scope) lookup.Pathor {
+	p := parentTuple(scope)
+	if p == nil {
+		return lookup.NewInvalidor("%", errorf("S0217", "the parent operator %% has no parent to refer to"))
+	}
+	return p.Current
+}
+
+// filterTuples keeps the tuples selected by a predicate evaluated against each of them.
+func filterTuples(tuples []*lookup.Scope, predicate *predicateRunner) ([]*lookup.Scope, error) {
+	var kept []*lookup.Scope
+	for i, t := range tuples {
+		if predicate.index != nil {
+			if sequenceIndex(*predicate.index, len(tuples)) == i {
+				kept = append(kept, t)
+			}
+			continue
+		}
+		res := predicate.expr.Run(t)
+		if err := errorOf(res); err != nil {
+			return nil, err
+		}
+		if selects(res, i, len(tuples)) {
+			kept = append(kept, t)
+		}
+	}
+	return kept, nil
+}
+
+// sortTuples orders tuples by the sort terms evaluated against each of them. The sort is stable, and items for which
+// a term is undefined are placed after the others whatever the direction.
+func sortTuples(tuples []*lookup.Scope, terms []sortTermRunner) ([]*lookup.Scope, error) {
+	keys := make([][]lookup.Pathor, len(tuples))
+	for i, t := range tuples {
+		keys[i] = make([]lookup.Pathor, len(terms))
+		for j, term := range terms {
+			key := term.expr.Run(t)
+			if err := errorOf(key); err != nil {
+				return nil, err
+			}
+			if !IsUndefined(key) {
+				if _, ok := key.Raw().(string); !ok {
+					if _, ok := lookup.ToFloat(key.Raw()); !ok {
+						return nil, errorf("T2008", "the expressions within an order-by clause must evaluate to numeric or string values")
+					}
+				}
+			}
+			keys[i][j] = key
+		}
+	}
+	order := make([]int, len(tuples))
+	for i := range order {
+		order[i] = i
+	}
+	var err error
+	sort.SliceStable(order, func(a, b int) bool {
+		for j, term := range terms {
+			c, cerr := compareSortKeys(keys[order[a]][j], keys[order[b]][j])
+			if cerr != nil {
+				err = cerr
+				return false
+			}
+			if c == 0 {
+				continue
+			}
+			if term.descending && !IsUndefined(keys[order[a]][j]) && !IsUndefined(keys[order[b]][j]) {
+				c = -c
+			}
+			return c < 0
+		}
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	sorted := make([]*lookup.Scope, len(tuples))
+	for i, o := range order {
+		sorted[i] = tuples[o]
+	}
+	return sorted, nil
+}
+
+// compareSortKeys compares two numbers or two strings, undefined coming after anything else.
+func compareSortKeys(a, b lookup.Pathor) (int, error) {
+	switch au, bu := IsUndefined(a), IsUndefined(b); {
+	case au && bu:
+		return 0, nil
+	case au:
+		return 1, nil
+	case bu:
+		return -1, nil
+	}
+	as, aString := a.Raw().(string)
+	bs, bString := b.Raw().(string)
+	if aString != bString {
+		return 0, errorf("T2007", "the expressions within an order-by clause must evaluate to values of the same type")
+	}
+	if aString {
+		switch {
+		case as < bs:
+			return -1, nil
+		case as > bs:
+			return 1, nil
+		}
+		return 0, nil
+	}
+	af, _ := lookup.ToFloat(a.Raw())
+	bf, _ := lookup.ToFloat(b.Raw())
+	switch {
+	case af < bf:
+		return -1, nil
+	case af > bf:
+		return 1, nil
+	}
+	return 0, nil
+}
@@ -0,0 +1,154 @@
+package jsonata
+
+import (
+	"testing"
+
+	"github.com/arran4/lookup"
+	"github.com/stretchr/testify/assert"
+)
+
+func tupleTestData() interface{} {
+	return map[string]interface{}{
+		"Account": map[string]interface{}{
+			"Name": "Firefly",
+			"Order": []interface{}{
+				map[string]interface{}{"OrderID": "o1", "Product": []interface{}{
+					map[string]interface{}{"Name": "Hat", "Price": float64(3), "Qty": float64(1)},
+					map[string]interface{}{"Name": "Cap", "Price": float64(1), "Qty": float64(2)},
+				}},
+				map[string]interface{}{"OrderID": "o2", "Product": []interface{}{
+					map[string]interface{}{"Name": "Fez", "Price": float64(2), "Qty": float64(1)},
+					map[string]interface{}{"Name": "Cloak", "Price": float64(2), "Qty": float64(5)},
+					map[string]interface{}{"Name": "Scarf"},
+				}},
+			},
+		},
+		"Library": map[string]interface{}{
+			"Books": []interface{}{
+				map[string]interface{}{"ISBN": "1", "Title": "Dune"},
+				map[string]interface{}{"ISBN": "2", "Title": "Emma"},
+			},
+			"Loans": []interface{}{
+				map[string]interface{}{"ISBN": "2", "Customer": "Ann"},
+				map[string]interface{}{"ISBN": "1", "Customer": "Bob"},
+			},
+		},
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	people := []interface{}{
+		map[string]interface{}{"name": "Jim", "age": float64(40)},
+		map[string]interface{}{"name": "Sally", "age": float64(30)},
+		map[string]interface{}{"name": "Bill", "age": float64(35)},
+	}
+
+	tests := []struct {
+		name     string
+		data     interface{}
+		expr     string
+		expected interface{}
+	}{
+		{"Ascending", tupleTestData(), "Account.Order.Product^(Price).Name", []interface{}{"Cap", "Fez", "Cloak", "Hat", "Scarf"}},
+		{"Explicitly ascending", tupleTestData(), "Account.Order.Product^(<Price).Name", []interface{}{"Cap", "Fez", "Cloak", "Hat", "Scarf"}},
+		{"Descending keeps undefined last", tupleTestData(), "Account.Order.Product^(>Price).Name", []interface{}{"Hat", "Fez", "Cloak", "Cap", "Scarf"}},
+		{"Multiple terms", tupleTestData(), "Account.Order.Product^(>Price, >Qty).Name", []interface{}{"Hat", "Cloak", "Fez", "Cap", "Scarf"}},
+		{"Expression term", tupleTestData(), "Account.Order.Product^(Price * Qty).Name", []interface{}{"Cap", "Fez", "Hat", "Cloak", "Scarf"}},
+		{"Strings", tupleTestData(), "Account.Order.Product.Name^($)", []interface{}{"Cap", "Cloak", "Fez", "Hat", "Scarf"}},
+		{"Context", people, "$^(age).name", []interface{}{"Sally", "Bill", "Jim"}},
+		{"Then index", people, "$^(age)[0].name", "Sally"},
+		{"Index then sort", people, "$[0]^(age).name", "Jim"},
+		{"Array constructor", nil, "[3, 1, 2]^($)", []interface{}{float64(1), float64(2), float64(3)}},
+		{"Within block", people, "($^(>age)).name", []interface{}{"Jim", "Bill", "Sally"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, run(t, tt.data, tt.expr))
+		})
+	}
+}
+
+func TestPositionalAndContextBindings(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     string
+		expected interface{}
+	}{
+		{"Position", "Account.Order#$i.(OrderID & $string($i))", []interface{}{"o10", "o21"}},
+		{"Position within each parent", "Account.Order.Product#$i[$i = 1].Name", []interface{}{"Cap", "Cloak"}},
+		{"Position visible to later steps", "Account.Order#$o.Product.{'Name': Name, 'Order': $o}", []interface{}{
+			map[string]interface{}{"Name": "Hat", "Order": float64(0)},
+			map[string]interface{}{"Name": "Cap", "Order": float64(0)},
+			map[string]interface{}{"Name": "Fez", "Order": float64(1)},
+			map[string]interface{}{"Name": "Cloak", "Order": float64(1)},
+			map[string]interface{}{"Name": "Scarf", "Order": float64(1)},
+		}},
+		{"Position after predicate", "Account.Order.Product[Price > 1]#$i.($string($i) & Name)", []interface{}{"0Hat", "0Fez", "1Cloak"}},
+		{"Position after sort", "Account.Order.Product^(Price)#$i[$i < 2].Name", []interface{}{"Cap", "Fez"}},
+		{"Context binding join", "Library.Loans@$l.Books@$b[$l.ISBN = $b.ISBN].{'Title': $b.Title, 'Customer': $l.Customer}", []interface{}{
+			map[string]interface{}{"Title": "Emma", "Customer": "Ann"},
+			map[string]interface{}{"Title": "Dune", "Customer": "Bob"},
+		}},
+		{"Predicate before context binding", "Library.Books[ISBN = '1']@$b.Loans[ISBN = $b.ISBN].Customer", "Bob"},
+		{"Context binding keeps context", "Account.Order@$o.Name", []interface{}{"Firefly", "Firefly"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, run(t, tupleTestData(), tt.expr))
+		})
+	}
+}
+
+func TestParentOperator(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     string
+		expected interface{}
+	}{
+		{"Parent", "Account.Order.Product[0].{'Name': Name, 'Order': %.OrderID}", []interface{}{
+			map[string]interface{}{"Name": "Hat", "Order": "o1"},
+			map[string]interface{}{"Name": "Fez", "Order": "o2"},
+		}},
+		{"Grandparent", "Account.Order.Product[0].%.%.Name", []interface{}{"Firefly", "Firefly"}},
+		{"Parent in predicate", "Account.Order.Product[%.OrderID = 'o2'].Name", []interface{}{"Fez", "Cloak", "Scarf"}},
+		{"Parent as step", "Account.Order.Product.Name.%.Price", []interface{}{float64(3), float64(1), float64(2), float64(2)}},
+		{"Parent in nested path", "Account.Order.(Product.%.OrderID)", []interface{}{"o1", "o1", "o2", "o2", "o2"}},
+		{"Remainder is not parent", "Account.Order.Product[0].(Qty % 2 = 1)", []interface{}{true, true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, run(t, tupleTestData(), tt.expr))
+		})
+	}
+}
+
+func TestTupleErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     string
+		parseErr bool
+	}{
+		{name: "Sort mixed types", expr: `[1, "a"]^($)`},
+		{name: "Sort by object", expr: `[{"a": 1}, {"a": 2}]^($)`},
+		{name: "Parent without path", expr: `%`},
+		{name: "Position without variable", expr: `a#i`, parseErr: true},
+		{name: "Sort without terms", expr: `a^Price`, parseErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast, err := Parse(tt.expr)
+			if tt.parseErr {
+				assert.Error(t, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.IsType(t, &lookup.Invalidor{}, Compile(ast).Run(lookup.NewScope(nil, lookup.Reflect(nil))))
+		})
+	}
+}
@@ -18,9 +18,10 @@ type txtarCase struct {
 type suiteCase struct {
 	ExprFile  string                 `json:"exprFile"`
 	Dataset   string                 `json:"dataset"`
-	Data      interface{}            `json:"data"`
+	Data      json.RawMessage        `json:"data"` // kept raw so `"data": null` can be told apart from no data
 	Bindings  map[string]interface{} `json:"bindings"`
 	Undefined bool                   `json:"undefinedResult"`
+	Code      string                 `json:"code"` // the code of the Error the case is expected to fail with
 }
 
 func parseTxtar(data []byte) ([]txtarCase, error) {
@@ -0,0 +1,43 @@
+package jsonata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWildcards(t *testing.T) {
+	data := map[string]interface{}{
+		"Account": map[string]interface{}{
+			"Name": "Firefly",
+			"Order": []interface{}{
+				map[string]interface{}{"ID": "o1", "Product": map[string]interface{}{"Price": float64(10)}},
+				map[string]interface{}{"ID": "o2", "Product": []interface{}{
+					map[string]interface{}{"Price": float64(20)},
+					map[string]interface{}{"Price": float64(30), "Parts": map[string]interface{}{"Price": float64(1)}},
+				}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected interface{}
+	}{
+		{"Children", "Account.*.ID", []interface{}{"o1", "o2"}},
+		{"Children of each item", "Account.Order.*.Price", []interface{}{float64(10), float64(20), float64(30)}},
+		{"Children with predicate", "Account.*[ID = 'o2'].Product[0].Price", float64(20)},
+		{"Leading wildcard", "*.Name", "Firefly"},
+		{"Descendants", "**.Price", []interface{}{float64(10), float64(20), float64(30), float64(1)}},
+		{"Descendants within path", "Account.Order[1].**.Price", []interface{}{float64(20), float64(30), float64(1)}},
+		{"Wildcard then descendants", "Account.*.**.Parts.Price", float64(1)},
+		{"Multiplication still parses", "Account.Order[0].Product.Price * 2", float64(20)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, run(t, data, tt.expr))
+		})
+	}
+}
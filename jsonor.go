@@ -1,21 +1,72 @@
 package lookup
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"io"
 	"reflect"
 )
 
-// Jsonor is a Pathor that lazily unmarshals JSON bytes when accessed.
+// Jsonor is a Pathor over raw JSON bytes. Finding a field of an object scans the object's members without decoding
+// them, skipping the values of other fields, so only the parts of the document a lookup reaches are decoded. The
+// fields found while scanning are remembered, so finding them again, or anything below them, doesn't scan the same
+// bytes twice. Arrays and other values are decoded when they're first accessed.
 type Jsonor struct {
-	path string
-	raw  json.RawMessage
-	p    Pathor
-	done bool
+	path   string
+	raw    json.RawMessage
+	p      Pathor
+	done   bool
+	fields map[string]*Jsonor // fields of an object by name, nil until the first Find
+	err    error              // the error scanning the object failed with, if any
+	opts   jsonOptions
+}
+
+// JsonOption configures how Json and the other JSON constructors decode values.
+type JsonOption func(*jsonOptions)
+
+type jsonOptions struct {
+	useNumber bool
+}
+
+// UseNumber decodes JSON numbers as json.Number rather than float64, so integers beyond the 53 bits a float64 holds
+// exactly, such as 64-bit IDs, keep their precision. A json.Number is both IsFloat and, when it holds an integer an
+// int64 can, IsInt, and AsInt, AsFloat, the arithmetic runners, Contains and In use it as a number.
+func UseNumber() JsonOption {
+	return func(o *jsonOptions) {
+		o.useNumber = true
+	}
+}
+
+func newJsonOptions(opts []JsonOption) jsonOptions {
+	var o jsonOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// decode decodes a JSON value, which like json.Unmarshal must not be followed by anything but whitespace.
+func (o jsonOptions) decode(raw []byte) (interface{}, error) {
+	var v interface{}
+	if !o.useNumber {
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid JSON: unexpected data after top-level value")
+	}
+	return v, nil
 }
 
 // Json creates a Pathor for navigating raw JSON data.
-func Json(raw []byte) Pathor {
-	return &Jsonor{raw: json.RawMessage(raw)}
+func Json(raw []byte, opts ...JsonOption) Pathor {
+	return &Jsonor{raw: json.RawMessage(raw), opts: newJsonOptions(opts)}
 }
 
 // Path returns the current lookup path.
@@ -26,8 +77,7 @@ func (j *Jsonor) ensure() Pathor {
 		return j.p
 	}
 	j.done = true
-	var v interface{}
-	if err := json.Unmarshal(j.raw, &v); err != nil {
+	if v, err := j.opts.decode(j.raw); err != nil {
 		j.p = NewInvalidor(j.path, err)
 	} else {
 		j.p = &Reflector{path: j.path, v: reflect.ValueOf(v)}
@@ -35,9 +85,79 @@ func (j *Jsonor) ensure() Pathor {
 	return j.p
 }
 
-// Find navigates the JSON structure using Reflector after decoding.
+// Find navigates the JSON structure. The fields of an object are found by scanning its bytes, anything else is
+// navigated using Reflector after decoding. When a field occurs more than once in an object the last is found.
 func (j *Jsonor) Find(path string, opts ...Runner) Pathor {
-	return j.ensure().Find(path, opts...)
+	var rr Pathor
+	switch {
+	case path == "":
+		rr = j
+	case j.done || j.kind() != '{':
+		rr = j.ensure().Find(path)
+	default:
+		rr = j.field(path)
+	}
+	p := ExtractPath(rr)
+	for _, runner := range opts {
+		rr = runner.Run(NewScope(j, rr))
+		if rr == nil {
+			rr = NewInvalidor(p, ErrEvalFail)
+		}
+	}
+	return rr
+}
+
+// kind is the first byte of the value, '{' for an object.
+func (j *Jsonor) kind() byte {
+	if i := skipJsonSpace(j.raw, 0); i < len(j.raw) {
+		return j.raw[i]
+	}
+	return 0
+}
+
+// field finds a field of an object. The first field found scans all of the object's members, so a field occurring
+// more than once is the last of them, as json.Unmarshal keeps it.
+func (j *Jsonor) field(name string) Pathor {
+	if j.fields == nil {
+		j.scan()
+	}
+	p := j.fieldPath(name)
+	if j.err != nil {
+		return NewInvalidor(p, j.err)
+	}
+	if f, ok := j.fields[name]; ok {
+		return f
+	}
+	return &Invalidor{
+		err:  fmt.Errorf("element not found at simple path %s element was %s expected %s", p, reflect.Map, reflect.String),
+		path: p,
+	}
+}
+
+// scan reads the members of the object into fields, skipping their values without decoding them.
+func (j *Jsonor) scan() {
+	j.fields = map[string]*Jsonor{}
+	object := &jsonObjectScanner{data: j.raw, pos: skipJsonSpace(j.raw, 0) + 1}
+	for {
+		key, value, ok, err := object.next()
+		if err != nil {
+			j.err = err
+			return
+		}
+		if !ok {
+			// Like json.Unmarshal nothing but whitespace may follow the object
+			if i := skipJsonSpace(j.raw, object.pos+1); i < len(j.raw) {
+				j.err = object.syntaxError(i, "unexpected data after top-level value")
+			}
+			return
+		}
+		j.fields[key] = &Jsonor{path: j.fieldPath(key), raw: value, opts: j.opts}
+	}
+}
+
+// fieldPath is the path of a field of the object, as Reflector gives the path of a map's value.
+func (j *Jsonor) fieldPath(name string) string {
+	return keyPath(j.path, name)
 }
 
 // Raw returns the decoded value.
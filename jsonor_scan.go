@@ -0,0 +1,155 @@
+package lookup
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// errJsonEnd is returned by the scanner when the data ends within a value.
+var errJsonEnd = errors.New("unexpected end of JSON input")
+
+// skipJsonSpace returns the offset of the first byte at or after i which isn't JSON whitespace.
+func skipJsonSpace(data []byte, i int) int {
+	for i < len(data) {
+		switch data[i] {
+		case ' ', '\t', '\n', '\r':
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// skipJsonValue returns the offset just past the JSON value starting at offset i, which must not be whitespace. Values
+// are skipped by matching brackets and quotes without decoding them, so their contents are only checked when decoded.
+func skipJsonValue(data []byte, i int) (int, error) {
+	if i >= len(data) {
+		return i, errJsonEnd
+	}
+	switch data[i] {
+	case '"':
+		return skipJsonString(data, i)
+	case '{', '[':
+		depth := 0
+		for i < len(data) {
+			switch data[i] {
+			case '"':
+				end, err := skipJsonString(data, i)
+				if err != nil {
+					return end, err
+				}
+				i = end
+				continue
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return i + 1, nil
+				}
+			}
+			i++
+		}
+		return i, errJsonEnd
+	}
+	// A number, true, false or null runs to the next delimiter
+	start := i
+	for i < len(data) {
+		switch data[i] {
+		case ',', '}', ']', ' ', '\t', '\n', '\r':
+			if i == start {
+				return i, fmt.Errorf("invalid character %q at offset %d: expected a value", data[i], i)
+			}
+			return i, nil
+		}
+		i++
+	}
+	if i == start {
+		return i, errJsonEnd
+	}
+	return i, nil
+}
+
+// skipJsonString returns the offset just past the string starting at offset i.
+func skipJsonString(data []byte, i int) (int, error) {
+	for i++; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		}
+	}
+	return i, errJsonEnd
+}
+
+// jsonObjectScanner reads the members of a JSON object one at a time.
+type jsonObjectScanner struct {
+	data []byte
+	pos  int // offset of the next member, or of the closing brace
+}
+
+// next reads the member at the scanner's position, returning its key and the bytes of its value. ok is false at the
+// end of the object.
+func (s *jsonObjectScanner) next() (key string, value []byte, ok bool, err error) {
+	i := skipJsonSpace(s.data, s.pos)
+	if i < len(s.data) && s.data[i] == '}' {
+		s.pos = i
+		return "", nil, false, nil
+	}
+	if i >= len(s.data) || s.data[i] != '"' {
+		return "", nil, false, s.syntaxError(i, "expected a string key")
+	}
+	end, err := skipJsonString(s.data, i)
+	if err != nil {
+		return "", nil, false, err
+	}
+	key, err = jsonKey(s.data[i:end])
+	if err != nil {
+		return "", nil, false, err
+	}
+	i = skipJsonSpace(s.data, end)
+	if i >= len(s.data) || s.data[i] != ':' {
+		return "", nil, false, s.syntaxError(i, "expected ':' after object key")
+	}
+	start := skipJsonSpace(s.data, i+1)
+	end, err = skipJsonValue(s.data, start)
+	if err != nil {
+		return "", nil, false, err
+	}
+	i = skipJsonSpace(s.data, end)
+	switch {
+	case i < len(s.data) && s.data[i] == ',':
+		// Another member must follow a comma
+		if i = skipJsonSpace(s.data, i+1); i >= len(s.data) || s.data[i] != '"' {
+			return "", nil, false, s.syntaxError(i, "expected a string key after ','")
+		}
+	case i < len(s.data) && s.data[i] == '}':
+	default:
+		return "", nil, false, s.syntaxError(i, "expected ',' or '}' after object value")
+	}
+	s.pos = i
+	return key, s.data[start:end], true, nil
+}
+
+func (s *jsonObjectScanner) syntaxError(i int, msg string) error {
+	if i >= len(s.data) {
+		return errJsonEnd
+	}
+	return fmt.Errorf("invalid character %q at offset %d: %s", s.data[i], i, msg)
+}
+
+// jsonKey decodes a quoted key, only unmarshalling those with escapes.
+func jsonKey(quoted []byte) (string, error) {
+	if bytes.IndexByte(quoted, '\\') < 0 {
+		return string(quoted[1 : len(quoted)-1]), nil
+	}
+	var key string
+	if err := json.Unmarshal(quoted, &key); err != nil {
+		return "", err
+	}
+	return key, nil
+}
@@ -0,0 +1,249 @@
+package lookup
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"iter"
+	"reflect"
+)
+
+// ErrStreamConsumed is the error of a JsonStream used as a Pathor after iterating over its elements, which weren't
+// kept.
+var ErrStreamConsumed = errors.New("stream already consumed by iterating over its elements")
+
+// JsonReader creates a Pathor for navigating the JSON document read from r. A document which is an array results in a
+// JsonStream reading the array as it's iterated over, anything else is read in full into a Jsonor. The options are
+// those of Json.
+func JsonReader(r io.Reader, opts ...JsonOption) Pathor {
+	return jsonReader(r, nil, newJsonOptions(opts))
+}
+
+// JsonFile creates a Pathor for navigating the JSON document in the named file of fsys, as JsonReader does. A file
+// holding an array is left open until the stream reaches its end or is closed.
+func JsonFile(fsys fs.FS, name string, opts ...JsonOption) Pathor {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return NewInvalidor("", err)
+	}
+	return jsonReader(f, f, newJsonOptions(opts))
+}
+
+func jsonReader(r io.Reader, closer io.Closer, opts jsonOptions) Pathor {
+	br := bufio.NewReader(r)
+	first, err := peekJson(br)
+	if err == nil && first == '[' {
+		dec := json.NewDecoder(br)
+		if _, err = dec.Token(); err == nil {
+			return &JsonStream{dec: dec, closer: closer, opts: opts}
+		}
+	}
+	var raw []byte
+	if err == nil {
+		raw, err = io.ReadAll(br)
+	}
+	if closer != nil {
+		_ = closer.Close()
+	}
+	if err != nil {
+		return NewInvalidor("", err)
+	}
+	return &Jsonor{raw: raw, opts: opts}
+}
+
+// peekJson returns the first byte of r which isn't whitespace without consuming it.
+func peekJson(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		switch b[0] {
+		case ' ', '\t', '\n', '\r':
+			_, _ = r.Discard(1)
+		default:
+			return b[0], nil
+		}
+	}
+}
+
+// JsonStream is a Pathor over a JSON array read from a stream. All reads the elements one at a time as they're
+// iterated over, without keeping them, so a large array can be processed in constant memory. Find with Filter or Map
+// streams in the same way, keeping only the results. Used as any other Pathor, Find with Index included, the stream
+// reads and decodes all of the array into memory first, which can't be done once its elements have been streamed.
+type JsonStream struct {
+	path     string
+	dec      *json.Decoder
+	closer   io.Closer
+	read     int  // elements read so far
+	iterated bool // All has read elements, which weren't kept
+	ended    bool
+	err      error
+	p        Pathor // the whole array, once decoded
+	opts     jsonOptions
+}
+
+// Path returns the current lookup path.
+func (s *JsonStream) Path() string { return s.path }
+
+// All iterates over the elements of the array, each a Jsonor, reading them from the stream as they're needed. Once
+// the array has been decoded the elements are those of the decoded array instead. Err reports whether reading failed.
+func (s *JsonStream) All() iter.Seq2[int, Pathor] {
+	return func(yield func(int, Pathor) bool) {
+		if s.p != nil {
+			for i, item := range s.p.RawAsInterfaceSlice() {
+				if !yield(i, &Reflector{path: fmt.Sprintf("%s[%d]", s.path, i), v: reflect.ValueOf(item)}) {
+					return
+				}
+			}
+			return
+		}
+		for {
+			item, ok := s.next()
+			if !ok {
+				return
+			}
+			s.iterated = true
+			if !yield(s.read-1, item) {
+				return
+			}
+		}
+	}
+}
+
+// Err returns the error reading the stream stopped with, if any.
+func (s *JsonStream) Err() error { return s.err }
+
+// Close closes the file a stream created by JsonFile reads from, which happens anyway when the end of the array is
+// reached.
+func (s *JsonStream) Close() error {
+	if s.ended {
+		return nil
+	}
+	s.ended = true
+	if s.closer != nil {
+		return s.closer.Close()
+	}
+	return nil
+}
+
+// next reads the next element of the array, ok is false at its end or when reading fails.
+func (s *JsonStream) next() (Pathor, bool) {
+	if s.ended {
+		return nil, false
+	}
+	if !s.dec.More() {
+		if _, err := s.dec.Token(); err != nil {
+			s.err = err
+		}
+		_ = s.Close()
+		return nil, false
+	}
+	var raw json.RawMessage
+	if err := s.dec.Decode(&raw); err != nil {
+		s.err = err
+		_ = s.Close()
+		return nil, false
+	}
+	item := &Jsonor{path: fmt.Sprintf("%s[%d]", s.path, s.read), raw: raw, opts: s.opts}
+	s.read++
+	return item, true
+}
+
+func (s *JsonStream) ensure() Pathor {
+	if s.p != nil {
+		return s.p
+	}
+	if s.iterated {
+		return NewInvalidor(s.path, ErrStreamConsumed)
+	}
+	items := []interface{}{}
+	for {
+		item, ok := s.next()
+		if !ok {
+			break
+		}
+		v, err := s.opts.decode(item.(*Jsonor).raw)
+		if err != nil {
+			s.err = err
+			break
+		}
+		items = append(items, v)
+	}
+	if s.err != nil {
+		s.p = NewInvalidor(s.path, s.err)
+	} else {
+		s.p = &Reflector{path: s.path, v: reflect.ValueOf(items)}
+	}
+	return s.p
+}
+
+// Find navigates the array. A Filter or Map given first runs over the elements as they're read, as All does, and
+// the runners following it over the results. Anything else, such as a path or Index, navigates the array after
+// reading and decoding all of it.
+func (s *JsonStream) Find(path string, opts ...Runner) Pathor {
+	if path != "" || len(opts) == 0 || !isElementRunner(opts[0]) || s.p != nil || s.iterated {
+		return s.ensure().Find(path, opts...)
+	}
+	var rr Pathor = s
+	p := s.path
+	for _, runner := range opts {
+		rr = runner.Run(NewScope(s, rr))
+		if rr == nil {
+			rr = NewInvalidor(p, ErrEvalFail)
+		}
+	}
+	return rr
+}
+
+// isElementRunner is true of the runners which run over each element of an array.
+func isElementRunner(r Runner) bool {
+	switch r.(type) {
+	case *filterFunc, *mapFunc:
+		return true
+	}
+	return false
+}
+
+// forEachPath runs the runners over each element as it's read, keeping only the results.
+func (s *JsonStream) forEachPath(runners []Runner, scope *Scope) Pathor {
+	result := forEachElementPath(s.path, nil, reflect.TypeOf([]interface{}{}), 0, s.All(), runners, scope)
+	if s.err != nil {
+		return NewInvalidor(s.path, s.err)
+	}
+	return result
+}
+
+// Raw returns the decoded array.
+func (s *JsonStream) Raw() interface{} { return s.ensure().Raw() }
+
+// RawAsInterfaceSlice returns the decoded array as a slice of interface{}.
+func (s *JsonStream) RawAsInterfaceSlice() []interface{} { return s.ensure().RawAsInterfaceSlice() }
+
+// Value returns the reflect.Value of the decoded array.
+func (s *JsonStream) Value() reflect.Value { return s.ensure().Value() }
+
+// Type returns the reflect.Type of the decoded array.
+func (s *JsonStream) Type() reflect.Type { return s.ensure().Type() }
+
+func (s *JsonStream) IsString() bool    { return s.ensure().IsString() }
+func (s *JsonStream) IsInt() bool       { return s.ensure().IsInt() }
+func (s *JsonStream) IsBool() bool      { return s.ensure().IsBool() }
+func (s *JsonStream) IsFloat() bool     { return s.ensure().IsFloat() }
+func (s *JsonStream) IsSlice() bool     { return s.ensure().IsSlice() }
+func (s *JsonStream) IsMap() bool       { return s.ensure().IsMap() }
+func (s *JsonStream) IsStruct() bool    { return s.ensure().IsStruct() }
+func (s *JsonStream) IsNil() bool       { return s.ensure().IsNil() }
+func (s *JsonStream) IsPtr() bool       { return s.ensure().IsPtr() }
+func (s *JsonStream) IsInterface() bool { return s.ensure().IsInterface() }
+
+func (s *JsonStream) AsString() (string, error)              { return s.ensure().AsString() }
+func (s *JsonStream) AsInt() (int64, error)                  { return s.ensure().AsInt() }
+func (s *JsonStream) AsBool() (bool, error)                  { return s.ensure().AsBool() }
+func (s *JsonStream) AsFloat() (float64, error)              { return s.ensure().AsFloat() }
+func (s *JsonStream) AsSlice() ([]interface{}, error)        { return s.ensure().AsSlice() }
+func (s *JsonStream) AsMap() (map[string]interface{}, error) { return s.ensure().AsMap() }
+func (s *JsonStream) AsPtr() (interface{}, error)            { return s.ensure().AsPtr() }
@@ -0,0 +1,217 @@
+package lookup
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingReader counts the bytes read from it.
+type countingReader struct {
+	r    io.Reader
+	read int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read += n
+	return n, err
+}
+
+func TestJsonReaderObject(t *testing.T) {
+	r := JsonReader(strings.NewReader(`  {"name": "root", "list": [1, 2]}`))
+	assert.IsType(t, &Jsonor{}, r)
+	assert.Equal(t, "root", r.Find("name").Raw())
+	assert.Equal(t, 2.0, r.Find("list", Index(1)).Raw())
+
+	assert.IsType(t, &Invalidor{}, JsonReader(strings.NewReader("")))
+}
+
+func TestJsonStreamAll(t *testing.T) {
+	r := JsonReader(strings.NewReader(` [{"id": 1}, {"id": 2}, {"id": 3}] `))
+	stream, ok := r.(*JsonStream)
+	if !assert.True(t, ok) {
+		return
+	}
+	var ids []interface{}
+	var paths []string
+	for i, item := range stream.All() {
+		ids = append(ids, item.Find("id").Raw())
+		paths = append(paths, fmt.Sprintf("%d:%s", i, ExtractPath(item)))
+	}
+	assert.Equal(t, []interface{}{1.0, 2.0, 3.0}, ids)
+	assert.Equal(t, []string{"0:[0]", "1:[1]", "2:[2]"}, paths)
+	assert.NoError(t, stream.Err())
+
+	// The elements weren't kept
+	res := stream.Find("", Index(0))
+	if assert.IsType(t, &Invalidor{}, res) {
+		assert.ErrorIs(t, res.(*Invalidor), ErrStreamConsumed)
+	}
+}
+
+func TestJsonStreamReadsAsNeeded(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("[")
+	for i := 0; i < 100000; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, `{"id": %d}`, i)
+	}
+	sb.WriteString("]")
+	in := &countingReader{r: strings.NewReader(sb.String())}
+
+	stream := JsonReader(in).(*JsonStream)
+	for i, item := range stream.All() {
+		if i == 2 {
+			assert.Equal(t, 2.0, item.Find("id").Raw())
+			break
+		}
+	}
+	assert.Less(t, in.read, sb.Len()/10)
+
+	// Iteration carries on from where it stopped
+	for i := range stream.All() {
+		assert.Equal(t, 3, i)
+		break
+	}
+}
+
+func TestJsonStreamAllDoesNotBuffer(t *testing.T) {
+	var sb strings.Builder
+	var ends []int // the offset of the end of each element
+	sb.WriteString("[")
+	for i := 0; i < 100000; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, `{"id": %d, "name": "item %d"}`, i, i)
+		ends = append(ends, sb.Len())
+	}
+	sb.WriteString("]")
+	in := &countingReader{r: strings.NewReader(sb.String())}
+
+	// Each element is read no more than a buffer's worth ahead of where iteration has got to, all the way to the end
+	stream := JsonReader(in).(*JsonStream)
+	count := 0
+	for i, item := range stream.All() {
+		if i%10000 == 0 {
+			assert.LessOrEqual(t, in.read, ends[i]+64*1024, "read ahead at element %d", i)
+			assert.Equal(t, float64(i), item.Find("id").Raw())
+		}
+		count++
+	}
+	assert.Equal(t, 100000, count)
+	assert.NoError(t, stream.Err())
+	assert.Nil(t, stream.p, "the elements aren't kept")
+}
+
+// readAhead is a runner checking how far ahead of the element it's run on the stream has been read.
+type readAhead struct {
+	t    *testing.T
+	in   *countingReader
+	ends []int
+}
+
+func (r *readAhead) Run(scope *Scope) Pathor {
+	id, _ := scope.Current.Find("id").AsFloat()
+	assert.LessOrEqual(r.t, r.in.read, r.ends[int(id)]+64*1024, "read ahead at element %v", id)
+	return scope.Current
+}
+
+func TestJsonStreamFilterAndMapDoNotBuffer(t *testing.T) {
+	var sb strings.Builder
+	var ends []int
+	sb.WriteString("[")
+	for i := 0; i < 100000; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, `{"id": %d, "even": %t}`, i, i%2 == 0)
+		ends = append(ends, sb.Len())
+	}
+	sb.WriteString("]")
+
+	in := &countingReader{r: strings.NewReader(sb.String())}
+	stream := JsonReader(in).(*JsonStream)
+	res := stream.Find("", Filter(Chain(&readAhead{t: t, in: in, ends: ends}, This("even"))), Map(This("id")), Index(-1))
+	assert.Equal(t, 99998.0, res.Raw())
+	assert.NoError(t, stream.Err())
+	assert.Nil(t, stream.p, "the elements aren't kept")
+
+	in = &countingReader{r: strings.NewReader(sb.String())}
+	stream = JsonReader(in).(*JsonStream)
+	res = stream.Find("", Map(Chain(&readAhead{t: t, in: in, ends: ends}, This("id"))))
+	assert.Len(t, res.RawAsInterfaceSlice(), 100000)
+	assert.Nil(t, stream.p, "the elements aren't kept")
+
+	// The elements have been streamed so can't be found again
+	res = stream.Find("", Index(0))
+	if assert.IsType(t, &Invalidor{}, res) {
+		assert.ErrorIs(t, res.(*Invalidor), ErrStreamConsumed)
+	}
+
+	stream = JsonReader(strings.NewReader(`[{"id": 1}, {"id": }]`)).(*JsonStream)
+	assert.IsType(t, &Invalidor{}, stream.Find("", Map(This("id"))))
+	assert.Error(t, stream.Err())
+}
+
+func TestJsonStreamAsPathor(t *testing.T) {
+	stream := JsonReader(strings.NewReader(`[{"id": 1}, {"id": 2}]`)).(*JsonStream)
+	assert.True(t, stream.IsSlice())
+	assert.Equal(t, 2.0, stream.Find("", Index(-1)).Find("id").Raw())
+	assert.Equal(t, []interface{}{map[string]interface{}{"id": 1.0}, map[string]interface{}{"id": 2.0}}, stream.Raw())
+
+	// Once decoded the elements can be iterated over any number of times
+	for range 2 {
+		count := 0
+		for range stream.All() {
+			count++
+		}
+		assert.Equal(t, 2, count)
+	}
+}
+
+func TestJsonStreamErrors(t *testing.T) {
+	stream := JsonReader(strings.NewReader(`[{"id": 1}, {"id": }]`)).(*JsonStream)
+	count := 0
+	for range stream.All() {
+		count++
+	}
+	assert.Equal(t, 1, count)
+	assert.Error(t, stream.Err())
+
+	stream = JsonReader(strings.NewReader(`[1, 2`)).(*JsonStream)
+	assert.IsType(t, &Invalidor{}, stream.Find("", Index(0)))
+}
+
+func TestJsonFile(t *testing.T) {
+	fsys := fstest.MapFS{
+		"doc.json":   {Data: []byte(`{"meta": {"version": 3}}`)},
+		"items.json": {Data: []byte(`[1, 2, 3]`)},
+	}
+	assert.Equal(t, 3.0, JsonFile(fsys, "doc.json").Find("meta").Find("version").Raw())
+
+	items := JsonFile(fsys, "items.json")
+	if assert.IsType(t, &JsonStream{}, items) {
+		var sum float64
+		for _, item := range items.(*JsonStream).All() {
+			v, _ := item.AsFloat()
+			sum += v
+		}
+		assert.Equal(t, 6.0, sum)
+		assert.NoError(t, items.(*JsonStream).Close())
+	}
+
+	missing := JsonFile(fsys, "missing.json")
+	if assert.IsType(t, &Invalidor{}, missing) {
+		assert.True(t, errors.Is(missing.(*Invalidor), fs.ErrNotExist))
+	}
+}
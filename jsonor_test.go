@@ -1,8 +1,12 @@
 package lookup
 
 import (
-	"github.com/stretchr/testify/assert"
+	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestJsonorBasic(t *testing.T) {
@@ -13,3 +17,184 @@ func TestJsonorBasic(t *testing.T) {
 	assert.Equal(t, 2.0, r.Find("list", Index(1)).Raw())
 	assert.Equal(t, "def", r.Find("missing", Default("def")).Raw())
 }
+
+func TestJsonorScanning(t *testing.T) {
+	data := []byte(` {
+		"text": "a \"quoted\" {brace} [bracket], comma",
+		"escaped Akey": true,
+		"nested": {"deep": {"value": null, "list": [{"a": 1}, {"a": 2}]}},
+		"number": -1.5e3,
+		"empty": {},
+		"meta": {"version": "1.2"}
+	} `)
+
+	tests := []struct {
+		name     string
+		find     func(r Pathor) Pathor
+		expected interface{}
+	}{
+		{"Strings with delimiters", func(r Pathor) Pathor { return r.Find("text") }, `a "quoted" {brace} [bracket], comma`},
+		{"Escaped key", func(r Pathor) Pathor { return r.Find("escaped Akey") }, true},
+		{"Nested objects", func(r Pathor) Pathor { return r.Find("nested").Find("deep").Find("value") }, nil},
+		{"Array in an object", func(r Pathor) Pathor { return r.Find("nested").Find("deep").Find("list", Index(-1)).Find("a") }, 2.0},
+		{"Number", func(r Pathor) Pathor { return r.Find("number") }, -1500.0},
+		{"Empty object", func(r Pathor) Pathor { return r.Find("empty") }, map[string]interface{}{}},
+		{"Last field", func(r Pathor) Pathor { return r.Find("meta").Find("version") }, "1.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Each against a fresh Jsonor and one which has already scanned the whole object
+			assert.Equal(t, tt.expected, tt.find(Json(data)).Raw())
+			r := Json(data)
+			r.Find("missing")
+			assert.Equal(t, tt.expected, tt.find(r).Raw())
+		})
+	}
+}
+
+func TestJsonorOnlyDecodesWhatIsFound(t *testing.T) {
+	// The value of "broken" is never decoded, so its being invalid doesn't matter until it's found
+	r := Json([]byte(`{"meta": {"version": 2}, "broken": [1, 2, tru], "after": "ok"}`))
+	assert.Equal(t, 2.0, r.Find("meta").Find("version").Raw())
+	assert.Equal(t, "ok", r.Find("after").Raw())
+	assert.IsType(t, &Invalidor{}, r.Find("broken").Find("x"))
+
+	// A syntax error anywhere in the object's members fails every field, as json.Unmarshal would
+	r = Json([]byte(`{"a": 1, "b" 2, "c": 3}`))
+	assert.IsType(t, &Invalidor{}, r.Find("a"))
+	assert.IsType(t, &Invalidor{}, r.Find("c"))
+	assert.IsType(t, &Invalidor{}, Json([]byte(`{"a": {"b": 1}`)).Find("c"))
+}
+
+func TestJsonorMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"Missing value", `{"a":1, "b": }`},
+		{"Missing value at the end", `{"a":1, "b":`},
+		{"Missing value before a comma", `{"b": , "a": 1}`},
+		{"Trailing comma", `{"a":1, "b":2,}`},
+		{"Unterminated string", `{"a":1, "b":"abc}`},
+		{"Unterminated key", `{"a":1, "b`},
+		{"Trailing data", `{"a":1, "b":2} garbage`},
+		{"Trailing object", `{"a":1, "b":2} {}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := Json([]byte(test.data))
+			assert.IsType(t, &Invalidor{}, r.Find("b"))
+			assert.IsType(t, &Invalidor{}, r.Find("a"))
+		})
+	}
+}
+
+func TestJsonorDuplicateFields(t *testing.T) {
+	// The last of a field occurring more than once is found, as it's the one json.Unmarshal keeps
+	r := Json([]byte(`{"a": 1, "b": {"c": 1}, "a": 2, "b": {"c": 2}}`))
+	assert.Equal(t, 2.0, r.Find("a").Raw())
+	assert.Equal(t, 2.0, r.Find("b").Find("c").Raw())
+	assert.Equal(t, Json([]byte(`{"a": 1, "a": 2}`)).Raw().(map[string]interface{})["a"], Json([]byte(`{"a": 1, "a": 2}`)).Find("a").Raw())
+}
+
+func TestJsonorCachesFields(t *testing.T) {
+	r := Json([]byte(`{"a": {"b": {"c": 1}}, "d": 2}`))
+	b := r.Find("a").Find("b")
+	assert.Same(t, b, r.Find("a").Find("b"))
+	assert.Same(t, r.Find("d"), r.Find("d"))
+	assert.Equal(t, `"a"."b"`, ExtractPath(b))
+	assert.Equal(t, `"a"."b"."c"`, ExtractPath(b.Find("c")))
+	assert.Equal(t, `"d"`, ExtractPath(r.Find("d")))
+}
+
+func TestJsonorNonObjects(t *testing.T) {
+	assert.Equal(t, 2.0, Json([]byte(`[1, 2]`)).Find("", Index(1)).Raw())
+	assert.IsType(t, &Invalidor{}, Json([]byte(`"text"`)).Find("a"))
+	assert.IsType(t, &Invalidor{}, Json([]byte(`{"a": 1}`)).Find("b"))
+	assert.IsType(t, &Invalidor{}, Json([]byte(``)).Find("b"))
+}
+
+func BenchmarkJsonorFindInLargeDocument(b *testing.B) {
+	var sb strings.Builder
+	sb.WriteString(`{"meta": {"version": 1}, "items": [`)
+	for i := 0; i < 10000; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, `{"id": %d, "name": "item %d", "tags": ["a", "b"]}`, i, i)
+	}
+	sb.WriteString(`]}`)
+	data := []byte(sb.String())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Json(data).Find("meta").Find("version").Raw()
+	}
+}
+
+func TestJsonorUseNumber(t *testing.T) {
+	data := []byte(`{"id":9007199254740993,"price":2.5,"count":3,"name":"n","list":[1,2]}`)
+
+	t.Run("Default decodes float64", func(t *testing.T) {
+		r := Json(data)
+		assert.Equal(t, 9007199254740992.0, r.Find("id").Raw())
+		assert.False(t, r.Find("count").IsInt())
+	})
+
+	r := Json(data, UseNumber())
+	t.Run("Integers keep their precision", func(t *testing.T) {
+		id := r.Find("id")
+		assert.Equal(t, json.Number("9007199254740993"), id.Raw())
+		assert.True(t, id.IsInt())
+		assert.True(t, id.IsFloat())
+		assert.False(t, id.IsString())
+		i, err := id.AsInt()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(9007199254740993), i)
+	})
+	t.Run("Fractions are floats", func(t *testing.T) {
+		price := r.Find("price")
+		assert.False(t, price.IsInt())
+		assert.True(t, price.IsFloat())
+		f, err := price.AsFloat()
+		assert.NoError(t, err)
+		assert.Equal(t, 2.5, f)
+		_, err = price.AsInt()
+		assert.ErrorIs(t, err, ErrNotInt)
+	})
+	t.Run("Strings are unaffected", func(t *testing.T) {
+		assert.True(t, r.Find("name").IsString())
+		assert.False(t, r.Find("name").IsInt())
+	})
+	t.Run("Arrays", func(t *testing.T) {
+		assert.Equal(t, json.Number("2"), r.Find("list", Index(json.Number("1"))).Raw())
+	})
+	t.Run("Arithmetic", func(t *testing.T) {
+		id := NewConstantor("", r.Find("id").Raw())
+		count := NewConstantor("", r.Find("count").Raw())
+		price := NewConstantor("", r.Find("price").Raw())
+		scope := NewScope(nil, r)
+		assert.Equal(t, int64(9007199254740996), Add(id, count).Run(scope).Raw())
+		assert.Equal(t, int64(9007199254740990), Subtract(id, count).Run(scope).Raw())
+		assert.Equal(t, int64(27021597764222979), Multiply(id, count).Run(scope).Raw())
+		assert.Equal(t, 7.5, Multiply(price, count).Run(scope).Raw())
+		assert.Equal(t, 1.5, Divide(count, NewConstantor("", json.Number("2"))).Run(scope).Raw())
+		assert.Equal(t, int64(1), Modulo(count, NewConstantor("", json.Number("2"))).Run(scope).Raw())
+	})
+	t.Run("Overflow falls back to float", func(t *testing.T) {
+		big := NewConstantor("", json.Number("9223372036854775807"))
+		assert.Equal(t, 2*9223372036854775807.0, Add(big, big).Run(NewScope(nil, r)).Raw())
+	})
+	t.Run("Streams", func(t *testing.T) {
+		s := JsonReader(strings.NewReader(`[{"id":9007199254740993}]`), UseNumber())
+		for _, item := range s.(*JsonStream).All() {
+			assert.Equal(t, json.Number("9007199254740993"), item.Find("id").Raw())
+		}
+		s = JsonReader(strings.NewReader(`[{"id":9007199254740993}]`), UseNumber())
+		assert.Equal(t, json.Number("9007199254740993"), s.Find("", Index(0)).Find("id").Raw())
+	})
+	t.Run("Trailing data", func(t *testing.T) {
+		_, ok := Json([]byte(`1 2`), UseNumber()).(*Jsonor).ensure().(*Invalidor)
+		assert.True(t, ok)
+	})
+}
@@ -0,0 +1,190 @@
+package lookup
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// DeepCopy returns a copy of v which shares no maps, slices, arrays or pointers with it, so the copy can be modified
+// with SetField and DeleteField leaving v as it was. A Pathor is copied by its Raw value. Values reached through the
+// same pointer or map in v are reached through the same copy, which also keeps cycles intact. Functions, channels
+// and unexported struct fields are shared with v.
+func DeepCopy(v interface{}) interface{} {
+	if p, ok := v.(Pathor); ok {
+		v = p.Raw()
+	}
+	if v == nil {
+		return nil
+	}
+	c := &copier{seen: map[copyKey]reflect.Value{}}
+	return c.copy(reflect.ValueOf(v)).Interface()
+}
+
+type copyKey struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+type copier struct {
+	seen map[copyKey]reflect.Value
+}
+
+func (c *copier) copy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(c.copy(v.Elem()))
+		return out
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		key := copyKey{ptr: v.Pointer(), typ: v.Type()}
+		if out, ok := c.seen[key]; ok {
+			return out
+		}
+		out := reflect.New(v.Type().Elem())
+		c.seen[key] = out
+		out.Elem().Set(c.copy(v.Elem()))
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		key := copyKey{ptr: v.Pointer(), typ: v.Type()}
+		if out, ok := c.seen[key]; ok {
+			return out
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		c.seen[key] = out
+		iter := v.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), c.copy(iter.Value()))
+		}
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(c.copy(v.Index(i)))
+		}
+		return out
+	case reflect.Array:
+		out := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(c.copy(v.Index(i)))
+		}
+		return out
+	case reflect.Struct:
+		out := reflect.New(v.Type()).Elem()
+		out.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if out.Field(i).CanSet() {
+				out.Field(i).Set(c.copy(v.Field(i)))
+			}
+		}
+		return out
+	}
+	return v
+}
+
+// SetField sets the entry name of a map, or the exported field name of a struct, in place. The struct must be
+// reached through a pointer, or be addressable as the fields found by a Reflector from a pointer are. The value is
+// converted to the type of the entry or field when it's a number or string of a different type.
+func SetField(target Pathor, name string, value interface{}) error {
+	if p, ok := value.(Pathor); ok {
+		value = p.Raw()
+	}
+	v := modifiable(target.Value())
+	switch v.Kind() {
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("setting %s: %w", name, ErrNotModifiable)
+		}
+		val, err := assignableTo(value, v.Type().Elem())
+		if err != nil {
+			return fmt.Errorf("setting %s: %w", name, err)
+		}
+		v.SetMapIndex(reflect.ValueOf(name).Convert(v.Type().Key()), val)
+		return nil
+	case reflect.Struct:
+		f := v.FieldByName(name)
+		if !f.IsValid() || !f.CanSet() {
+			return fmt.Errorf("setting %s: %w", name, ErrNotModifiable)
+		}
+		val, err := assignableTo(value, f.Type())
+		if err != nil {
+			return fmt.Errorf("setting %s: %w", name, err)
+		}
+		f.Set(val)
+		return nil
+	}
+	return fmt.Errorf("setting %s: %w", name, ErrNotModifiable)
+}
+
+// DeleteField removes the entry name from a map in place. Struct fields can't be removed so the exported field name
+// is set to its zero value instead. Deleting an entry which doesn't exist does nothing.
+func DeleteField(target Pathor, name string) error {
+	v := modifiable(target.Value())
+	switch v.Kind() {
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("deleting %s: %w", name, ErrNotModifiable)
+		}
+		v.SetMapIndex(reflect.ValueOf(name).Convert(v.Type().Key()), reflect.Value{})
+		return nil
+	case reflect.Struct:
+		f := v.FieldByName(name)
+		if !f.IsValid() {
+			return nil
+		}
+		if !f.CanSet() {
+			return fmt.Errorf("deleting %s: %w", name, ErrNotModifiable)
+		}
+		f.Set(reflect.Zero(f.Type()))
+		return nil
+	}
+	return fmt.Errorf("deleting %s: %w", name, ErrNotModifiable)
+}
+
+// modifiable follows interfaces and pointers to the map or struct which SetField and DeleteField change.
+func modifiable(v reflect.Value) reflect.Value {
+	for (v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer) && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
+
+// assignableTo converts value to a reflect.Value which can be stored in a location of type t.
+func assignableTo(value interface{}, t reflect.Type) (reflect.Value, error) {
+	if value == nil {
+		switch t.Kind() {
+		case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+			return reflect.Zero(t), nil
+		}
+		return reflect.Value{}, fmt.Errorf("null can't be stored as %s: %w", t, ErrNotModifiable)
+	}
+	v := reflect.ValueOf(value)
+	if v.Type().AssignableTo(t) {
+		return v, nil
+	}
+	if isNumberKind(v.Kind()) && isNumberKind(t.Kind()) || v.Kind() == reflect.String && t.Kind() == reflect.String {
+		return v.Convert(t), nil
+	}
+	return reflect.Value{}, fmt.Errorf("%s can't be stored as %s: %w", v.Type(), t, ErrNotModifiable)
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
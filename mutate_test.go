@@ -0,0 +1,182 @@
+package lookup
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type mutateNode struct {
+	Name     string
+	Size     int
+	Children []*mutateNode
+	Tags     map[string]string
+}
+
+func TestDeepCopy(t *testing.T) {
+	shared := &mutateNode{Name: "shared"}
+	tests := []struct {
+		name string
+		in   interface{}
+	}{
+		{name: "nil", in: nil},
+		{name: "scalar", in: 5},
+		{name: "json", in: map[string]interface{}{"a": []interface{}{1.0, map[string]interface{}{"b": "c"}}, "d": nil}},
+		{name: "struct", in: &mutateNode{Name: "root", Size: 2, Children: []*mutateNode{shared, shared}, Tags: map[string]string{"k": "v"}}},
+		{name: "array", in: [2][]int{{1}, {2, 3}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DeepCopy(tt.in)
+			if diff := cmp.Diff(tt.in, got); diff != "" {
+				t.Errorf("DeepCopy() mismatch: %s", diff)
+			}
+		})
+	}
+
+	t.Run("copy is independent", func(t *testing.T) {
+		in := map[string]interface{}{"a": map[string]interface{}{"b": 1.0}, "list": []interface{}{"x"}}
+		got := DeepCopy(Reflect(in)).(map[string]interface{})
+		got["a"].(map[string]interface{})["b"] = 2.0
+		got["list"].([]interface{})[0] = "y"
+		want := map[string]interface{}{"a": map[string]interface{}{"b": 1.0}, "list": []interface{}{"x"}}
+		if diff := cmp.Diff(want, in); diff != "" {
+			t.Errorf("original changed: %s", diff)
+		}
+	})
+
+	t.Run("shared pointers stay shared", func(t *testing.T) {
+		in := &mutateNode{Children: []*mutateNode{shared, shared}}
+		got := DeepCopy(in).(*mutateNode)
+		if got.Children[0] == shared || got.Children[0] != got.Children[1] {
+			t.Errorf("expected a single new copy of the shared child")
+		}
+	})
+
+	t.Run("cycles", func(t *testing.T) {
+		in := &mutateNode{Name: "loop"}
+		in.Children = []*mutateNode{in}
+		got := DeepCopy(in).(*mutateNode)
+		if got == in || got.Children[0] != got {
+			t.Errorf("expected the copy to refer to itself")
+		}
+	})
+}
+
+func TestSetField(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  func() interface{}
+		field   string
+		value   interface{}
+		want    interface{}
+		wantErr error
+	}{
+		{
+			name:   "map entry",
+			target: func() interface{} { return map[string]interface{}{"a": 1.0} },
+			field:  "b", value: "x",
+			want: map[string]interface{}{"a": 1.0, "b": "x"},
+		},
+		{
+			name:   "typed map converts numbers",
+			target: func() interface{} { return map[string]int{"a": 1} },
+			field:  "a", value: 2.0,
+			want: map[string]int{"a": 2},
+		},
+		{
+			name:   "map null",
+			target: func() interface{} { return map[string]interface{}{} },
+			field:  "a", value: nil,
+			want: map[string]interface{}{"a": nil},
+		},
+		{
+			name:   "struct pointer",
+			target: func() interface{} { return &mutateNode{Name: "a"} },
+			field:  "Size", value: 3.0,
+			want: &mutateNode{Name: "a", Size: 3},
+		},
+		{
+			name:   "pathor value",
+			target: func() interface{} { return &mutateNode{} },
+			field:  "Name", value: Reflect("b"),
+			want: &mutateNode{Name: "b"},
+		},
+		{
+			name:   "unknown field",
+			target: func() interface{} { return &mutateNode{} },
+			field:  "Missing", value: 1,
+			wantErr: ErrNotModifiable,
+		},
+		{
+			name:   "wrong type",
+			target: func() interface{} { return &mutateNode{} },
+			field:  "Name", value: 1,
+			wantErr: ErrNotModifiable,
+		},
+		{
+			name:   "struct value",
+			target: func() interface{} { return mutateNode{} },
+			field:  "Name", value: "a",
+			wantErr: ErrNotModifiable,
+		},
+		{
+			name:   "scalar",
+			target: func() interface{} { return "a" },
+			field:  "Name", value: "a",
+			wantErr: ErrNotModifiable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := tt.target()
+			err := SetField(Reflect(target), tt.field, tt.value)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SetField() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.want, target); diff != "" {
+				t.Errorf("SetField() mismatch: %s", diff)
+			}
+		})
+	}
+
+	t.Run("addressable struct found through a pointer", func(t *testing.T) {
+		type wrapper struct{ Node mutateNode }
+		w := &wrapper{}
+		if err := SetField(Reflect(w).Find("Node"), "Name", "inner"); err != nil {
+			t.Fatalf("SetField() error = %v", err)
+		}
+		if w.Node.Name != "inner" {
+			t.Errorf("Name = %q, want inner", w.Node.Name)
+		}
+	})
+}
+
+func TestDeleteField(t *testing.T) {
+	m := map[string]interface{}{"a": 1.0, "b": 2.0}
+	if err := DeleteField(Reflect(m), "a"); err != nil {
+		t.Fatalf("DeleteField() error = %v", err)
+	}
+	if err := DeleteField(Reflect(m), "missing"); err != nil {
+		t.Fatalf("DeleteField() error = %v", err)
+	}
+	if diff := cmp.Diff(map[string]interface{}{"b": 2.0}, m); diff != "" {
+		t.Errorf("DeleteField() mismatch: %s", diff)
+	}
+
+	n := &mutateNode{Name: "a", Size: 2}
+	if err := DeleteField(Reflect(n), "Size"); err != nil {
+		t.Fatalf("DeleteField() error = %v", err)
+	}
+	if diff := cmp.Diff(&mutateNode{Name: "a"}, n); diff != "" {
+		t.Errorf("DeleteField() mismatch: %s", diff)
+	}
+
+	if err := DeleteField(Reflect([]int{1}), "a"); !errors.Is(err, ErrNotModifiable) {
+		t.Errorf("DeleteField() error = %v, want %v", err, ErrNotModifiable)
+	}
+}
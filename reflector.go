@@ -1,6 +1,7 @@
 package lookup
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 )
@@ -156,11 +157,17 @@ func Reflect(i interface{}) Pathor {
 	}
 }
 
+// IsString is false for a json.Number, which is a number despite being a string underneath.
 func (r *Reflector) IsString() bool {
-	return r.v.Kind() == reflect.String
+	return r.v.Kind() == reflect.String && r.v.Type() != jsonNumberType
 }
 
+// IsInt is true for a json.Number holding an integer within the range of an int64.
 func (r *Reflector) IsInt() bool {
+	if n, ok := r.jsonNumber(); ok {
+		_, ok = jsonInt(n)
+		return ok
+	}
 	k := r.v.Kind()
 	return k == reflect.Int || k == reflect.Int8 || k == reflect.Int16 || k == reflect.Int32 || k == reflect.Int64
 }
@@ -169,11 +176,23 @@ func (r *Reflector) IsBool() bool {
 	return r.v.Kind() == reflect.Bool
 }
 
+// IsFloat is true for any json.Number, as JSON numbers are all floating point, so one holding an integer is both.
 func (r *Reflector) IsFloat() bool {
+	if _, ok := r.jsonNumber(); ok {
+		return true
+	}
 	k := r.v.Kind()
 	return k == reflect.Float32 || k == reflect.Float64
 }
 
+// jsonNumber is the value when it's a json.Number, as numbers are when JSON is decoded with UseNumber.
+func (r *Reflector) jsonNumber() (json.Number, bool) {
+	if r.v.IsValid() && r.v.Type() == jsonNumberType {
+		return json.Number(r.v.String()), true
+	}
+	return "", false
+}
+
 func (r *Reflector) IsSlice() bool {
 	k := r.v.Kind()
 	return k == reflect.Slice || k == reflect.Array
@@ -214,7 +233,11 @@ func (r *Reflector) AsString() (string, error) {
 }
 
 func (r *Reflector) AsInt() (int64, error) {
-	if r.IsInt() {
+	if n, ok := r.jsonNumber(); ok {
+		if i, ok := jsonInt(n); ok {
+			return i, nil
+		}
+	} else if r.IsInt() {
 		return r.v.Int(), nil
 	}
 	return 0, fmt.Errorf("path %s: %w", r.Path(), ErrNotInt)
@@ -228,7 +251,11 @@ func (r *Reflector) AsBool() (bool, error) {
 }
 
 func (r *Reflector) AsFloat() (float64, error) {
-	if r.IsFloat() {
+	if n, ok := r.jsonNumber(); ok {
+		if f, err := n.Float64(); err == nil {
+			return f, nil
+		}
+	} else if r.IsFloat() {
 		return r.v.Float(), nil
 	}
 	return 0.0, fmt.Errorf("path %s: %w", r.Path(), ErrNotFloat)
@@ -236,6 +263,11 @@ func (r *Reflector) AsFloat() (float64, error) {
 
 func (r *Reflector) AsSlice() ([]interface{}, error) {
 	if r.IsSlice() {
+		if s, ok := r.v.Interface().([]interface{}); ok {
+			res := make([]interface{}, len(s))
+			copy(res, s)
+			return res, nil
+		}
 		// Convert slice to []interface{}
 		l := r.v.Len()
 		res := make([]interface{}, l)
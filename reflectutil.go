@@ -1,8 +1,10 @@
 package lookup
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"iter"
 	"reflect"
 	"strconv"
 	"strings"
@@ -13,35 +15,47 @@ import (
 // function extracts all matches from the array and puts them into a type matched array if possible otherwise a generic
 // []interface{} map.
 func arrayOrSliceForEachPath(prefix string, paths []string, v reflect.Value, runners []Runner, scope *Scope) Pathor {
-	typeCount := map[reflect.Type]int{}
-	type Pair struct {
-		Boxed   Pathor
-		Unboxed Pathor
-	}
-	result := make([]*Pair, 0, v.Len())
-	for i := 0; i < v.Len(); i++ {
-		p := prefix + fmt.Sprintf("[%d]", i)
-		vi := v.Index(i)
-		var boxed Pathor
-		// Check if the element itself implements Finder/Pathor
-		if vi.CanInterface() {
-			if f, ok := vi.Interface().(Finder); ok {
-				// It's already a Finder/Pathor (most likely Interfaceor or Reflector)
-				// If it is a Finder, we can just call Find on it.
-				// But we need a Pathor for 'Boxed'. Pathor includes Finder.
-				if parthor, ok := f.(Pathor); ok {
-					boxed = parthor
+	elements := func(yield func(int, Pathor) bool) {
+		for i := 0; i < v.Len(); i++ {
+			vi := v.Index(i)
+			var boxed Pathor
+			// Check if the element itself implements Finder/Pathor
+			if vi.CanInterface() {
+				if f, ok := vi.Interface().(Finder); ok {
+					// It's already a Finder/Pathor (most likely Interfaceor or Reflector)
+					// If it is a Finder, we can just call Find on it.
+					// But we need a Pathor for 'Boxed'. Pathor includes Finder.
+					if parthor, ok := f.(Pathor); ok {
+						boxed = parthor
+					}
 				}
 			}
-		}
 
-		if boxed == nil {
-			boxed = &Reflector{
-				path: p,
-				v:    vi,
+			if boxed == nil {
+				boxed = &Reflector{
+					path: prefix + fmt.Sprintf("[%d]", i),
+					v:    vi,
+				}
+			}
+			if !yield(i, boxed) {
+				return
 			}
 		}
+	}
+	return forEachElementPath(prefix, paths, v.Type(), v.Len(), elements, runners, scope)
+}
 
+// forEachElementPath is arrayOrSliceForEachPath over elements given one at a time, such as those read from a
+// JsonStream, of an array of type at. Only the matches are kept.
+func forEachElementPath(prefix string, paths []string, at reflect.Type, size int, elements iter.Seq2[int, Pathor], runners []Runner, scope *Scope) Pathor {
+	typeCount := map[reflect.Type]int{}
+	type Pair struct {
+		Boxed   Pathor
+		Unboxed Pathor
+	}
+	result := make([]*Pair, 0, size)
+	for i, boxed := range elements {
+		p := prefix + fmt.Sprintf("[%d]", i)
 		vipath := &Pair{
 			Boxed: boxed,
 		}
@@ -89,7 +103,6 @@ func arrayOrSliceForEachPath(prefix string, paths []string, v reflect.Value, run
 		typeCount[t] += 1
 	}
 	boxing := true
-	at := v.Type()
 	p := prefix + "[*]"
 	for _, path := range paths {
 		if len(path) > 0 {
@@ -205,13 +218,18 @@ func arrayOrSlicePath(prefix string, path interface{}, v reflect.Value) Pathor {
 	}
 }
 
+// keyPath is the path of the value of a map's key.
+func keyPath(prefix string, key string) string {
+	if prefix == "" || strings.HasSuffix(prefix, ".") {
+		return prefix + strconv.Quote(key)
+	}
+	return prefix + "." + strconv.Quote(key)
+}
+
 // mapPath attempts to convert the path to the appropriate from of key if it can be determined then look up the value
 // and return it.
 func mapPath(prefix string, path string, v reflect.Value) Pathor {
-	p := prefix + "." + strconv.Quote(path)
-	if prefix == "" || strings.HasSuffix(prefix, ".") {
-		p = prefix + strconv.Quote(path)
-	}
+	p := keyPath(prefix, path)
 	//p := prefix + "[\"" + strconv.Quote(path) + "\"]"
 	k, pather := extractKey(path, v, p)
 	if pather != nil {
@@ -489,7 +507,7 @@ func elementOf(v reflect.Value, in reflect.Value, pv *reflect.Value) bool {
 	case reflect.Array:
 		for i := 0; i < in.Len(); i++ {
 			f := in.Index(i)
-			if reflect.DeepEqual(v.Interface(), f.Interface()) {
+			if elementEqual(v.Interface(), f.Interface()) {
 				return true
 			}
 		}
@@ -499,7 +517,7 @@ func elementOf(v reflect.Value, in reflect.Value, pv *reflect.Value) bool {
 	case reflect.Map:
 		for _, k := range in.MapKeys() {
 			f := in.MapIndex(k)
-			if reflect.DeepEqual(v.Interface(), f.Interface()) {
+			if elementEqual(v.Interface(), f.Interface()) {
 				return true
 			}
 		}
@@ -547,7 +565,7 @@ func elementOf(v reflect.Value, in reflect.Value, pv *reflect.Value) bool {
 			case []interface{}:
 				val := v.Interface()
 				for _, x := range s {
-					if reflect.DeepEqual(val, x) {
+					if elementEqual(val, x) {
 						return true
 					}
 				}
@@ -556,14 +574,14 @@ func elementOf(v reflect.Value, in reflect.Value, pv *reflect.Value) bool {
 		}
 		for i := 0; i < in.Len(); i++ {
 			f := in.Index(i)
-			if reflect.DeepEqual(v.Interface(), f.Interface()) {
+			if elementEqual(v.Interface(), f.Interface()) {
 				return true
 			}
 		}
 	case reflect.Struct:
 		for i := 0; i < in.NumField(); i++ {
 			f := in.Field(i)
-			if reflect.DeepEqual(v.Interface(), f.Interface()) {
+			if elementEqual(v.Interface(), f.Interface()) {
 				return true
 			}
 		}
@@ -580,11 +598,44 @@ func elementOf(v reflect.Value, in reflect.Value, pv *reflect.Value) bool {
 			}
 		}
 	default:
-		return reflect.DeepEqual(v.Interface(), in.Interface())
+		return elementEqual(v.Interface(), in.Interface())
 	}
 	return false
 }
 
+// elementEqual compares a value with an element for elementOf. A json.Number, as decoded with UseNumber, equals any
+// number of the same value, as it does with Equals, integers being compared exactly.
+func elementEqual(a, b interface{}) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	_, okA := a.(json.Number)
+	_, okB := b.(json.Number)
+	if !okA && !okB {
+		return false
+	}
+	if ia, ok := exactInt(a); ok {
+		if ib, ok := exactInt(b); ok {
+			return ia == ib
+		}
+	}
+	fa, errA := interfaceToFloat(a)
+	fb, errB := interfaceToFloat(b)
+	return errA == nil && errB == nil && fa == fb
+}
+
+// exactInt is an integer, or a json.Number holding one, as an int64.
+func exactInt(i interface{}) (int64, bool) {
+	if n, ok := i.(json.Number); ok {
+		return jsonInt(n)
+	}
+	switch v := reflect.ValueOf(i); v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	}
+	return 0, false
+}
+
 func interfaceToInt(i interface{}) (int, error) {
 	switch i := i.(type) {
 	case int:
@@ -613,6 +664,9 @@ func interfaceToInt(i interface{}) (int, error) {
 		return int(i), nil
 	case float64:
 		return int(i), nil
+	case json.Number:
+		n, err := i.Int64()
+		return int(n), err
 	}
 	return 0, errors.New("unknown number type")
 }
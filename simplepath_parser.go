@@ -1,25 +1,59 @@
 package lookup
 
-import "strings"
+import (
+	"reflect"
+	"strings"
+)
 
 // ParseSimplePath converts a simple query string like "A.B[0].C" into a Relator
 // which can be run against any Pathor. The supported syntax only understands
 // dot separated field lookups and integer based indexes using square brackets.
+// Names containing dots, brackets or spaces can be quoted at the start of a
+// segment, `A."first name"`, `A['content-type']` or `A["a.b"]`, and within the
+// quotes a backslash escapes the character following it. Anywhere else quotes
+// and backslashes are part of the name, so `it's` is the field "it's".
 func ParseSimplePath(query string) *Relator {
 	r := NewRelator()
 	token := strings.Builder{}
+	quoted := false
+	find := func(name string) {
+		if name == "" {
+			r = r.Find("", emptyKey{})
+			return
+		}
+		r = r.Find(name)
+	}
+	flush := func() {
+		if token.Len() > 0 || quoted {
+			find(token.String())
+			token.Reset()
+			quoted = false
+		}
+	}
+	segmentStart := true
 	for i := 0; i < len(query); {
-		switch query[i] {
-		case '.':
-			if token.Len() > 0 {
-				r = r.Find(token.String())
-				token.Reset()
-			}
+		c := query[i]
+		switch {
+		case c == '.':
+			flush()
 			i++
-		case '[':
-			if token.Len() > 0 {
-				r = r.Find(token.String())
-				token.Reset()
+			segmentStart = true
+			continue
+		case segmentStart && (c == '"' || c == '\''):
+			name, n := unquoteSimplePath(query[i:])
+			token.WriteString(name)
+			quoted = true
+			i += n
+		case c == '[':
+			flush()
+			// A quoted name in brackets, `["first name"]`
+			if i+1 < len(query) && (query[i+1] == '"' || query[i+1] == '\'') {
+				name, n := unquoteSimplePath(query[i+1:])
+				if end := i + 1 + n; end < len(query) && query[end] == ']' {
+					find(name)
+					i = end + 1
+					break
+				}
 			}
 			j := strings.IndexByte(query[i:], ']')
 			if j == -1 {
@@ -32,16 +66,52 @@ func ParseSimplePath(query string) *Relator {
 			r = r.Find("", Index(idx))
 			i += j + 1
 		default:
-			token.WriteByte(query[i])
+			token.WriteByte(c)
 			i++
 		}
+		segmentStart = false
 	}
-	if token.Len() > 0 {
-		r = r.Find(token.String())
-	}
+	flush()
 	return r
 }
 
+// emptyKey looks up the empty key "", which Find can't as an empty path is the
+// current value.
+type emptyKey struct{}
+
+func (emptyKey) Run(scope *Scope) Pathor {
+	p := keyPath(ExtractPath(scope.Current), "")
+	m, err := scope.Current.AsMap()
+	if err != nil {
+		return NewInvalidor(p, err)
+	}
+	v, ok := m[""]
+	if !ok {
+		return NewInvalidor(p, ErrNoSuchPath)
+	}
+	return &Reflector{path: p, v: reflect.ValueOf(v)}
+}
+
+// unquoteSimplePath reads the quoted string s starts with, resulting in its
+// contents and the number of bytes read including the quotes. A backslash
+// escapes the character following it. An unterminated string runs to the end.
+func unquoteSimplePath(s string) (string, int) {
+	quote := s[0]
+	name := strings.Builder{}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case quote:
+			return name.String(), i + 1
+		case '\\':
+			if i+1 < len(s) {
+				i++
+			}
+		}
+		name.WriteByte(s[i])
+	}
+	return name.String(), len(s)
+}
+
 // QuerySimplePath executes the given simple path query string against the
 // provided value using reflection.
 func QuerySimplePath(v interface{}, query string) Pathor {
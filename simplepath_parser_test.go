@@ -51,3 +51,51 @@ func TestParseUnmatchedBracket(t *testing.T) {
 	res := QuerySimplePath(root, "A.B[0.C")
 	assert.IsType(t, &Invalidor{}, res)
 }
+
+func TestQueryQuotedNames(t *testing.T) {
+	root := map[string]interface{}{
+		"first name":   "Fred",
+		"content-type": "text/plain",
+		"a.b":          map[string]interface{}{"c": 1},
+		"a":            map[string]interface{}{"b": 2},
+		"x[0]":         3,
+		`say "hi"`:     4,
+		"it's":         6,
+		`a\b`:          7,
+		"":             8,
+		`"x"`:          9,
+		`x"y"`:         10,
+		"items":        []interface{}{map[string]interface{}{"the id": 5}},
+	}
+
+	tests := []struct {
+		query    string
+		expected interface{}
+	}{
+		{`"first name"`, "Fred"},
+		{`'first name'`, "Fred"},
+		{`content-type`, "text/plain"},
+		{`"a.b".c`, 1},
+		{`a.b`, 2},
+		{`["a.b"].c`, 1},
+		{`['a.b']["c"]`, 1},
+		{`"x[0]"`, 3},
+		{`"say \"hi\""`, 4},
+		{`'say "hi"'`, 4},
+		{`items[0]."the id"`, 5},
+		{`items[0]["the id"]`, 5},
+		{`it's`, 6},
+		{`"it's"`, 6},
+		{`a\b`, 7},
+		{`""`, 8},
+		{`[""]`, 8},
+		{`x"y"`, 10},
+		{`'"x"'`, 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			assert.Equal(t, tt.expected, QuerySimplePath(root, tt.query).Raw())
+		})
+	}
+}
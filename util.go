@@ -1,6 +1,7 @@
 package lookup
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 )
@@ -74,6 +75,8 @@ func interfaceToFloat(i interface{}) (float64, error) {
 		return float64(v), nil
 	case float64:
 		return v, nil
+	case json.Number:
+		return v.Float64()
 	case reflect.Value:
 		if v.CanInterface() {
 			return interfaceToFloat(v.Interface())
@@ -116,6 +119,13 @@ func ToInt(i interface{}) (int64, bool) {
 		return int64(v), true
 	case float64:
 		return int64(v), true
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n, true
+		}
+		if f, err := v.Float64(); err == nil {
+			return int64(f), true
+		}
 	case reflect.Value:
 		if v.CanInterface() {
 			return ToInt(v.Interface())
@@ -123,3 +133,12 @@ func ToInt(i interface{}) (int64, bool) {
 	}
 	return 0, false
 }
+
+// jsonNumberType is the type of numbers decoded from JSON with UseNumber.
+var jsonNumberType = reflect.TypeOf(json.Number(""))
+
+// jsonInt is a json.Number as an int64, ok when it's an integer an int64 holds.
+func jsonInt(n json.Number) (int64, bool) {
+	i, err := n.Int64()
+	return i, err == nil
+}
@@ -1,22 +1,72 @@
 package lookup
 
 import (
+	"io"
+	"io/fs"
 	"reflect"
 
 	"gopkg.in/yaml.v3"
 )
 
-// Yamlor is a Pathor that lazily unmarshals YAML bytes when accessed.
+// Yamlor is a Pathor that lazily unmarshals YAML bytes when accessed. Created with UseNodes it navigates the yaml.Node
+// tree of the document instead, so whatever it finds knows where in the document it is and the comments about it.
 type Yamlor struct {
-	path string
-	raw  []byte
-	p    Pathor
-	done bool
+	path   string
+	raw    []byte
+	p      Pathor
+	done   bool
+	opts   yamlOptions
+	node   *yaml.Node // the node of the value when navigating nodes, once parsed
+	key    *yaml.Node // the node of the key the value is of, if it's the value of a field
+	parsed bool
+	err    error // the error parsing the document failed with, if any
+}
+
+// YamlOption configures how Yaml and the other YAML constructors navigate documents.
+type YamlOption func(*yamlOptions)
+
+type yamlOptions struct {
+	useNodes bool
+}
+
+// UseNodes navigates the yaml.Node tree of a document rather than the values it decodes to. The fields and elements
+// found are Yamlors which know their Position in the document and the comments about them, and tags, anchors and
+// aliases are kept in their Node. Values are decoded as they would be otherwise when they're used.
+func UseNodes() YamlOption {
+	return func(o *yamlOptions) {
+		o.useNodes = true
+	}
+}
+
+func newYamlOptions(opts []YamlOption) yamlOptions {
+	var o yamlOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
 }
 
 // Yaml creates a Pathor for navigating raw YAML data.
-func Yaml(raw []byte) Pathor {
-	return &Yamlor{raw: raw}
+func Yaml(raw []byte, opts ...YamlOption) Pathor {
+	return &Yamlor{raw: raw, opts: newYamlOptions(opts)}
+}
+
+// YamlReader creates a Pathor for navigating the YAML document read from r. The options are those of Yaml.
+func YamlReader(r io.Reader, opts ...YamlOption) Pathor {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return NewInvalidor("", err)
+	}
+	return Yaml(raw, opts...)
+}
+
+// YamlFile creates a Pathor for navigating the YAML document in the named file of fsys.
+func YamlFile(fsys fs.FS, name string, opts ...YamlOption) Pathor {
+	raw, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return NewInvalidor("", err)
+	}
+	return Yaml(raw, opts...)
 }
 
 // Path returns the current lookup path.
@@ -28,7 +78,13 @@ func (y *Yamlor) ensure() Pathor {
 	}
 	y.done = true
 	var v interface{}
-	if err := yaml.Unmarshal(y.raw, &v); err != nil {
+	var err error
+	if y.opts.useNodes {
+		err = y.decodeNode(&v)
+	} else {
+		err = yaml.Unmarshal(y.raw, &v)
+	}
+	if err != nil {
 		y.p = NewInvalidor(y.path, err)
 	} else {
 		y.p = &Reflector{path: y.path, v: reflect.ValueOf(v)}
@@ -36,9 +92,26 @@ func (y *Yamlor) ensure() Pathor {
 	return y.p
 }
 
-// Find navigates the YAML structure using Reflector after decoding.
+// Find navigates the YAML structure using Reflector after decoding, or when navigating nodes finds the fields of
+// mappings in the nodes.
 func (y *Yamlor) Find(path string, opts ...Runner) Pathor {
-	return y.ensure().Find(path, opts...)
+	var rr Pathor
+	switch m := y.mapping(); {
+	case path == "":
+		rr = y
+	case m != nil:
+		rr = y.field(m, path)
+	default:
+		rr = y.ensure().Find(path)
+	}
+	p := ExtractPath(rr)
+	for _, runner := range opts {
+		rr = runner.Run(NewScope(y, rr))
+		if rr == nil {
+			rr = NewInvalidor(p, ErrEvalFail)
+		}
+	}
+	return rr
 }
 
 // Raw returns the decoded value.
@@ -0,0 +1,200 @@
+package lookup
+
+import (
+	"fmt"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YamlNode creates a Pathor navigating a yaml.Node tree already parsed, as Yaml does with UseNodes. A document node is
+// navigated from the value it holds.
+func YamlNode(node *yaml.Node) Pathor {
+	return &Yamlor{opts: yamlOptions{useNodes: true}, node: documentValue(node), parsed: true}
+}
+
+// YamlPosition is the line and column, counted from 1, at which the value a Pathor found in a YAML document navigated
+// with UseNodes starts. ok is false for anything else, such as the default used for a field which wasn't found.
+func YamlPosition(p Pathor) (line, column int, ok bool) {
+	y, isYaml := p.(*Yamlor)
+	if !isYaml || y.Node() == nil {
+		return 0, 0, false
+	}
+	line, column = y.Position()
+	return line, column, true
+}
+
+// Node is the node of the value when navigating nodes, nil when not or when the document is empty. The node of an
+// alias is the alias rather than the node it refers to.
+func (y *Yamlor) Node() *yaml.Node {
+	n, _ := y.parse()
+	return n
+}
+
+// KeyNode is the node of the key of the field the value was found as, nil for a value which isn't the value of a field.
+func (y *Yamlor) KeyNode() *yaml.Node { return y.key }
+
+// Position is the line and column, counted from 1, at which the value starts in the document, or 0, 0 when not
+// navigating nodes. A block mapping or sequence starts at its first entry, the KeyNode of a field being where the field
+// is.
+func (y *Yamlor) Position() (line, column int) {
+	n := y.Node()
+	if n == nil {
+		return 0, 0
+	}
+	return n.Line, n.Column
+}
+
+// HeadComment is the comment on the lines before the value, which for the value of a field are the lines before its
+// key.
+func (y *Yamlor) HeadComment() string {
+	if y.key != nil && y.key.HeadComment != "" {
+		return y.key.HeadComment
+	}
+	if n := y.Node(); n != nil {
+		return n.HeadComment
+	}
+	return ""
+}
+
+// LineComment is the comment at the end of the line the value, or the key of a field, is on.
+func (y *Yamlor) LineComment() string {
+	if n := y.Node(); n != nil && n.LineComment != "" {
+		return n.LineComment
+	}
+	if y.key != nil {
+		return y.key.LineComment
+	}
+	return ""
+}
+
+// parse is the node of the value when navigating nodes, parsing the document the first time it's needed.
+func (y *Yamlor) parse() (*yaml.Node, error) {
+	if !y.opts.useNodes || y.parsed {
+		return y.node, y.err
+	}
+	y.parsed = true
+	var doc yaml.Node
+	if err := yaml.Unmarshal(y.raw, &doc); err != nil {
+		y.err = err
+		return nil, err
+	}
+	y.node = documentValue(&doc)
+	return y.node, nil
+}
+
+// decodeNode decodes the value of the node into v, leaving v nil for an empty document.
+func (y *Yamlor) decodeNode(v interface{}) error {
+	n, err := y.parse()
+	if err != nil || n == nil {
+		return err
+	}
+	return n.Decode(v)
+}
+
+// mapping is the mapping node of the value when navigating nodes and the value is a mapping.
+func (y *Yamlor) mapping() *yaml.Node {
+	n, err := y.parse()
+	if err != nil || n == nil {
+		return nil
+	}
+	if n = resolveAlias(n); n.Kind != yaml.MappingNode {
+		return nil
+	}
+	return n
+}
+
+// field finds a field of a mapping, as Reflector finds the value of a map's key.
+func (y *Yamlor) field(m *yaml.Node, name string) Pathor {
+	p := keyPath(y.path, name)
+	key, value, err := mappingValue(m, name)
+	if err != nil {
+		return NewInvalidor(p, err)
+	}
+	if value == nil {
+		return &Invalidor{
+			err:  fmt.Errorf("element not found at simple path %s element was %s expected %s", p, reflect.Map, reflect.String),
+			path: p,
+		}
+	}
+	return &Yamlor{path: p, opts: y.opts, node: value, key: key, parsed: true}
+}
+
+// index finds an element of a sequence for Index, leaving any other value to reflection.
+func (y *Yamlor) index(i int64) (Pathor, bool) {
+	n, err := y.parse()
+	if err != nil || n == nil {
+		return nil, false
+	}
+	n = resolveAlias(n)
+	if n.Kind != yaml.SequenceNode {
+		return nil, false
+	}
+	p := fmt.Sprintf("%s[%d]", y.path, i)
+	if i < 0 {
+		i += int64(len(n.Content))
+	}
+	if i < 0 || i >= int64(len(n.Content)) {
+		return nil, false
+	}
+	return &Yamlor{path: p, opts: y.opts, node: n.Content[i], parsed: true}, true
+}
+
+// mappingValue finds the key and value nodes of a field of a mapping, looking in the mappings merged into it with "<<"
+// for a field which isn't one of its own. A field occurring more than once is an error, as yaml.Unmarshal reports it.
+func mappingValue(m *yaml.Node, name string) (key, value *yaml.Node, err error) {
+	var merges []*yaml.Node
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		k, v := m.Content[i], m.Content[i+1]
+		switch {
+		case k.Kind != yaml.ScalarNode:
+		case k.ShortTag() == "!!merge":
+			merges = append(merges, resolveAlias(v))
+		case k.Value != name:
+		case key != nil:
+			return nil, nil, fmt.Errorf("yaml: line %d: mapping key %q already defined at line %d", k.Line, name, key.Line)
+		default:
+			key, value = k, v
+		}
+	}
+	if value != nil {
+		return key, value, nil
+	}
+	for _, merge := range merges {
+		merged := []*yaml.Node{merge}
+		if merge.Kind == yaml.SequenceNode {
+			merged = merge.Content
+		}
+		for _, mm := range merged {
+			if mm = resolveAlias(mm); mm.Kind == yaml.MappingNode {
+				if key, value, err = mappingValue(mm, name); err != nil || value != nil {
+					return key, value, err
+				}
+			}
+		}
+	}
+	return nil, nil, nil
+}
+
+// resolveAlias is the node an alias refers to, or the node itself when it isn't an alias.
+func resolveAlias(n *yaml.Node) *yaml.Node {
+	for n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	return n
+}
+
+// documentValue is the node of the value a document holds, nil for an empty document, or the node itself when it
+// isn't a document.
+func documentValue(n *yaml.Node) *yaml.Node {
+	if n == nil || n.Kind == 0 {
+		return nil
+	}
+	if n.Kind != yaml.DocumentNode {
+		return n
+	}
+	if len(n.Content) == 0 {
+		return nil
+	}
+	return n.Content[0]
+}
@@ -1,9 +1,12 @@
 package lookup
 
 import (
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
 )
 
 func TestYamlorBasic(t *testing.T) {
@@ -14,3 +17,91 @@ func TestYamlorBasic(t *testing.T) {
 	assert.Equal(t, 2, r.Find("list", Index(1)).Raw())
 	assert.Equal(t, "def", r.Find("missing", Default("def")).Raw())
 }
+
+func TestYamlReaderAndFile(t *testing.T) {
+	data := "name: root\nchild:\n  size: 10\n"
+	assert.Equal(t, 10, YamlReader(strings.NewReader(data)).Find("child").Find("size").Raw())
+
+	fsys := fstest.MapFS{"doc.yaml": {Data: []byte(data)}}
+	assert.Equal(t, "root", YamlFile(fsys, "doc.yaml").Find("name").Raw())
+	assert.IsType(t, &Invalidor{}, YamlFile(fsys, "missing.yaml"))
+}
+
+func TestYamlorUseNodes(t *testing.T) {
+	data := []byte(`# The deployment
+defaults: &defaults
+  image: app:1.0
+spec:
+  # How many pods
+  replicas: 3 # at least two
+  containers:
+    - name: web
+      <<: *defaults
+    - name: sidecar
+      image: proxy:2.1
+`)
+	r := Yaml(data, UseNodes())
+
+	t.Run("Values decode as without nodes", func(t *testing.T) {
+		assert.Equal(t, 3, r.Find("spec").Find("replicas").Raw())
+		assert.Equal(t, Yaml(data).Find("spec").Raw(), r.Find("spec").Raw())
+		assert.Equal(t, "def", r.Find("missing", Default("def")).Raw())
+		assert.True(t, r.Find("spec").IsMap())
+	})
+
+	t.Run("Positions", func(t *testing.T) {
+		replicas := r.Find("spec").Find("replicas")
+		line, column, ok := YamlPosition(replicas)
+		assert.True(t, ok)
+		assert.Equal(t, 6, line)
+		assert.Equal(t, 13, column)
+		assert.Equal(t, `"spec"."replicas"`, ExtractPath(replicas))
+
+		image := r.Find("spec").Find("containers", Index(-1)).Find("image")
+		line, column, ok = YamlPosition(image)
+		assert.True(t, ok)
+		assert.Equal(t, 11, line)
+		assert.Equal(t, 14, column)
+		assert.Equal(t, "proxy:2.1", image.Raw())
+	})
+
+	t.Run("Comments", func(t *testing.T) {
+		replicas := r.Find("spec").Find("replicas").(*Yamlor)
+		assert.Equal(t, "# How many pods", replicas.HeadComment())
+		assert.Equal(t, "# at least two", replicas.LineComment())
+		assert.Equal(t, "replicas", replicas.KeyNode().Value)
+	})
+
+	t.Run("Merged fields are found at their anchor", func(t *testing.T) {
+		image := r.Find("spec").Find("containers", Index(0)).Find("image")
+		assert.Equal(t, "app:1.0", image.Raw())
+		line, _, _ := YamlPosition(image)
+		assert.Equal(t, 3, line)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		_, _, ok := YamlPosition(r.Find("spec").Find("missing", Default(1)))
+		assert.False(t, ok)
+		assert.IsType(t, &Invalidor{}, r.Find("spec").Find("missing"))
+		_, _, ok = YamlPosition(Yaml(data).Find("spec"))
+		assert.False(t, ok)
+	})
+
+	t.Run("Existing nodes and invalid documents", func(t *testing.T) {
+		var doc yaml.Node
+		assert.NoError(t, yaml.Unmarshal(data, &doc))
+		spec := YamlNode(&doc).Find("spec")
+		line, _, ok := YamlPosition(spec)
+		assert.True(t, ok)
+		assert.Equal(t, 6, line, "a mapping starts at its first key")
+		assert.Equal(t, 4, spec.(*Yamlor).KeyNode().Line)
+		assert.IsType(t, &Invalidor{}, Yaml([]byte("a: [1"), UseNodes()).Find("a"))
+		assert.Nil(t, Yaml(nil, UseNodes()).Raw())
+
+		// A field occurring more than once is an error, as decoding the document reports it
+		dup := Yaml([]byte("a: 1\nb: 2\na: 3\n"), UseNodes())
+		assert.IsType(t, &Invalidor{}, dup.Find("a"))
+		assert.Equal(t, 2, dup.Find("b").Raw())
+		assert.IsType(t, &Invalidor{}, Yaml([]byte("a: 1\na: 3\n")).Find("a"))
+	})
+}